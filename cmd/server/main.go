@@ -24,9 +24,15 @@ import (
 	"goapitemplate/internal/database"
 	"goapitemplate/internal/events"
 	"goapitemplate/internal/handlers"
+	"goapitemplate/internal/logging"
 	"goapitemplate/internal/middleware"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
@@ -35,42 +41,113 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	db, err := database.New(cfg.Database)
+	logger := logging.Setup(cfg.Logging)
+
+	db, err := database.New(cfg.Database, cfg.Logging.Level)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
-	if err := db.AutoMigrate(); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	if !cfg.Database.AutoMigrate {
+		log.Printf("Auto-migration disabled; expecting migrations to be applied externally (e.g. scripts/migrate.go)")
+	}
+	if err := db.EnsureSchema(cfg.Database.AutoMigrate); err != nil {
+		log.Fatalf("Failed to prepare database schema: %v", err)
+	}
+
+	if len(cfg.Webhooks) > 0 {
+		created, updated, err := db.ReconcileWebhooks(cfg.Webhooks)
+		if err != nil {
+			log.Fatalf("Failed to reconcile configured webhooks: %v", err)
+		}
+		log.Printf("Reconciled configured webhooks: %d created, %d updated", created, updated)
 	}
 
+	// Record spans so trace context reaches webhook receivers; register an
+	// exporter on the provider to ship them to a tracing backend
+	tracerProvider := sdktrace.NewTracerProvider()
+	defer tracerProvider.Shutdown(context.Background())
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
 	var cacheClient cache.Client
 	if cfg.Cache.Enabled {
 		cacheClient, err = cache.New(cfg.Cache)
-		if err != nil {
+		switch {
+		case err == nil:
+			defer cacheClient.Close()
+		case cfg.Cache.FailOpen:
+			// Caching is optional; handlers treat a nil client as a miss
+			logger.WithError(err).Warn("Failed to connect to cache, running without caching")
+			cacheClient = nil
+		default:
 			log.Fatalf("Failed to connect to cache: %v", err)
 		}
-		defer cacheClient.Close()
 	}
 
-	eventStore := events.NewDBEventStore(db)
-	eventManager := events.NewManager(eventStore, db)
+	var eventStore events.EventStore
+	switch cfg.Events.Backend {
+	case "kafka":
+		// Events are still saved to the database, which webhook deliveries
+		// and the read endpoints rely on
+		kafkaStore := events.NewKafkaEventStore(cfg.Events.Kafka, events.NewDBEventStore(db))
+		defer kafkaStore.Close()
+		eventStore = kafkaStore
+	default:
+		eventStore = events.NewDBEventStore(db)
+	}
+	eventManager := events.NewManager(eventStore, db, logger)
+	eventManager.SetRequirePersistence(cfg.Events.RequirePersistence)
+	eventManager.GetWebhookDeliveryService().SetMaxResponseBytes(cfg.Webhook.MaxResponseBytes)
+	eventManager.GetWebhookDeliveryService().SetURLGuard(events.NewURLGuard(cfg.Webhook.AllowPrivate))
+	eventManager.GetWebhookDeliveryService().SetMaxConcurrentDeliveries(cfg.Webhook.MaxConcurrentDeliveries)
+	eventManager.GetWebhookDeliveryService().SetUserAgent(cfg.Webhook.UserAgent)
+	eventManager.GetWebhookDeliveryService().SetMaxPayloadBytes(cfg.Webhook.MaxPayloadBytes)
+	if err := eventManager.GetWebhookDeliveryService().SetProxy(cfg.Webhook.HTTPProxy); err != nil {
+		logger.WithError(err).Fatal("Invalid webhook HTTP proxy")
+	}
+
+	if cfg.NATS.Enabled {
+		natsPublisher, err := events.NewNATSPublisher(cfg.NATS)
+		if err != nil {
+			log.Printf("NATS publisher disabled: %v", err)
+		} else {
+			defer natsPublisher.Close()
+			eventManager.Subscribe(events.AllEventTypes, natsPublisher.Handle)
+		}
+	}
+
+	// CORS and rate limits can be changed at runtime by reloading the config
+	reloadable := middleware.NewReloadableConfig(cfg)
 
 	router := gin.New()
-	router.Use(middleware.Logger())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.LoggerWithConfig(cfg.RequestLog))
 	router.Use(middleware.Recovery())
-	router.Use(middleware.CORS(cfg.CORS))
-	
-	if cfg.RateLimit.Enabled {
-		router.Use(middleware.RateLimit(cfg.RateLimit.MaxRequests, time.Duration(cfg.RateLimit.WindowMinutes)*time.Minute))
+	router.Use(reloadable.CORS(router.Routes))
+	router.Use(middleware.Tracing())
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics())
+		router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
 	}
+	router.Use(middleware.Timeout(time.Duration(cfg.Server.HandlerTimeout) * time.Second))
+	router.Use(reloadable.RateLimit())
 
-	handler := handlers.New(db, cacheClient, eventManager)
+	handler := handlers.New(db, cacheClient, eventManager, cfg, logger)
 	handler.RegisterRoutes(router)
 
 	// Start webhook retry scheduler
 	go startWebhookRetryScheduler(eventManager)
+	go startOutboxDispatcher(eventManager, logger)
+
+	if cfg.Events.RetentionDays > 0 {
+		go startEventRetentionCleanup(db, cfg.Events.RetentionDays, logger)
+	}
+
+	reloader := config.NewReloader(cfg)
+	reloader.OnReload(reloadable.Apply)
+	go reloadOnSIGHUP(reloader)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -100,9 +177,74 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Wait for in-flight webhook deliveries so their records are updated
+	if err := eventManager.GetWebhookDeliveryService().Shutdown(ctx); err != nil {
+		log.Printf("Webhook deliveries did not finish before shutdown: %v", err)
+	}
+
 	log.Println("Server exited")
 }
 
+// reloadOnSIGHUP reloads the hot-reloadable settings (CORS, rate limits, log
+// level) each time the process receives SIGHUP
+func reloadOnSIGHUP(reloader *config.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		restartRequired, err := reloader.Reload()
+		if err != nil {
+			log.Printf("Failed to reload config, keeping current settings: %v", err)
+			continue
+		}
+		for _, section := range restartRequired {
+			log.Printf("Warning: %s settings changed but require a restart to take effect", section)
+		}
+		log.Println("Configuration reloaded")
+	}
+}
+
+// startEventRetentionCleanup deletes events older than the retention window
+// every hour
+func startEventRetentionCleanup(db *database.DB, retentionDays int, logger *logrus.Logger) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+	for ; ; <-ticker.C {
+		eventsDeleted, deliveriesDeleted, err := db.DeleteEventsBefore(time.Now().Add(-retention))
+		if err != nil {
+			logger.WithError(err).Error("Failed to delete expired events")
+			continue
+		}
+		if eventsDeleted > 0 {
+			logger.WithFields(logrus.Fields{
+				"events_deleted":     eventsDeleted,
+				"deliveries_deleted": deliveriesDeleted,
+				"retention_days":     retentionDays,
+			}).Info("Deleted expired events")
+		}
+	}
+}
+
+// startOutboxDispatcher delivers events left in the outbox, e.g. by a crash
+// between saving an event and recording its deliveries. Events are left to
+// their publisher until its outbox lease (database.OutboxLease) runs out.
+func startOutboxDispatcher(eventManager *events.Manager, logger *logrus.Logger) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		dispatched, err := eventManager.GetWebhookDeliveryService().DispatchOutbox(context.Background())
+		if err != nil {
+			logger.WithError(err).Error("Failed to dispatch outbox events")
+		}
+		if dispatched > 0 {
+			logger.WithField("events", dispatched).Warn("Dispatched events left in the outbox")
+		}
+	}
+}
+
 func startWebhookRetryScheduler(eventManager *events.Manager) {
 	ticker := time.NewTicker(1 * time.Minute) // Check for retries every minute
 	defer ticker.Stop()
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -111,4 +112,31 @@ func (m *MemcacheClient) Delete(ctx context.Context, key string) error {
 
 func (m *MemcacheClient) Close() error {
 	return nil
-}
\ No newline at end of file
+}
+
+// GetOrSet returns the JSON cached under key, or calls loader, caches its
+// result for ttl and returns that encoded as JSON. Caching is best effort: a
+// nil client or a failing cache just means the loader is called.
+func GetOrSet(ctx context.Context, client Client, key string, ttl time.Duration, loader func() (interface{}, error)) ([]byte, error) {
+	if client != nil {
+		if cached, err := client.Get(ctx, key); err == nil && cached != "" {
+			return []byte(cached), nil
+		}
+	}
+
+	value, err := loader()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s for caching: %w", key, err)
+	}
+
+	if client != nil {
+		_ = client.Set(ctx, key, string(encoded), ttl)
+	}
+
+	return encoded, nil
+}
@@ -1,38 +1,54 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 )
 
 type Config struct {
-	Server    ServerConfig    `json:"server"`
-	Database  DatabaseConfig  `json:"database"`
-	Cache     CacheConfig     `json:"cache"`
-	Logging   LoggingConfig   `json:"logging"`
-	CORS      CORSConfig      `json:"cors"`
-	RateLimit RateLimitConfig `json:"rate_limit"`
+	Server     ServerConfig          `json:"server"`
+	Database   DatabaseConfig        `json:"database"`
+	Cache      CacheConfig           `json:"cache"`
+	Logging    LoggingConfig         `json:"logging"`
+	RequestLog RequestLogConfig      `json:"request_log"`
+	CORS       CORSConfig            `json:"cors"`
+	RateLimit  RateLimitConfig       `json:"rate_limit"`
+	Admin      AdminConfig           `json:"admin"`
+	Metrics    MetricsConfig         `json:"metrics"`
+	Events     EventsConfig          `json:"events"`
+	NATS       NATSConfig            `json:"nats"`
+	Webhook    WebhookDeliveryConfig `json:"webhook"`
+	Webhooks   []WebhookConfig       `json:"webhooks"`
 }
 
 type ServerConfig struct {
-	Port         int `json:"port"`
-	ReadTimeout  int `json:"read_timeout"`
-	WriteTimeout int `json:"write_timeout"`
-	IdleTimeout  int `json:"idle_timeout"`
+	Port           int `json:"port"`
+	ReadTimeout    int `json:"read_timeout"`
+	WriteTimeout   int `json:"write_timeout"`
+	IdleTimeout    int `json:"idle_timeout"`
+	HandlerTimeout int `json:"handler_timeout"`
 }
 
 type DatabaseConfig struct {
-	Type     string `json:"type"`
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	Database string `json:"database"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	SSLMode  string `json:"ssl_mode"`
-	MaxConns int    `json:"max_conns"`
-	MaxIdle  int    `json:"max_idle"`
+	Type        string `json:"type"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Database    string `json:"database"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	SSLMode     string `json:"ssl_mode"`
+	MaxConns    int    `json:"max_conns"`
+	MaxIdle     int    `json:"max_idle"`
+	AutoMigrate bool   `json:"auto_migrate"` // When false, startup verifies the schema instead of migrating
+	// ReplicaHosts are read replicas that serve SELECT queries; for sqlite
+	// they are database file paths. Writes always go to the primary.
+	ReplicaHosts []string `json:"replica_hosts,omitempty"`
+
+	SlowQueryMs int `json:"slow_query_ms"` // Queries slower than this are logged as warnings; 0 disables it
 }
 
 type CacheConfig struct {
@@ -43,6 +59,9 @@ type CacheConfig struct {
 	Password string `json:"password"`
 	DB       int    `json:"db"`
 	TTL      int    `json:"ttl"`
+	// FailOpen runs the server without caching when the cache can't be
+	// reached at startup, instead of exiting
+	FailOpen bool `json:"fail_open"`
 }
 
 type LoggingConfig struct {
@@ -50,12 +69,26 @@ type LoggingConfig struct {
 	Format string `json:"format"`
 }
 
+// RequestLogConfig controls what the request logger records beyond the
+// request line. Headers and fields named here are redacted in addition to the
+// built-in sensitive ones (Authorization, X-API-Key, secret, password, ...).
+type RequestLogConfig struct {
+	Details       bool     `json:"details"` // Log request headers and JSON bodies
+	RedactHeaders []string `json:"redact_headers"`
+	RedactFields  []string `json:"redact_fields"` // JSON body fields, matched at any depth
+}
+
 type CORSConfig struct {
 	AllowedOrigins   []string `json:"allowed_origins"`
 	AllowedMethods   []string `json:"allowed_methods"`
 	AllowedHeaders   []string `json:"allowed_headers"`
 	AllowCredentials bool     `json:"allow_credentials"`
 	MaxAge           int      `json:"max_age"`
+
+	// Routes overrides the policy for paths starting with each key; the
+	// longest matching prefix wins. Lists and MaxAge left empty in an
+	// override are inherited from this policy.
+	Routes map[string]CORSConfig `json:"routes,omitempty"`
 }
 
 type RateLimitConfig struct {
@@ -64,24 +97,107 @@ type RateLimitConfig struct {
 	WindowMinutes int  `json:"window_minutes"`
 }
 
+type AdminConfig struct {
+	APIKey string `json:"-"`
+}
+
+// MetricsConfig controls the Prometheus HTTP metrics
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // Where the metrics are served, e.g. /metrics
+}
+
+type EventsConfig struct {
+	Backend string      `json:"backend"`
+	Kafka   KafkaConfig `json:"kafka"`
+	// MaxFutureSkewSeconds is how far ahead of the server clock a client
+	// supplied event timestamp may be
+	MaxFutureSkewSeconds int `json:"max_future_skew_seconds"`
+	// RequirePersistence fails event creation when the store can't save the
+	// event; when false the error is logged and the event still reaches
+	// handlers and subscribers, but not webhooks
+	RequirePersistence bool `json:"require_persistence"`
+	// RetentionDays deletes events older than this many days, with their
+	// webhook deliveries; 0 keeps events forever
+	RetentionDays int `json:"retention_days"`
+	// AllowedSources, when set, is the only sources events may be created
+	// with; other sources are rejected with 403
+	AllowedSources []string `json:"allowed_sources"`
+	// GapAlerts publishes a stream.gap_detected event whenever a stream
+	// integrity check finds missing sequence numbers
+	GapAlerts bool `json:"gap_alerts"`
+}
+
+type KafkaConfig struct {
+	Brokers []string `json:"brokers"`
+	Topic   string   `json:"topic"`
+}
+
+type NATSConfig struct {
+	Enabled       bool   `json:"enabled"`
+	URL           string `json:"url"`
+	Stream        string `json:"stream"`
+	SubjectPrefix string `json:"subject_prefix"`
+}
+
+// WebhookDeliveryConfig holds settings shared by all webhook deliveries
+type WebhookDeliveryConfig struct {
+	MaxResponseBytes        int  `json:"max_response_bytes"`        // Receiver response bodies are truncated to this length when stored
+	AllowPrivate            bool `json:"allow_private"`             // Allow webhook URLs on private, loopback and link-local addresses (dev/test only)
+	MaxConcurrentDeliveries int  `json:"max_concurrent_deliveries"` // Size of the delivery worker pool; beyond it deliveries queue, then block publishers
+
+	UserAgent       string `json:"user_agent"`        // User-Agent header sent with every delivery
+	MaxPayloadBytes int    `json:"max_payload_bytes"` // Deliveries with a larger serialized payload fail without being sent
+	HTTPProxy       string `json:"http_proxy"`        // Proxy for deliveries and OAuth token requests (http, https or socks5 URL); webhooks may override it
+}
+
+// WebhookConfig declares a webhook that is reconciled into the database at
+// startup. Key identifies the webhook across restarts.
+// Limits on webhook settings, matching those enforced by the webhooks API
+const (
+	MaxWebhookRetries        = 10
+	MaxWebhookTimeoutSeconds = 120
+)
+
+type WebhookConfig struct {
+	Key            string   `json:"key"`
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	Secret         string   `json:"secret"`
+	EventTypes     []string `json:"event_types"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+	MaxRetries     int      `json:"max_retries"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
 func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadEnvFile(path); err != nil {
+			return nil, fmt.Errorf("invalid config file: %w", err)
+		}
+	}
+
 	config := &Config{
 		Server: ServerConfig{
-			Port:         getEnvInt("SERVER_PORT", 8080),
-			ReadTimeout:  getEnvInt("SERVER_READ_TIMEOUT", 30),
-			WriteTimeout: getEnvInt("SERVER_WRITE_TIMEOUT", 30),
-			IdleTimeout:  getEnvInt("SERVER_IDLE_TIMEOUT", 60),
+			Port:           getEnvInt("SERVER_PORT", 8080),
+			ReadTimeout:    getEnvInt("SERVER_READ_TIMEOUT", 30),
+			WriteTimeout:   getEnvInt("SERVER_WRITE_TIMEOUT", 30),
+			IdleTimeout:    getEnvInt("SERVER_IDLE_TIMEOUT", 60),
+			HandlerTimeout: getEnvInt("SERVER_HANDLER_TIMEOUT", 25),
 		},
 		Database: DatabaseConfig{
-			Type:     getEnvString("DB_TYPE", "postgres"),
-			Host:     getEnvString("DB_HOST", "localhost"),
-			Port:     getEnvInt("DB_PORT", getDefaultDBPort(getEnvString("DB_TYPE", "postgres"))),
-			Database: getEnvString("DB_NAME", "goapitemplate"),
-			Username: getEnvString("DB_USER", "postgres"),
-			Password: getEnvString("DB_PASSWORD", ""),
-			SSLMode:  getEnvString("DB_SSLMODE", "disable"),
-			MaxConns: getEnvInt("DB_MAX_CONNS", 25),
-			MaxIdle:  getEnvInt("DB_MAX_IDLE", 10),
+			Type:         getEnvString("DB_TYPE", "postgres"),
+			Host:         getEnvString("DB_HOST", "localhost"),
+			Port:         getEnvInt("DB_PORT", getDefaultDBPort(getEnvString("DB_TYPE", "postgres"))),
+			Database:     getEnvString("DB_NAME", "goapitemplate"),
+			Username:     getEnvString("DB_USER", "postgres"),
+			Password:     getEnvString("DB_PASSWORD", ""),
+			SSLMode:      getEnvString("DB_SSLMODE", "disable"),
+			MaxConns:     getEnvInt("DB_MAX_CONNS", 25),
+			MaxIdle:      getEnvInt("DB_MAX_IDLE", 10),
+			AutoMigrate:  getEnvBool("DB_AUTO_MIGRATE", true),
+			ReplicaHosts: getEnvList("DB_REPLICA_HOSTS"),
+			SlowQueryMs:  getEnvInt("DB_SLOW_QUERY_MS", 200),
 		},
 		Cache: CacheConfig{
 			Enabled:  getEnvBool("CACHE_ENABLED", false),
@@ -91,11 +207,17 @@ func Load() (*Config, error) {
 			Password: getEnvString("CACHE_PASSWORD", ""),
 			DB:       getEnvInt("CACHE_DB", 0),
 			TTL:      getEnvInt("CACHE_TTL", 3600),
+			FailOpen: getEnvBool("CACHE_FAIL_OPEN", true),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnvString("LOG_LEVEL", "info"),
 			Format: getEnvString("LOG_FORMAT", "json"),
 		},
+		RequestLog: RequestLogConfig{
+			Details:       getEnvBool("LOG_REQUEST_DETAILS", false),
+			RedactHeaders: getEnvList("LOG_REDACT_HEADERS"),
+			RedactFields:  getEnvList("LOG_REDACT_FIELDS"),
+		},
 		CORS: CORSConfig{
 			AllowedOrigins:   strings.Split(getEnvString("CORS_ALLOWED_ORIGINS", "*"), ","),
 			AllowedMethods:   strings.Split(getEnvString("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"), ","),
@@ -108,7 +230,52 @@ func Load() (*Config, error) {
 			MaxRequests:   getEnvInt("RATE_LIMIT_MAX_REQUESTS", 100),
 			WindowMinutes: getEnvInt("RATE_LIMIT_WINDOW_MINUTES", 1),
 		},
+		Admin: AdminConfig{
+			APIKey: getEnvString("ADMIN_API_KEY", ""),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("METRICS_ENABLED", false),
+			Path:    getEnvString("METRICS_PATH", "/metrics"),
+		},
+		Events: EventsConfig{
+			Backend:              getEnvString("EVENTS_BACKEND", "db"),
+			MaxFutureSkewSeconds: getEnvInt("EVENTS_MAX_FUTURE_SKEW_SECONDS", 86400),
+			RequirePersistence:   getEnvBool("EVENTS_REQUIRE_PERSISTENCE", true),
+			RetentionDays:        getEnvInt("EVENTS_RETENTION_DAYS", 0),
+			AllowedSources:       getEnvList("EVENTS_ALLOWED_SOURCES"),
+			GapAlerts:            getEnvBool("EVENTS_GAP_ALERTS", false),
+			Kafka: KafkaConfig{
+				Brokers: strings.Split(getEnvString("KAFKA_BROKERS", "localhost:9092"), ","),
+				Topic:   getEnvString("KAFKA_TOPIC", "events"),
+			},
+		},
+		NATS: NATSConfig{
+			Enabled:       getEnvBool("NATS_ENABLED", false),
+			URL:           getEnvString("NATS_URL", "nats://localhost:4222"),
+			Stream:        getEnvString("NATS_STREAM", "EVENTS"),
+			SubjectPrefix: getEnvString("NATS_SUBJECT_PREFIX", "events"),
+		},
+		Webhook: WebhookDeliveryConfig{
+			MaxResponseBytes:        getEnvInt("WEBHOOK_MAX_RESPONSE_BYTES", 1000),
+			AllowPrivate:            getEnvBool("WEBHOOK_ALLOW_PRIVATE", false),
+			MaxConcurrentDeliveries: getEnvInt("WEBHOOK_MAX_CONCURRENT_DELIVERIES", 50),
+			UserAgent:               getEnvString("WEBHOOK_USER_AGENT", "GoAPITemplate-Webhook/1.0"),
+			HTTPProxy:               getEnvString("WEBHOOK_HTTP_PROXY", ""),
+			MaxPayloadBytes:         getEnvInt("WEBHOOK_MAX_PAYLOAD_BYTES", 1<<20),
+		},
+	}
+
+	corsRoutes, err := loadCORSRoutes(getEnvString("CORS_ROUTES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS routes config: %w", err)
+	}
+	config.CORS.Routes = corsRoutes
+
+	webhooks, err := loadWebhooks(getEnvString("WEBHOOKS_CONFIG", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhooks config: %w", err)
 	}
+	config.Webhooks = webhooks
 
 	if err := validateConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -130,6 +297,64 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	supportedEventBackends := []string{"db", "kafka"}
+	if !contains(supportedEventBackends, cfg.Events.Backend) {
+		return fmt.Errorf("unsupported events backend: %s", cfg.Events.Backend)
+	}
+
+	if cfg.Events.Backend == "kafka" && cfg.Events.Kafka.Topic == "" {
+		return fmt.Errorf("kafka topic is required for the kafka events backend")
+	}
+
+	if cfg.Events.MaxFutureSkewSeconds < 0 {
+		return fmt.Errorf("events max future skew must not be negative: %d", cfg.Events.MaxFutureSkewSeconds)
+	}
+
+	if cfg.Events.RetentionDays < 0 {
+		return fmt.Errorf("events retention days must not be negative: %d", cfg.Events.RetentionDays)
+	}
+
+	if cfg.Metrics.Enabled && !strings.HasPrefix(cfg.Metrics.Path, "/") {
+		return fmt.Errorf("metrics path must start with /: %s", cfg.Metrics.Path)
+	}
+
+	if cfg.Database.SlowQueryMs < 0 {
+		return fmt.Errorf("database slow query threshold must not be negative: %d", cfg.Database.SlowQueryMs)
+	}
+
+	if cfg.Webhook.MaxResponseBytes <= 0 {
+		return fmt.Errorf("webhook max response bytes must be positive: %d", cfg.Webhook.MaxResponseBytes)
+	}
+	if cfg.Webhook.MaxConcurrentDeliveries <= 0 {
+		return fmt.Errorf("webhook max concurrent deliveries must be positive: %d", cfg.Webhook.MaxConcurrentDeliveries)
+	}
+	if cfg.Webhook.MaxPayloadBytes <= 0 {
+		return fmt.Errorf("webhook max payload bytes must be positive: %d", cfg.Webhook.MaxPayloadBytes)
+	}
+	if cfg.Webhook.HTTPProxy != "" {
+		proxy, err := url.Parse(cfg.Webhook.HTTPProxy)
+		if err != nil || proxy.Host == "" || (proxy.Scheme != "http" && proxy.Scheme != "https" && proxy.Scheme != "socks5") {
+			return fmt.Errorf("webhook HTTP proxy must be an http, https or socks5 URL: %q", cfg.Webhook.HTTPProxy)
+		}
+	}
+
+	webhookKeys := make(map[string]bool)
+	for _, webhook := range cfg.Webhooks {
+		if webhook.Key == "" || webhook.URL == "" || len(webhook.EventTypes) == 0 {
+			return fmt.Errorf("configured webhooks require key, url and event_types")
+		}
+		if webhookKeys[webhook.Key] {
+			return fmt.Errorf("duplicate webhook key: %s", webhook.Key)
+		}
+		if webhook.MaxRetries < 0 || webhook.MaxRetries > MaxWebhookRetries {
+			return fmt.Errorf("webhook %s: max_retries must be between 0 and %d", webhook.Key, MaxWebhookRetries)
+		}
+		if webhook.TimeoutSeconds < 0 || webhook.TimeoutSeconds > MaxWebhookTimeoutSeconds {
+			return fmt.Errorf("webhook %s: timeout_seconds must be between 0 and %d", webhook.Key, MaxWebhookTimeoutSeconds)
+		}
+		webhookKeys[webhook.Key] = true
+	}
+
 	supportedLogLevels := []string{"debug", "info", "warn", "error"}
 	if !contains(supportedLogLevels, cfg.Logging.Level) {
 		return fmt.Errorf("unsupported log level: %s", cfg.Logging.Level)
@@ -138,6 +363,87 @@ func validateConfig(cfg *Config) error {
 	return nil
 }
 
+// loadWebhooks parses WEBHOOKS_CONFIG, which holds either a JSON array of
+// webhooks or the path of a file containing one
+func loadWebhooks(value string) ([]WebhookConfig, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		fileData, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", value, err)
+		}
+		data = fileData
+	}
+
+	var webhooks []WebhookConfig
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// loadCORSRoutes parses CORS_ROUTES, which holds either a JSON object mapping
+// path prefixes to CORS policies or the path of a file containing one
+func loadCORSRoutes(value string) (map[string]CORSConfig, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		fileData, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", value, err)
+		}
+		data = fileData
+	}
+
+	var routes map[string]CORSConfig
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse CORS routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// loadEnvFile sets environment variables from a file of KEY=VALUE lines, in
+// the format of configs/config.example.env. Values in the file take precedence
+// over the existing environment so the file can be re-read on reload.
+func loadEnvFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, i+1)
+		}
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+	}
+
+	return nil
+}
+
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -163,6 +469,17 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getDefaultDBPort(dbType string) int {
 	switch dbType {
 	case "postgres":
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloaderAppliesLogLevel(t *testing.T) {
+	// Register the variables the config file sets so they are restored afterwards
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("SERVER_PORT", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+
+	originalLevel := logrus.GetLevel()
+	defer logrus.SetLevel(originalLevel)
+
+	path := filepath.Join(t.TempDir(), "app.env")
+	writeConfigFile := func(contents string) {
+		err := os.WriteFile(path, []byte(contents), 0o600)
+		require.NoError(t, err)
+	}
+	writeConfigFile("LOG_LEVEL=info\nSERVER_PORT=8080\nCORS_ALLOWED_ORIGINS=https://a.example.com\n")
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, ApplyLogging(cfg.Logging))
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.False(t, logrus.IsLevelEnabled(logrus.DebugLevel))
+
+	reloader := NewReloader(cfg)
+	var applied *Config
+	reloader.OnReload(func(cfg *Config) { applied = cfg })
+
+	// Change a hot-reloadable and a restart-only setting
+	writeConfigFile("# reloaded\nLOG_LEVEL=debug\nSERVER_PORT=9090\nCORS_ALLOWED_ORIGINS=\"https://b.example.com\"\n")
+
+	restartRequired, err := reloader.Reload()
+	require.NoError(t, err)
+
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.True(t, logrus.IsLevelEnabled(logrus.DebugLevel))
+	assert.Equal(t, []string{"server"}, restartRequired)
+
+	require.NotNil(t, applied)
+	assert.Equal(t, []string{"https://b.example.com"}, applied.CORS.AllowedOrigins)
+	assert.Same(t, applied, reloader.Current())
+
+	// An invalid config is rejected and the running settings are kept
+	writeConfigFile("LOG_LEVEL=verbose\n")
+
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.Same(t, applied, reloader.Current())
+}
+
+func TestLoadAutoMigrate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "defaults to enabled", value: "", want: true},
+		{name: "disabled", value: "false", want: false},
+		{name: "enabled", value: "true", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("DB_AUTO_MIGRATE", tt.value)
+
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Database.AutoMigrate)
+		})
+	}
+}
+
+func TestLoadMaxFutureSkew(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("EVENTS_MAX_FUTURE_SKEW_SECONDS", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 86400, cfg.Events.MaxFutureSkewSeconds)
+}
+
+func TestLoadReplicaHosts(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_REPLICA_HOSTS", "replica1.internal, replica2.internal,")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica1.internal", "replica2.internal"}, cfg.Database.ReplicaHosts)
+
+	t.Setenv("DB_REPLICA_HOSTS", "")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Database.ReplicaHosts)
+}
+
+func TestLoadCORSRoutes(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CORS_ROUTES", `{"/api/v1/events/stream": {"allowed_origins": ["https://dashboard.example.com"], "max_age": 60}}`)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.Contains(t, cfg.CORS.Routes, "/api/v1/events/stream")
+	route := cfg.CORS.Routes["/api/v1/events/stream"]
+	assert.Equal(t, []string{"https://dashboard.example.com"}, route.AllowedOrigins)
+	assert.Equal(t, 60, route.MaxAge)
+
+	t.Setenv("CORS_ROUTES", `{"/api": `)
+	_, err = Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader re-reads configuration while the server is running and hands the
+// result to listeners that apply the hot-reloadable settings (CORS, rate
+// limits, log level). Other settings only take effect after a restart.
+type Reloader struct {
+	mu        sync.Mutex
+	current   *Config
+	load      func() (*Config, error)
+	listeners []func(*Config)
+}
+
+// NewReloader creates a reloader starting from the config the server was
+// started with
+func NewReloader(cfg *Config) *Reloader {
+	return &Reloader{
+		current: cfg,
+		load:    Load,
+	}
+}
+
+// OnReload registers a function called with each successfully reloaded config
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Current returns the most recently loaded config
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the config again, applies the log level and notifies
+// listeners. It returns the names of changed sections that cannot be applied
+// without a restart. On error the running config is left untouched.
+func (r *Reloader) Reload() (restartRequired []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ApplyLogging(cfg.Logging); err != nil {
+		return nil, err
+	}
+
+	for _, listener := range r.listeners {
+		listener(cfg)
+	}
+
+	restartRequired = restartRequiredChanges(r.current, cfg)
+	r.current = cfg
+
+	return restartRequired, nil
+}
+
+// restartRequiredChanges lists the sections that differ between two configs
+// but are only read at startup
+func restartRequiredChanges(old, new *Config) []string {
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"server", old.Server, new.Server},
+		{"database", old.Database, new.Database},
+		{"cache", old.Cache, new.Cache},
+		{"request_log", old.RequestLog, new.RequestLog},
+		{"admin", old.Admin, new.Admin},
+		{"metrics", old.Metrics, new.Metrics},
+		{"events", old.Events, new.Events},
+		{"nats", old.NATS, new.NATS},
+		{"webhook", old.Webhook, new.Webhook},
+		{"webhooks", old.Webhooks, new.Webhooks},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.old, section.new) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
+
+// ApplyLogging sets the level and format of the standard logrus logger
+func ApplyLogging(cfg LoggingConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	logrus.SetLevel(level)
+
+	switch cfg.Format {
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	return nil
+}
@@ -1,17 +1,26 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strings"
 	"time"
 
 	"goapitemplate/internal/config"
 	"goapitemplate/pkg/models"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 type DB struct {
@@ -19,7 +28,17 @@ type DB struct {
 	dbType string
 }
 
-func New(cfg config.DatabaseConfig) (*DB, error) {
+// ErrSequenceConflict is returned when an event's ExpectedSequence is not the
+// next sequence number in its stream, i.e. another append got there first
+var ErrSequenceConflict = errors.New("stream sequence conflict")
+
+// ErrDuplicateCloudEvent is returned when an event's CloudEventID was
+// already stored for its source
+var ErrDuplicateCloudEvent = errors.New("duplicate CloudEvent")
+
+// New connects to the database in cfg. Queries are logged according to
+// logLevel, the application's log level; see gormLoggerConfig.
+func New(cfg config.DatabaseConfig, logLevel string) (*DB, error) {
 	var (
 		gormDB *gorm.DB
 		err    error
@@ -27,28 +46,23 @@ func New(cfg config.DatabaseConfig) (*DB, error) {
 
 	// Configure GORM logger
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormLoggerConfig(cfg, logLevel)),
 	}
 
-	switch cfg.Type {
-	case "postgres":
-		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.SSLMode)
-		gormDB, err = gorm.Open(postgres.Open(dsn), gormConfig)
-	case "mysql":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
-		gormDB, err = gorm.Open(mysql.Open(dsn), gormConfig)
-	case "sqlite":
-		gormDB, err = gorm.Open(sqlite.Open(cfg.Database), gormConfig)
-	default:
-		return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
 	}
 
+	gormDB, err = gorm.Open(dialector, gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := useReplicas(gormDB, cfg); err != nil {
+		return nil, err
+	}
+
 	// Configure connection pool
 	sqlDB, err := gormDB.DB()
 	if err != nil {
@@ -65,16 +79,159 @@ func New(cfg config.DatabaseConfig) (*DB, error) {
 	}, nil
 }
 
+// gormLoggerConfig derives GORM's logger settings from the application log
+// level. Only at debug (or trace) is every query logged; otherwise queries
+// slower than cfg.SlowQueryMs are logged as warnings, along with errors.
+// Record-not-found errors are expected lookups, not failures, so they are
+// never logged.
+func gormLoggerConfig(cfg config.DatabaseConfig, logLevel string) logger.Config {
+	level := logger.Warn
+	if parsed, err := logrus.ParseLevel(logLevel); err == nil {
+		switch {
+		case parsed >= logrus.DebugLevel:
+			level = logger.Info
+		case parsed == logrus.ErrorLevel:
+			level = logger.Error
+		case parsed < logrus.ErrorLevel:
+			level = logger.Silent
+		}
+	}
+
+	return logger.Config{
+		SlowThreshold:             time.Duration(cfg.SlowQueryMs) * time.Millisecond,
+		LogLevel:                  level,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  false,
+	}
+}
+
+// openDialector builds the GORM dialector for cfg
+func openDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Type {
+	case "postgres":
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.SSLMode)
+		return postgres.Open(dsn), nil
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+		return mysql.Open(dsn), nil
+	case "sqlite":
+		return sqlite.Open(cfg.Database), nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
+	}
+}
+
+// useReplicas routes SELECT queries to the configured read replicas; writes,
+// raw Exec statements and transactions stay on the primary. Without replicas
+// the connection is left as is.
+func useReplicas(gormDB *gorm.DB, cfg config.DatabaseConfig) error {
+	if len(cfg.ReplicaHosts) == 0 {
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(cfg.ReplicaHosts))
+	for _, host := range cfg.ReplicaHosts {
+		replicaCfg := cfg
+		replicaCfg.Host = host
+		if cfg.Type == "sqlite" {
+			replicaCfg.Database = host
+		}
+		dialector, err := openDialector(replicaCfg)
+		if err != nil {
+			return err
+		}
+		replicas = append(replicas, dialector)
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(cfg.MaxConns).
+		SetMaxIdleConns(cfg.MaxIdle).
+		SetConnMaxLifetime(time.Hour)
+
+	if err := gormDB.Use(resolver); err != nil {
+		return fmt.Errorf("failed to configure read replicas: %w", err)
+	}
+	return nil
+}
+
+// ForContext returns a DB whose queries, including those of its helper
+// methods, run with ctx and so stop at its deadline or cancellation
+func (db *DB) ForContext(ctx context.Context) *DB {
+	return &DB{DB: db.DB.WithContext(ctx), dbType: db.dbType}
+}
+
 func (db *DB) GetDBType() string {
 	return db.dbType
 }
 
-func (db *DB) AutoMigrate() error {
-	return db.DB.AutoMigrate(
+// schemaModels lists the models managed by migrations
+func schemaModels() []interface{} {
+	return []interface{}{
 		&models.Event{},
 		&models.WebhookEndpoint{},
 		&models.WebhookDelivery{},
-	)
+		&models.WebhookDeliveryAttempt{},
+		&models.OutboxEntry{},
+		&models.StreamRetention{},
+		&models.AuditLog{},
+		&models.EventTypeAlias{},
+	}
+}
+
+func (db *DB) AutoMigrate() error {
+	return db.DB.AutoMigrate(schemaModels()...)
+}
+
+// EnsureSchema prepares the schema at startup: it migrates when autoMigrate is
+// set, and otherwise only verifies that migrations have already been applied
+func (db *DB) EnsureSchema(autoMigrate bool) error {
+	if autoMigrate {
+		return db.AutoMigrate()
+	}
+	return db.VerifySchema()
+}
+
+// VerifySchema checks that every model's table and columns exist, without
+// changing the schema
+func (db *DB) VerifySchema() error {
+	migrator := db.DB.Migrator()
+
+	for _, model := range schemaModels() {
+		stmt := &gorm.Statement{DB: db.DB}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse model schema: %w", err)
+		}
+
+		table := stmt.Schema.Table
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table %s is missing; run migrations", table)
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				return fmt.Errorf("column %s.%s is missing; run migrations", table, field.DBName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Stats returns connection pool statistics for the primary database
+func (db *DB) Stats() (sql.DBStats, error) {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return sqlDB.Stats(), nil
 }
 
 func (db *DB) Close() error {
@@ -122,40 +279,303 @@ func (db *DB) GetEventsByTypeWithPagination(eventType string, offset, limit int)
 		return nil, 0, err
 	}
 	
-	// Get paginated results
-	if err := query.Order("timestamp DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+	// Get paginated results; the ID breaks timestamp ties so the order
+	// matches GetEventsAfterCursor
+	if err := query.Order("timestamp DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
 		return nil, 0, err
 	}
 	
 	return events, total, nil
 }
 
+// EventCursor is a position in the newest-first event listing: the
+// timestamp and ID of the last event on the previous page
+type EventCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// GetEventsAfterCursor returns up to limit events newest first, starting
+// after cursor, or from the newest event when cursor is nil. Unlike offset
+// pagination it seeks straight to the position, so deep pages stay cheap
+// and events stored in between don't shift the pages.
+func (db *DB) GetEventsAfterCursor(cursor *EventCursor, limit int) ([]models.Event, int64, error) {
+	var events []models.Event
+	var total int64
+
+	if err := db.DB.Model(&models.Event{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := db.DB.Model(&models.Event{})
+	if cursor != nil {
+		query = query.Where("(timestamp, id) < (?, ?)", cursor.Timestamp, cursor.ID)
+	}
+	if err := query.Order("timestamp DESC, id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// EventSearch filters events across streams; From is inclusive, To exclusive
+// and empty strings match anything
+type EventSearch struct {
+	From   time.Time
+	To     time.Time
+	Type   string
+	Source string
+}
+
+// SearchEvents returns events in the search's time range, oldest first, with
+// the total number of matches
+func (db *DB) SearchEvents(search EventSearch, offset, limit int) ([]models.Event, int64, error) {
+	var events []models.Event
+	var total int64
+
+	query := db.DB.Model(&models.Event{}).
+		Where("timestamp >= ? AND timestamp < ?", search.From, search.To)
+	if search.Type != "" {
+		query = query.Where("type = ?", search.Type)
+	}
+	if search.Source != "" {
+		query = query.Where("source = ?", search.Source)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("timestamp, stream_id, sequence_number").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// GetWebhooksWithPagination gets webhook endpoints with pagination, newest
+// first. Soft-deleted webhooks are only included when includeDeleted is set.
+func (db *DB) GetWebhooksWithPagination(offset, limit int, includeDeleted bool) ([]models.WebhookEndpoint, int64, error) {
+	var webhooks []models.WebhookEndpoint
+	var total int64
+
+	query := db.DB.Model(&models.WebhookEndpoint{})
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&webhooks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return webhooks, total, nil
+}
+
+// DeleteWebhooksByEventType deletes every webhook subscribed to eventType and
+// returns the IDs removed. Webhooks are soft-deleted unless hard is set, in
+// which case already soft-deleted subscribers are purged as well. Only exact
+// entries in event_types match; patterns such as "order.*" are left alone.
+func (db *DB) DeleteWebhooksByEventType(eventType string, hard bool) ([]string, error) {
+	var ids []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if hard {
+			tx = tx.Unscoped()
+		}
+
+		// event_types is serialized JSON, so match in Go rather than per dialect
+		var webhooks []models.WebhookEndpoint
+		if err := tx.Select("id", "event_types").Find(&webhooks).Error; err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			for _, t := range webhook.EventTypes {
+				if t == eventType {
+					ids = append(ids, webhook.ID)
+					break
+				}
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return tx.Delete(&models.WebhookEndpoint{}, "id IN ?", ids).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // CreateEventWithSequence creates an event with proper sequence number
 func (db *DB) CreateEventWithSequence(event *models.Event) error {
-	return db.DB.Transaction(func(tx *gorm.DB) error {
-		// Get next sequence number for this stream
-		var maxSeq int64
-		err := tx.Model(&models.Event{}).
-			Where("stream_id = ?", event.StreamID).
-			Select("COALESCE(MAX(sequence_number), 0)").
-			Scan(&maxSeq).Error
-		if err != nil {
+	return db.appendEvents([]*models.Event{event})
+}
+
+// sequenceRetries is how many times an append that lost the race for its
+// sequence number is retried before giving up
+const sequenceRetries = 3
+
+// appendEvents creates the events in one transaction, each with the next
+// sequence number in its stream. Appends read the stream's sequence without
+// a lock, so two racing appends can pick the same number; the unique index
+// on (stream_id, sequence_number) lets only one of them commit. The loser is
+// retried with a fresh number unless one of its events expects a specific
+// sequence, in which case it fails with ErrSequenceConflict.
+func (db *DB) appendEvents(events []*models.Event) error {
+	expectsSequence := false
+	for _, event := range events {
+		if event.ExpectedSequence > 0 {
+			expectsSequence = true
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			for _, event := range events {
+				// Get next sequence number for this stream
+				if err := assignSequence(tx, event); err != nil {
+					return err
+				}
+
+				if err := tx.Create(event).Error; err != nil {
+					if violatesUnique(tx, err, "idx_events_stream_sequence", "sequence_number") {
+						return fmt.Errorf("%w: sequence %d of stream %s was taken by another append",
+							ErrSequenceConflict, event.SequenceNumber, event.StreamID)
+					}
+					if event.CloudEventID != nil && violatesUnique(tx, err, "idx_events_cloudevent", "cloud_event_id") {
+						return fmt.Errorf("%w: CloudEvent %q from source %q already exists",
+							ErrDuplicateCloudEvent, *event.CloudEventID, event.Source)
+					}
+					return err
+				}
+				if err := createOutboxEntry(tx, event); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil || expectsSequence || attempt == sequenceRetries || !errors.Is(err, ErrSequenceConflict) {
 			return err
 		}
-		
-		event.SequenceNumber = maxSeq + 1
-		
-		// Create event
-		return tx.Create(event).Error
-	})
+	}
+}
+
+// violatesUnique reports whether err, returned by a statement on tx, is a
+// unique constraint violation of the named index. Drivers name the index or,
+// for SQLite, its columns in the message, so either is matched.
+func violatesUnique(tx *gorm.DB, err error, index, column string) bool {
+	translator, ok := tx.Dialector.(gorm.ErrorTranslator)
+	if !ok || !errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return false
+	}
+	return strings.Contains(err.Error(), index) || strings.Contains(err.Error(), column)
+}
+
+// assignSequence sets the event's sequence number to the next one in its
+// stream. When the event has an ExpectedSequence it must be that number,
+// otherwise ErrSequenceConflict is returned.
+func assignSequence(tx *gorm.DB, event *models.Event) error {
+	var maxSeq int64
+	err := tx.Model(&models.Event{}).
+		Where("stream_id = ?", event.StreamID).
+		Select("COALESCE(MAX(sequence_number), 0)").
+		Scan(&maxSeq).Error
+	if err != nil {
+		return err
+	}
+
+	if event.ExpectedSequence > 0 && event.ExpectedSequence != maxSeq+1 {
+		return fmt.Errorf("%w: expected sequence %d but stream %s is at %d",
+			ErrSequenceConflict, event.ExpectedSequence, event.StreamID, maxSeq)
+	}
+
+	event.SequenceNumber = maxSeq + 1
+	return nil
+}
+
+// OutboxLease is how long an outbox entry is held by whoever dispatches its
+// event, first the publisher and then the outbox dispatcher. It is far longer
+// than a dispatch takes, so an event is only dispatched again once its holder
+// has evidently stopped.
+const OutboxLease = 10 * time.Minute
+
+// createOutboxEntry queues an event for webhook delivery as part of the
+// transaction that saves it, leased to the publisher that goes on to
+// dispatch it
+func createOutboxEntry(tx *gorm.DB, event *models.Event) error {
+	now := time.Now()
+	return tx.Create(&models.OutboxEntry{EventID: event.ID, CreatedAt: now, LockedUntil: now.Add(OutboxLease)}).Error
 }
 
-// GetWebhookDeliveriesWithRelations demonstrates complex relationships
-func (db *DB) GetWebhookDeliveriesWithRelations(webhookID string, limit int) ([]models.WebhookDelivery, error) {
+// ClaimOutboxEntries takes up to limit outbox entries whose lease has run
+// out, oldest first, and leases each to the caller. An entry claimed
+// concurrently by someone else is left out.
+func (db *DB) ClaimOutboxEntries(ctx context.Context, limit int) ([]models.OutboxEntry, error) {
+	now := time.Now()
+
+	var entries []models.OutboxEntry
+	err := db.DB.WithContext(ctx).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("created_at").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := entries[:0]
+	for _, entry := range entries {
+		// Only the claim that still sees the old lease succeeds
+		result := db.DB.WithContext(ctx).Model(&models.OutboxEntry{}).
+			Where("event_id = ? AND (locked_until IS NULL OR locked_until < ?)", entry.EventID, now).
+			Update("locked_until", now.Add(OutboxLease))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, entry)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkOutboxEntryRelayed records that the event store has handed an outbox
+// entry's event on to its downstream system
+func (db *DB) MarkOutboxEntryRelayed(ctx context.Context, eventID string) error {
+	return db.DB.WithContext(ctx).Model(&models.OutboxEntry{}).
+		Where("event_id = ?", eventID).
+		Update("relayed", true).Error
+}
+
+// CreateEventsWithSequence creates several events in one transaction, assigning
+// each the next sequence number in its stream. Either all events are created or none.
+func (db *DB) CreateEventsWithSequence(events []*models.Event) error {
+	return db.appendEvents(events)
+}
+
+// GetWebhookDeliveriesWithRelations demonstrates complex relationships.
+// A non-empty status keeps only deliveries in that status, and a non-zero
+// since keeps only deliveries created at or after it.
+func (db *DB) GetWebhookDeliveriesWithRelations(webhookID, status string, since time.Time, limit int) ([]models.WebhookDelivery, error) {
 	var deliveries []models.WebhookDelivery
 	
-	err := db.DB.Preload("Webhook").Preload("Event").
-		Where("webhook_id = ?", webhookID).
+	query := db.DB.Preload("Webhook").Preload("Event").
+		Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+
+	err := query.
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&deliveries).Error
@@ -163,6 +583,36 @@ func (db *DB) GetWebhookDeliveriesWithRelations(webhookID string, limit int) ([]
 	return deliveries, err
 }
 
+// GetWebhookDeliveryWithRelations gets a single delivery with its webhook and
+// event. The webhook is loaded even if it has since been soft-deleted.
+func (db *DB) GetWebhookDeliveryWithRelations(deliveryID string) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	err := db.DB.
+		Preload("Webhook", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Preload("Event").
+		First(&delivery, "id = ?", deliveryID).Error
+
+	return delivery, err
+}
+
+// GetEventTypeAliases returns every event type alias, ordered by alias
+func (db *DB) GetEventTypeAliases(ctx context.Context) ([]models.EventTypeAlias, error) {
+	var aliases []models.EventTypeAlias
+	err := db.DB.WithContext(ctx).Order("alias").Find(&aliases).Error
+	return aliases, err
+}
+
+// GetDeliveryAttempts returns the recorded attempts of a delivery, oldest first
+func (db *DB) GetDeliveryAttempts(deliveryID string) ([]models.WebhookDeliveryAttempt, error) {
+	var attempts []models.WebhookDeliveryAttempt
+	err := db.DB.
+		Where("delivery_id = ?", deliveryID).
+		Order("created_at ASC, attempt ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
 // GetEventStatsByType demonstrates aggregation queries for events
 func (db *DB) GetEventStatsByType() (map[string]int64, error) {
 	var results []struct {
@@ -187,3 +637,505 @@ func (db *DB) GetEventStatsByType() (map[string]int64, error) {
 	return stats, nil
 }
 
+// GetEventStreamsWithCounts returns each stream with its event count and latest
+// event timestamp, most recently active streams first
+func (db *DB) GetEventStreamsWithCounts(limit, offset int) ([]models.StreamSummary, error) {
+	var rows []struct {
+		StreamID        string
+		EventCount      int64
+		LatestTimestamp string
+	}
+
+	err := db.DB.Model(&models.Event{}).
+		Select("stream_id, count(*) as event_count, max(timestamp) as latest_timestamp").
+		Group("stream_id").
+		Order("latest_timestamp DESC, stream_id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.StreamSummary, 0, len(rows))
+	for _, row := range rows {
+		latest, err := parseAggregateTime(row.LatestTimestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse latest timestamp for stream %s: %w", row.StreamID, err)
+		}
+		summaries = append(summaries, models.StreamSummary{
+			StreamID:        row.StreamID,
+			EventCount:      row.EventCount,
+			LatestTimestamp: latest,
+		})
+	}
+
+	return summaries, nil
+}
+
+// GetStreamCursors returns each stream's highest sequence number, keyed by
+// stream ID. Streams are paged in stream ID order.
+func (db *DB) GetStreamCursors(limit, offset int) (map[string]int64, error) {
+	var rows []struct {
+		StreamID       string
+		SequenceNumber int64
+	}
+
+	err := db.DB.Model(&models.Event{}).
+		Select("stream_id, max(sequence_number) as sequence_number").
+		Group("stream_id").
+		Order("stream_id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cursors := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		cursors[row.StreamID] = row.SequenceNumber
+	}
+
+	return cursors, nil
+}
+
+// parseAggregateTime parses a timestamp returned by an aggregate such as MAX,
+// which drivers hand back as text rather than a typed time value
+func parseAggregateTime(value string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+	}
+
+	var lastErr error
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
+
+// bucketExpression returns SQL truncating column to the start of its bucket
+// ("hour" or "day") as UTC text in "YYYY-MM-DD HH:MM:SS" form, for the
+// connected database's dialect
+func (db *DB) bucketExpression(column, bucket string) (string, error) {
+	dialect := db.DB.Dialector.Name()
+
+	switch bucket {
+	case "hour", "day":
+	default:
+		return "", fmt.Errorf("unsupported bucket %q", bucket)
+	}
+
+	switch dialect {
+	case "sqlite":
+		if bucket == "day" {
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d 00:00:00', %s)", column), nil
+		}
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:00:00', %s)", column), nil
+	case "postgres":
+		return fmt.Sprintf("to_char(date_trunc('%s', %s AT TIME ZONE 'UTC'), 'YYYY-MM-DD HH24:MI:SS')", bucket, column), nil
+	case "mysql":
+		if bucket == "day" {
+			return fmt.Sprintf("DATE_FORMAT(CONVERT_TZ(%s, @@session.time_zone, '+00:00'), '%%Y-%%m-%%d 00:00:00')", column), nil
+		}
+		return fmt.Sprintf("DATE_FORMAT(CONVERT_TZ(%s, @@session.time_zone, '+00:00'), '%%Y-%%m-%%d %%H:00:00')", column), nil
+	default:
+		return "", fmt.Errorf("unsupported database dialect %q", dialect)
+	}
+}
+
+// GetDeliveryTimeSeries returns webhook delivery counts and success rates per
+// time bucket between from (inclusive) and to (exclusive), oldest first.
+// Buckets without deliveries are omitted. An empty webhookID covers all webhooks.
+func (db *DB) GetDeliveryTimeSeries(bucket string, from, to time.Time, webhookID string) ([]models.DeliveryStatsBucket, error) {
+	bucketExpr, err := db.bucketExpression("created_at", bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		BucketStart string
+		Total       int64
+		Success     int64
+	}
+
+	query := db.DB.Model(&models.WebhookDelivery{}).
+		Select(bucketExpr+" as bucket_start, count(*) as total, "+
+			"sum(case when status = 'success' then 1 else 0 end) as success").
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if webhookID != "" {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+
+	err = query.Group("bucket_start").Order("bucket_start").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.DeliveryStatsBucket, 0, len(rows))
+	for _, row := range rows {
+		start, err := time.Parse("2006-01-02 15:04:05", row.BucketStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bucket start %q: %w", row.BucketStart, err)
+		}
+
+		b := models.DeliveryStatsBucket{
+			BucketStart: start,
+			Total:       row.Total,
+			Success:     row.Success,
+		}
+		if row.Total > 0 {
+			b.SuccessRate = float64(row.Success) / float64(row.Total) * 100
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, nil
+}
+
+// GetWebhookStats returns delivery totals, success rate, average attempt
+// count and the most recent attempt for one webhook
+func (db *DB) GetWebhookStats(webhookID string) (models.WebhookStats, error) {
+	var row struct {
+		Total           int64
+		Successful      int64
+		Failed          int64
+		Pending         int64
+		AverageAttempts float64
+		LastAttempt     *string
+	}
+
+	err := db.DB.Model(&models.WebhookDelivery{}).
+		Select("count(*) as total, "+
+			"coalesce(sum(case when status = 'success' then 1 else 0 end), 0) as successful, "+
+			"coalesce(sum(case when status = 'failed' then 1 else 0 end), 0) as failed, "+
+			"coalesce(sum(case when status = 'pending' then 1 else 0 end), 0) as pending, "+
+			"coalesce(avg(attempt_count), 0) as average_attempts, "+
+			"max(last_attempt) as last_attempt").
+		Where("webhook_id = ?", webhookID).
+		Scan(&row).Error
+	if err != nil {
+		return models.WebhookStats{}, err
+	}
+
+	stats := models.WebhookStats{
+		WebhookID:            webhookID,
+		TotalDeliveries:      row.Total,
+		SuccessfulDeliveries: row.Successful,
+		FailedDeliveries:     row.Failed,
+		PendingDeliveries:    row.Pending,
+		AverageAttempts:      row.AverageAttempts,
+	}
+	if row.Total > 0 {
+		stats.SuccessRate = float64(row.Successful) / float64(row.Total) * 100
+	}
+	if row.LastAttempt != nil {
+		last, err := parseAggregateTime(*row.LastAttempt)
+		if err != nil {
+			return models.WebhookStats{}, fmt.Errorf("failed to parse last attempt for webhook %s: %w", webhookID, err)
+		}
+		stats.LastDeliveryAt = &last
+	}
+
+	return stats, nil
+}
+
+// GetEventTimeSeries returns event counts per time bucket of the event
+// timestamp between from (inclusive) and to (exclusive), oldest first.
+// Buckets without events are omitted. An empty eventType covers all types.
+func (db *DB) GetEventTimeSeries(bucket string, from, to time.Time, eventType string) ([]models.EventCountBucket, error) {
+	bucketExpr, err := db.bucketExpression("timestamp", bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		BucketStart string
+		Count       int64
+	}
+
+	query := db.DB.Model(&models.Event{}).
+		Select(bucketExpr+" as bucket_start, count(*) as count").
+		Where("timestamp >= ? AND timestamp < ?", from, to)
+	if eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+
+	err = query.Group("bucket_start").Order("bucket_start").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.EventCountBucket, 0, len(rows))
+	for _, row := range rows {
+		start, err := time.Parse("2006-01-02 15:04:05", row.BucketStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bucket start %q: %w", row.BucketStart, err)
+		}
+		buckets = append(buckets, models.EventCountBucket{BucketStart: start, Count: row.Count})
+	}
+
+	return buckets, nil
+}
+
+// ForEachEventInStream calls fn for every event in a stream in sequence
+// order, reading them from a cursor so large streams are never held in
+// memory at once. It stops at the first error returned by fn.
+func (db *DB) ForEachEventInStream(ctx context.Context, streamID string, fn func(models.Event) error) error {
+	rows, err := db.DB.WithContext(ctx).
+		Model(&models.Event{}).
+		Where("stream_id = ?", streamID).
+		Order("sequence_number").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.Event
+		if err := db.DB.ScanRows(rows, &event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DetectSequenceGaps returns the runs of sequence numbers missing from a
+// stream, in order. Sequences start at 1, or after the last one retention
+// cleanup deleted, so missing leading numbers count as a gap too.
+func (db *DB) DetectSequenceGaps(streamID string) ([]models.SequenceGap, error) {
+	var rows []struct {
+		GapFrom int64
+		GapTo   int64
+	}
+
+	err := db.DB.Raw(`SELECT prev + 1 AS gap_from, sequence_number - 1 AS gap_to
+		FROM (
+			SELECT sequence_number, COALESCE(
+				LAG(sequence_number) OVER (ORDER BY sequence_number),
+				(SELECT pruned_through FROM stream_retentions WHERE stream_id = ?),
+				0) AS prev
+			FROM events
+			WHERE stream_id = ?
+		) AS sequenced
+		WHERE sequence_number > prev + 1
+		ORDER BY sequence_number`, streamID, streamID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := make([]models.SequenceGap, 0, len(rows))
+	for _, row := range rows {
+		gaps = append(gaps, models.SequenceGap{From: row.GapFrom, To: row.GapTo})
+	}
+	return gaps, nil
+}
+
+// GetEventsAfterSequence returns up to limit events of a stream with a
+// sequence number greater than after, in sequence order, so consumers can
+// resume from a checkpoint
+func (db *DB) GetEventsAfterSequence(ctx context.Context, streamID string, after int64, limit int) ([]models.Event, error) {
+	var events []models.Event
+	err := db.DB.WithContext(ctx).
+		Where("stream_id = ? AND sequence_number > ?", streamID, after).
+		Order("sequence_number ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteEventsBefore removes events stored before cutoff together with their
+// webhook deliveries and delivery attempts in a single transaction. Each
+// pruned stream's StreamRetention records the last sequence deleted.
+func (db *DB) DeleteEventsBefore(cutoff time.Time) (eventsDeleted, deliveriesDeleted int64, err error) {
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var pruned []models.StreamRetention
+		err := tx.Model(&models.Event{}).
+			Select("stream_id, MAX(sequence_number) AS pruned_through").
+			Where("created_at < ?", cutoff).
+			Group("stream_id").
+			Scan(&pruned).Error
+		if err != nil {
+			return err
+		}
+		if len(pruned) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "stream_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"pruned_through"}),
+			}).Create(&pruned).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		eventIDs := tx.Model(&models.Event{}).Select("id").Where("created_at < ?", cutoff)
+
+		deliveryIDs := tx.Model(&models.WebhookDelivery{}).Select("id").Where("event_id IN (?)", eventIDs)
+		if err := tx.Where("delivery_id IN (?)", deliveryIDs).Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("event_id IN (?)", eventIDs).Delete(&models.WebhookDelivery{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deliveriesDeleted = result.RowsAffected
+
+		if err := tx.Where("event_id IN (?)", eventIDs).Delete(&models.OutboxEntry{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("created_at < ?", cutoff).Delete(&models.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		eventsDeleted = result.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return eventsDeleted, deliveriesDeleted, nil
+}
+
+// DeleteEventsByStream removes all events in a stream together with their
+// webhook deliveries and delivery attempts in a single transaction
+func (db *DB) DeleteEventsByStream(streamID string) (eventsDeleted, deliveriesDeleted int64, err error) {
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		eventIDs := tx.Model(&models.Event{}).Select("id").Where("stream_id = ?", streamID)
+
+		deliveryIDs := tx.Model(&models.WebhookDelivery{}).Select("id").Where("event_id IN (?)", eventIDs)
+		if err := tx.Where("delivery_id IN (?)", deliveryIDs).Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("event_id IN (?)", eventIDs).Delete(&models.WebhookDelivery{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deliveriesDeleted = result.RowsAffected
+
+		if err := tx.Where("event_id IN (?)", eventIDs).Delete(&models.OutboxEntry{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("stream_id = ?", streamID).Delete(&models.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		eventsDeleted = result.RowsAffected
+
+		// A stream created again under the same ID starts from 1
+		return tx.Delete(&models.StreamRetention{}, "stream_id = ?", streamID).Error
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return eventsDeleted, deliveriesDeleted, nil
+}
+
+// ReconcileWebhooks creates or updates the webhooks declared in config. Each
+// is stored under the ID "cfg_<key>" so reconciling again is a no-op unless
+// the declaration changed.
+func (db *DB) ReconcileWebhooks(webhooks []config.WebhookConfig) (created, updated int, err error) {
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, cfg := range webhooks {
+			desired := webhookFromConfig(cfg)
+
+			// Include soft-deleted rows: a webhook declared in config is
+			// restored rather than recreated under the same ID
+			var existing models.WebhookEndpoint
+			result := tx.Unscoped().Where("id = ?", desired.ID).Limit(1).Find(&existing)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				if err := tx.Create(&desired).Error; err != nil {
+					return fmt.Errorf("failed to create webhook %s: %w", cfg.Key, err)
+				}
+				created++
+				continue
+			}
+
+			if webhookMatches(existing, desired) && !existing.DeletedAt.Valid {
+				continue
+			}
+
+			// Update from the struct so event_types goes through its JSON serializer
+			err := tx.Unscoped().Model(&existing).
+				Select("name", "url", "secret", "event_types", "enabled", "max_retries", "timeout_seconds", "deleted_at").
+				Updates(&desired).Error
+			if err != nil {
+				return fmt.Errorf("failed to update webhook %s: %w", cfg.Key, err)
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return created, updated, nil
+}
+
+func webhookFromConfig(cfg config.WebhookConfig) models.WebhookEndpoint {
+	webhook := models.WebhookEndpoint{
+		ID:             "cfg_" + cfg.Key,
+		Name:           cfg.Name,
+		URL:            cfg.URL,
+		Secret:         cfg.Secret,
+		EventTypes:     cfg.EventTypes,
+		Enabled:        true,
+		MaxRetries:     cfg.MaxRetries,
+		TimeoutSeconds: cfg.TimeoutSeconds,
+	}
+
+	if webhook.Name == "" {
+		webhook.Name = cfg.Key
+	}
+	if cfg.Enabled != nil {
+		webhook.Enabled = *cfg.Enabled
+	}
+	if webhook.MaxRetries == 0 {
+		webhook.MaxRetries = 3
+	}
+	if webhook.TimeoutSeconds == 0 {
+		webhook.TimeoutSeconds = 30
+	}
+
+	return webhook
+}
+
+func webhookMatches(a, b models.WebhookEndpoint) bool {
+	if a.Name != b.Name || a.URL != b.URL || a.Secret != b.Secret || a.Enabled != b.Enabled ||
+		a.MaxRetries != b.MaxRetries || a.TimeoutSeconds != b.TimeoutSeconds ||
+		len(a.EventTypes) != len(b.EventTypes) {
+		return false
+	}
+	for i := range a.EventTypes {
+		if a.EventTypes[i] != b.EventTypes[i] {
+			return false
+		}
+	}
+	return true
+}
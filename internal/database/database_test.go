@@ -0,0 +1,411 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goapitemplate/internal/config"
+	"goapitemplate/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestDB(t *testing.T) *DB {
+	gormDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	db := &DB{DB: gormDB, dbType: "sqlite"}
+
+	// Run migrations
+	err = db.AutoMigrate()
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestReconcileWebhooks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	webhooks := []config.WebhookConfig{
+		{
+			Key:        "billing",
+			Name:       "Billing",
+			URL:        "https://billing.example.com/hooks",
+			Secret:     "billing-secret",
+			EventTypes: []string{"payment.processed"},
+		},
+		{
+			Key:        "audit",
+			URL:        "https://audit.example.com/hooks",
+			Secret:     "audit-secret",
+			EventTypes: []string{"user.created", "user.deleted"},
+			MaxRetries: 5,
+		},
+	}
+
+	// First run creates both webhooks
+	created, updated, err := db.ReconcileWebhooks(webhooks)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 0, updated)
+
+	// Second run with the same config is a no-op
+	created, updated, err = db.ReconcileWebhooks(webhooks)
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.Equal(t, 0, updated)
+
+	var count int64
+	db.Model(&models.WebhookEndpoint{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	var audit models.WebhookEndpoint
+	err = db.First(&audit, "id = ?", "cfg_audit").Error
+	require.NoError(t, err)
+	assert.Equal(t, "audit", audit.Name) // Name defaults to key
+	assert.Equal(t, 5, audit.MaxRetries)
+	assert.Equal(t, 30, audit.TimeoutSeconds)
+	assert.True(t, audit.Enabled)
+
+	// Changing a declaration updates the existing webhook in place
+	disabled := false
+	webhooks[0].URL = "https://billing.example.com/v2/hooks"
+	webhooks[0].Enabled = &disabled
+
+	created, updated, err = db.ReconcileWebhooks(webhooks)
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.Equal(t, 1, updated)
+
+	var billing models.WebhookEndpoint
+	err = db.First(&billing, "id = ?", "cfg_billing").Error
+	require.NoError(t, err)
+	assert.Equal(t, "https://billing.example.com/v2/hooks", billing.URL)
+	assert.False(t, billing.Enabled)
+
+	db.Model(&models.WebhookEndpoint{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestEnsureSchema(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	db := &DB{DB: gormDB, dbType: "sqlite"}
+	defer db.Close()
+
+	// With auto-migrate disabled, startup fails fast on a missing schema
+	// instead of creating it
+	err = db.EnsureSchema(false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "run migrations")
+	assert.False(t, db.Migrator().HasTable(&models.Event{}))
+
+	// With auto-migrate enabled the schema is created
+	err = db.EnsureSchema(true)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable(&models.Event{}))
+
+	// Once migrated, verification passes without auto-migrate
+	err = db.EnsureSchema(false)
+	assert.NoError(t, err)
+
+	// A column missing from an existing table is reported
+	err = db.Migrator().DropColumn(&models.WebhookEndpoint{}, "log_level")
+	require.NoError(t, err)
+
+	err = db.EnsureSchema(false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook_endpoints.log_level")
+}
+
+func TestNewRoutesReadsToReplicas(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath := filepath.Join(dir, "primary.db")
+	replicaPath := filepath.Join(dir, "replica.db")
+
+	// Prepare both databases; the replica holds a row the primary doesn't
+	for _, path := range []string{primaryPath, replicaPath} {
+		gormDB, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		require.NoError(t, err)
+		seed := &DB{DB: gormDB, dbType: "sqlite"}
+		require.NoError(t, seed.AutoMigrate())
+		if path == replicaPath {
+			require.NoError(t, gormDB.Create(&models.WebhookEndpoint{
+				ID:     "replica-webhook",
+				Name:   "Replica Webhook",
+				URL:    "https://replica.example.com/hooks",
+				Secret: "secret",
+			}).Error)
+		}
+		require.NoError(t, seed.Close())
+	}
+
+	db, err := New(config.DatabaseConfig{
+		Type:         "sqlite",
+		Database:     primaryPath,
+		MaxConns:     1,
+		MaxIdle:      1,
+		ReplicaHosts: []string{replicaPath},
+	}, "")
+	require.NoError(t, err)
+	defer db.Close()
+	db.DB = db.DB.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	// Writes go to the primary
+	require.NoError(t, db.Create(&models.WebhookEndpoint{
+		ID:     "primary-webhook",
+		Name:   "Primary Webhook",
+		URL:    "https://primary.example.com/hooks",
+		Secret: "secret",
+	}).Error)
+
+	// Reads are served by the replica
+	webhooks, total, err := db.GetWebhooksWithPagination(0, 10, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "replica-webhook", webhooks[0].ID)
+
+	// The primary has the written row and not the replica's
+	primary, err := gorm.Open(sqlite.Open(primaryPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	var ids []string
+	require.NoError(t, primary.Model(&models.WebhookEndpoint{}).Pluck("id", &ids).Error)
+	assert.Equal(t, []string{"primary-webhook"}, ids)
+	sqlDB, err := primary.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+func TestNewWithoutReplicas(t *testing.T) {
+	db, err := New(config.DatabaseConfig{
+		Type:     "sqlite",
+		Database: filepath.Join(t.TempDir(), "single.db"),
+		MaxConns: 1,
+		MaxIdle:  1,
+	}, "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, registered := db.Config.Plugins["gorm:db_resolver"]
+	assert.False(t, registered)
+}
+
+func TestDeleteEventsBefore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	for _, event := range []models.Event{
+		{ID: "event-old", Type: "user.created", StreamID: "stream-1", Source: "test", Data: models.JSON{}, CreatedAt: now.Add(-40 * 24 * time.Hour)},
+		{ID: "event-new", Type: "user.created", StreamID: "stream-1", Source: "test", Data: models.JSON{}, CreatedAt: now.Add(-time.Hour)},
+	} {
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	webhook := models.WebhookEndpoint{ID: "webhook-1", Name: "Webhook", URL: "https://example.com/webhook", Secret: "secret"}
+	require.NoError(t, db.Create(&webhook).Error)
+	for _, delivery := range []models.WebhookDelivery{
+		{ID: "delivery-old", WebhookID: webhook.ID, EventID: "event-old", Status: "success"},
+		{ID: "delivery-new", WebhookID: webhook.ID, EventID: "event-new", Status: "success"},
+	} {
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	eventsDeleted, deliveriesDeleted, err := db.DeleteEventsBefore(now.Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), eventsDeleted)
+	assert.Equal(t, int64(1), deliveriesDeleted)
+
+	var eventIDs []string
+	require.NoError(t, db.Model(&models.Event{}).Pluck("id", &eventIDs).Error)
+	assert.Equal(t, []string{"event-new"}, eventIDs)
+
+	var deliveryIDs []string
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Pluck("id", &deliveryIDs).Error)
+	assert.Equal(t, []string{"delivery-new"}, deliveryIDs)
+}
+
+func TestCreateEventWithSequence_ConcurrentAppend(t *testing.T) {
+	tests := []struct {
+		name             string
+		expectedSequence int64
+		wantErr          error
+		wantAttempts     int
+	}{
+		{name: "expected sequence conflicts", expectedSequence: 1, wantErr: ErrSequenceConflict, wantAttempts: 1},
+		{name: "unconditional append retries", wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+
+			// SQLite serializes writers, so the competing append is made
+			// between this append's read of the stream and its insert, as a
+			// concurrent append on Postgres or MySQL could be. It shares the
+			// append's transaction and so is rolled back with it.
+			attempts := 0
+			require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:race", func(tx *gorm.DB) {
+				if tx.Statement.Table != "events" {
+					return
+				}
+				attempts++
+				if attempts > 1 {
+					return
+				}
+				require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).Exec(
+					"INSERT INTO events (id, type, stream_id, source, schema_version, sequence_number, timestamp, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+					"event-other", "user.created", "stream-1", "test", 1, 1, time.Now(), time.Now()).Error)
+			}))
+
+			event := models.Event{ID: "event-1", Type: "user.created", StreamID: "stream-1", Source: "test", Data: models.JSON{}, ExpectedSequence: tt.expectedSequence}
+			err := db.CreateEventWithSequence(&event)
+			assert.Equal(t, tt.wantAttempts, attempts)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var count int64
+				require.NoError(t, db.Model(&models.Event{}).Where("id = ?", event.ID).Count(&count).Error)
+				assert.Zero(t, count)
+				return
+			}
+
+			require.NoError(t, err)
+			var stored models.Event
+			require.NoError(t, db.First(&stored, "id = ?", event.ID).Error)
+			assert.Equal(t, int64(1), stored.SequenceNumber)
+		})
+	}
+}
+
+func TestCreateEventWithSequence_DuplicateCloudEvent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	cloudEventID := "ce-1"
+	newEvent := func(id, source string, cloudEventID *string) *models.Event {
+		return &models.Event{ID: id, Type: "order.created", StreamID: "order-1", Source: source, Data: models.JSON{}, CloudEventID: cloudEventID}
+	}
+
+	require.NoError(t, db.CreateEventWithSequence(newEvent("event-1", "orders", &cloudEventID)))
+	assert.ErrorIs(t, db.CreateEventWithSequence(newEvent("event-2", "orders", &cloudEventID)), ErrDuplicateCloudEvent)
+
+	// The same id from another source, and events not created from a
+	// CloudEvent, are stored
+	require.NoError(t, db.CreateEventWithSequence(newEvent("event-3", "billing", &cloudEventID)))
+	require.NoError(t, db.CreateEventWithSequence(newEvent("event-4", "orders", nil)))
+	require.NoError(t, db.CreateEventWithSequence(newEvent("event-5", "orders", nil)))
+
+	var ids []string
+	require.NoError(t, db.Model(&models.Event{}).Order("sequence_number").Pluck("id", &ids).Error)
+	assert.Equal(t, []string{"event-1", "event-3", "event-4", "event-5"}, ids)
+}
+
+func TestDetectSequenceGapsAfterRetention(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		createdAt := now.Add(-time.Hour)
+		if i <= 2 {
+			createdAt = now.Add(-40 * 24 * time.Hour)
+		}
+		event := models.Event{ID: fmt.Sprintf("event-%d", i), Type: "user.created", StreamID: "stream-1", Source: "test", Data: models.JSON{}, CreatedAt: createdAt}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+	// A gap retention had nothing to do with
+	require.NoError(t, db.Delete(&models.Event{}, "id = ?", "event-4").Error)
+
+	_, _, err := db.DeleteEventsBefore(now.Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+
+	gaps, err := db.DetectSequenceGaps("stream-1")
+	require.NoError(t, err)
+	assert.Equal(t, []models.SequenceGap{{From: 4, To: 4}}, gaps)
+
+	// Pruning again moves the start on
+	require.NoError(t, db.Model(&models.Event{}).Where("id = ?", "event-3").Update("created_at", now.Add(-40*24*time.Hour)).Error)
+	_, _, err = db.DeleteEventsBefore(now.Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+
+	gaps, err = db.DetectSequenceGaps("stream-1")
+	require.NoError(t, err)
+	assert.Equal(t, []models.SequenceGap{{From: 4, To: 4}}, gaps)
+
+	// Missing leading sequences of an unpruned stream still count
+	event := models.Event{ID: "event-other", Type: "user.created", StreamID: "stream-2", Source: "test", Data: models.JSON{}}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+	require.NoError(t, db.Model(&event).Update("sequence_number", 3).Error)
+	gaps, err = db.DetectSequenceGaps("stream-2")
+	require.NoError(t, err)
+	assert.Equal(t, []models.SequenceGap{{From: 1, To: 2}}, gaps)
+}
+
+func TestClaimOutboxEntries(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	require.NoError(t, db.Create(&models.OutboxEntry{EventID: "stale-event", CreatedAt: time.Now()}).Error)
+
+	claimed, err := db.ClaimOutboxEntries(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "stale-event", claimed[0].EventID)
+
+	// Leased to the first claim, so a second dispatcher doesn't take it
+	claimed, err = db.ClaimOutboxEntries(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	var entry models.OutboxEntry
+	require.NoError(t, db.First(&entry, "event_id = ?", "stale-event").Error)
+	assert.WithinDuration(t, time.Now().Add(OutboxLease), entry.LockedUntil, time.Minute)
+}
+
+func TestGormLoggerConfig(t *testing.T) {
+	tests := []struct {
+		logLevel  string
+		wantLevel logger.LogLevel
+	}{
+		{"debug", logger.Info},
+		{"trace", logger.Info},
+		{"info", logger.Warn},
+		{"warn", logger.Warn},
+		{"error", logger.Error},
+		{"fatal", logger.Silent},
+		{"", logger.Warn},
+		{"verbose", logger.Warn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.logLevel, func(t *testing.T) {
+			cfg := gormLoggerConfig(config.DatabaseConfig{SlowQueryMs: 250}, tt.logLevel)
+			assert.Equal(t, tt.wantLevel, cfg.LogLevel)
+			assert.Equal(t, 250*time.Millisecond, cfg.SlowThreshold)
+			assert.True(t, cfg.IgnoreRecordNotFoundError)
+		})
+	}
+
+	// Zero disables slow query logging
+	assert.Zero(t, gormLoggerConfig(config.DatabaseConfig{}, "info").SlowThreshold)
+}
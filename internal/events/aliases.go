@@ -0,0 +1,31 @@
+package events
+
+import "goapitemplate/pkg/models"
+
+// eventTypeNames returns eventType together with every name linked to it
+// through aliases, in either direction and across chained renames, so
+// webhooks subscribed to an old name still match events published under the
+// new one and the other way round. eventType comes first.
+func eventTypeNames(eventType string, aliases []models.EventTypeAlias) []string {
+	if len(aliases) == 0 {
+		return []string{eventType}
+	}
+
+	linked := make(map[string][]string)
+	for _, alias := range aliases {
+		linked[alias.Alias] = append(linked[alias.Alias], alias.EventType)
+		linked[alias.EventType] = append(linked[alias.EventType], alias.Alias)
+	}
+
+	names := []string{eventType}
+	seen := map[string]bool{eventType: true}
+	for i := 0; i < len(names); i++ {
+		for _, name := range linked[names[i]] {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"sync"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// subscriptionBuffer is how many events a live subscription can fall behind
+// before further events are dropped for it
+const subscriptionBuffer = 64
+
+// Subscription streams published events of selected types to an in-process
+// consumer such as a WebSocket client. Events are dropped rather than blocking
+// the publisher when the consumer falls behind.
+type Subscription struct {
+	manager *Manager
+	events  chan models.Event
+
+	mu         sync.RWMutex
+	eventTypes map[string]bool
+	closed     bool
+}
+
+// Watch registers a live subscription. It receives nothing until event types
+// are set with SetEventTypes; call Close when done.
+func (m *Manager) Watch() *Subscription {
+	sub := &Subscription{
+		manager:    m,
+		events:     make(chan models.Event, subscriptionBuffer),
+		eventTypes: make(map[string]bool),
+	}
+
+	m.mu.Lock()
+	m.subscriptions[sub] = struct{}{}
+	m.mu.Unlock()
+
+	return sub
+}
+
+// SubscriptionCount returns the number of live subscriptions
+func (m *Manager) SubscriptionCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.subscriptions)
+}
+
+// Events returns the channel published events are delivered on. It is closed
+// by Close.
+func (s *Subscription) Events() <-chan models.Event {
+	return s.events
+}
+
+// SetEventTypes replaces the event types the subscription receives.
+// AllEventTypes subscribes to every event.
+func (s *Subscription) SetEventTypes(eventTypes []string) {
+	types := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		types[eventType] = true
+	}
+
+	s.mu.Lock()
+	s.eventTypes = types
+	s.mu.Unlock()
+}
+
+// Close unregisters the subscription and closes its event channel
+func (s *Subscription) Close() {
+	s.manager.mu.Lock()
+	delete(s.manager.subscriptions, s)
+	s.manager.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+func (s *Subscription) matches(eventType string) bool {
+	return s.eventTypes[eventType] || s.eventTypes[AllEventTypes]
+}
+
+// offer delivers the event if the subscription wants it, reporting false when
+// it had to be dropped because the subscriber is behind
+func (s *Subscription) offer(event models.Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed || !s.matches(event.Type) {
+		return true
+	}
+
+	select {
+	case s.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// broadcast fans an event out to live subscriptions
+func (m *Manager) broadcast(event models.Event) {
+	m.mu.RLock()
+	subs := make([]*Subscription, 0, len(m.subscriptions))
+	for sub := range m.subscriptions {
+		subs = append(subs, sub)
+	}
+	m.mu.RUnlock()
+
+	for _, sub := range subs {
+		if !sub.offer(event) {
+			m.logger.WithFields(logrus.Fields{
+				"event_type": event.Type,
+				"event_id":   event.ID,
+			}).Warn("Dropped event for slow subscriber")
+		}
+	}
+}
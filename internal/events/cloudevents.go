@@ -0,0 +1,35 @@
+package events
+
+import (
+	"time"
+
+	"goapitemplate/pkg/models"
+)
+
+// Content types a webhook can receive deliveries as
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeCloudEvents = "application/cloudevents+json"
+)
+
+// cloudEvent builds a CloudEvents 1.0 structured-mode envelope for the event.
+// The stream is the subject; sequence number and schema version travel as
+// extension attributes, whose names the spec limits to lowercase alphanumerics.
+func cloudEvent(event models.Event) map[string]interface{} {
+	envelope := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              event.ID,
+		"source":          event.Source,
+		"type":            event.Type,
+		"subject":         event.StreamID,
+		"time":            event.Timestamp.Format(time.RFC3339Nano),
+		"datacontenttype": ContentTypeJSON,
+		"data":            event.Data,
+		"sequencenumber":  event.SequenceNumber,
+		"schemaversion":   schemaVersion(event),
+	}
+	if correlationID, ok := event.Metadata["correlation_id"].(string); ok && correlationID != "" {
+		envelope["correlationid"] = correlationID
+	}
+	return envelope
+}
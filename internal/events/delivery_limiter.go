@@ -0,0 +1,57 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// deliveryLimiter spaces out deliveries to webhooks with a
+// MaxDeliveriesPerMinute, using a token bucket per webhook. A bucket holds up
+// to a minute's worth of deliveries and refills continuously.
+type deliveryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newDeliveryLimiter() *deliveryLimiter {
+	return &deliveryLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// reserve takes a token for a delivery to webhookID at now. It returns zero
+// if the delivery may go ahead, or how long to wait until a token is free.
+// A perMinute of zero or less means no limit.
+func (l *deliveryLimiter) reserve(webhookID string, perMinute int, now time.Time) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(perMinute)
+	ratePerSecond := capacity / 60
+
+	bucket, ok := l.buckets[webhookID]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[webhookID] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * ratePerSecond
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - bucket.tokens) / ratePerSecond * float64(time.Second))
+}
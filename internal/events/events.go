@@ -2,8 +2,7 @@ package events
 
 import (
 	"context"
-	"crypto/rand"
-	"fmt"
+	"errors"
 	"sync"
 	"time"
 
@@ -15,21 +14,91 @@ import (
 
 type Handler func(ctx context.Context, event models.Event) error
 
+// AllEventTypes subscribes a handler to every published event regardless of type
+const AllEventTypes = "*"
+
+// ExecutionMode controls how the handlers for an event type are dispatched
+type ExecutionMode string
+
+const (
+	// ExecutionParallel runs every handler in its own goroutine (the default)
+	ExecutionParallel ExecutionMode = "parallel"
+	// ExecutionSequential runs handlers one after another in registration order
+	ExecutionSequential ExecutionMode = "sequential"
+)
+
+// ExecutionOptions configures handler dispatch for an event type
+type ExecutionOptions struct {
+	Mode ExecutionMode
+	// StopOnError skips the remaining handlers after one fails. Only applies
+	// to sequential execution.
+	StopOnError bool
+	// Wait makes Publish return only once the handlers have finished, or
+	// with the context's error if it is cancelled first
+	Wait bool
+}
+
 type Manager struct {
 	handlers        map[string][]Handler
+	execution       map[string]ExecutionOptions
 	store           EventStore
 	webhookDelivery *WebhookDeliveryService
-	mu              sync.RWMutex
-	logger          *logrus.Logger
+	subscriptions   map[*Subscription]struct{}
+	upcasters       map[string]registeredUpcaster
+	// requirePersistence makes publishing fail when the store does; when
+	// off, a failed save is logged and the event is dispatched anyway
+	requirePersistence bool
+	mu                 sync.RWMutex
+	logger             *logrus.Logger
 }
 
-func NewManager(store EventStore, db *database.DB) *Manager {
+func NewManager(store EventStore, db *database.DB, logger *logrus.Logger) *Manager {
+	webhookDelivery := NewWebhookDeliveryService(db, logger)
+	if relay, ok := store.(OutboxRelay); ok {
+		webhookDelivery.SetOutboxRelay(relay)
+	}
+
 	return &Manager{
-		handlers:        make(map[string][]Handler),
-		store:           store,
-		webhookDelivery: NewWebhookDeliveryService(db),
-		logger:          logrus.New(),
+		handlers:           make(map[string][]Handler),
+		execution:          make(map[string]ExecutionOptions),
+		store:              store,
+		webhookDelivery:    webhookDelivery,
+		subscriptions:      make(map[*Subscription]struct{}),
+		upcasters:          make(map[string]registeredUpcaster),
+		requirePersistence: true,
+		logger:             logger,
+	}
+}
+
+// SetRequirePersistence controls whether Publish returns store errors (the
+// default) or logs them and still dispatches the event to handlers, webhooks
+// and subscribers
+func (m *Manager) SetRequirePersistence(required bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requirePersistence = required
+}
+
+// persistenceFailed logs a store error and reports whether publishing should
+// stop
+func (m *Manager) persistenceFailed(err error, message string) bool {
+	m.mu.RLock()
+	required := m.requirePersistence
+	m.mu.RUnlock()
+
+	if required {
+		m.logger.WithError(err).Error(message)
+		return true
 	}
+	m.logger.WithError(err).Warn(message + "; dispatching to handlers and subscribers only, without persistence")
+	return false
+}
+
+// rejectedByStore reports whether saving failed because the store refused
+// the event rather than because it was unavailable
+func rejectedByStore(err error) bool {
+	return errors.Is(err, database.ErrSequenceConflict) || errors.Is(err, database.ErrDuplicateCloudEvent)
 }
 
 func (m *Manager) Subscribe(eventType string, handler Handler) {
@@ -40,31 +109,147 @@ func (m *Manager) Subscribe(eventType string, handler Handler) {
 	m.logger.WithField("event_type", eventType).Info("Handler subscribed")
 }
 
+// SetExecutionOptions configures how handlers for eventType are executed
+func (m *Manager) SetExecutionOptions(eventType string, opts ExecutionOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.execution[eventType] = opts
+	m.logger.WithFields(logrus.Fields{
+		"event_type":    eventType,
+		"mode":          opts.Mode,
+		"stop_on_error": opts.StopOnError,
+	}).Info("Handler execution options set")
+}
+
+// NewEvent builds an event with a fresh ID, the current timestamp and the
+// default schema version
+func NewEvent(streamID, eventType, source string, data map[string]interface{}) models.Event {
+	return models.Event{
+		ID:            generateEventID(),
+		Type:          eventType,
+		StreamID:      streamID,
+		Source:        source,
+		Data:          models.JSON(data),
+		SchemaVersion: DefaultSchemaVersion,
+		Timestamp:     time.Now().UTC(),
+	}
+}
+
 func (m *Manager) Publish(ctx context.Context, streamID, eventType, source string, data map[string]interface{}) error {
-	event := models.Event{
-		ID:        generateEventID(),
-		Type:      eventType,
-		StreamID:  streamID,
-		Source:    source,
-		Data:      models.JSON(data),
-		Timestamp: time.Now(),
+	return m.PublishEvent(ctx, NewEvent(streamID, eventType, source, data))
+}
+
+// PublishEvent stores a prepared event and dispatches it to handlers and webhooks
+func (m *Manager) PublishEvent(ctx context.Context, event models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	// Store event in database with proper sequence number
-	if err := m.store.SaveEvent(ctx, event); err != nil {
-		m.logger.WithError(err).Error("Failed to save event")
+	persisted := true
+	if err := m.store.SaveEvent(ctx, &event); err != nil {
+		// A sequence conflict or duplicate CloudEvent is the caller's to
+		// resolve, so it is never dispatched without persistence
+		if rejectedByStore(err) || m.persistenceFailed(err, "Failed to save event") {
+			return err
+		}
+		persisted = false
+	}
+
+	return waitForHandlers(ctx, m.dispatch(ctx, event, persisted))
+}
+
+// PublishEvents stores a batch of events atomically, then dispatches each one.
+// The store must implement BatchEventStore.
+func (m *Manager) PublishEvents(ctx context.Context, events []models.Event) error {
+	batchStore, ok := m.store.(BatchEventStore)
+	if !ok {
+		return ErrBatchUnsupported
+	}
+
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	// Process handlers asynchronously
-	go m.processHandlers(ctx, event)
+	persisted := true
+	if err := batchStore.SaveEvents(ctx, events); err != nil {
+		if rejectedByStore(err) || m.persistenceFailed(err, "Failed to save event batch") {
+			return err
+		}
+		persisted = false
+	}
 
-	// Deliver to webhooks asynchronously
-	go m.deliverWebhooks(ctx, event)
+	var pending []<-chan struct{}
+	for _, event := range events {
+		if done := m.dispatch(ctx, event, persisted); done != nil {
+			pending = append(pending, done)
+		}
+	}
+	for _, done := range pending {
+		if err := waitForHandlers(ctx, done); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// dispatch hands an event to its handlers, webhooks and live subscribers.
+// Webhooks are skipped for an event that wasn't persisted, since delivery
+// records reference the stored event. If the event type's handlers are
+// waited for, it returns a channel closed once they have finished; otherwise
+// nil.
+func (m *Manager) dispatch(ctx context.Context, event models.Event, persisted bool) <-chan struct{} {
+	m.mu.RLock()
+	wait := m.execution[event.Type].Wait
+	m.mu.RUnlock()
+
+	// Handlers that aren't waited for outlive the publish, e.g. an HTTP
+	// request, so they keep its values and trace but not its cancellation
+	handlerCtx := ctx
+	if !wait {
+		handlerCtx = context.WithoutCancel(ctx)
+	}
+
+	// Process handlers asynchronously
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.processHandlers(handlerCtx, event)
+	}()
+
+	// Queue webhook deliveries; only the delivery records are written here and
+	// the requests run on the delivery workers. Queuing in publish order lets
+	// ordered webhooks receive a stream's events in sequence.
+	if persisted {
+		m.deliverWebhooks(ctx, event)
+	}
+
+	// Push to live subscribers; this never blocks
+	m.broadcast(event)
+
+	if !wait {
+		return nil
+	}
+	return done
+}
+
+// waitForHandlers blocks until done is closed or ctx is cancelled. A nil done
+// returns immediately.
+func waitForHandlers(ctx context.Context, done <-chan struct{}) error {
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (m *Manager) PublishAsync(ctx context.Context, streamID, eventType, source string, data map[string]interface{}) {
 	go func() {
 		if err := m.Publish(ctx, streamID, eventType, source, data); err != nil {
@@ -75,11 +260,29 @@ func (m *Manager) PublishAsync(ctx context.Context, streamID, eventType, source
 
 func (m *Manager) processHandlers(ctx context.Context, event models.Event) {
 	m.mu.RLock()
-	handlers := m.handlers[event.Type]
+	handlers := make([]Handler, 0, len(m.handlers[event.Type])+len(m.handlers[AllEventTypes]))
+	handlers = append(handlers, m.handlers[event.Type]...)
+	if event.Type != AllEventTypes {
+		handlers = append(handlers, m.handlers[AllEventTypes]...)
+	}
+	opts := m.execution[event.Type]
 	m.mu.RUnlock()
 
+	if opts.Mode == ExecutionSequential {
+		m.processHandlersSequentially(ctx, event, handlers, opts.StopOnError)
+		return
+	}
+
+	var wg sync.WaitGroup
 	for _, handler := range handlers {
+		wg.Add(1)
 		go func(h Handler) {
+			defer wg.Done()
+
+			// Don't start work the caller has already given up on
+			if ctx.Err() != nil {
+				return
+			}
 			if err := h(ctx, event); err != nil {
 				m.logger.WithFields(logrus.Fields{
 					"event_type": event.Type,
@@ -89,6 +292,35 @@ func (m *Manager) processHandlers(ctx context.Context, event models.Event) {
 			}
 		}(handler)
 	}
+	wg.Wait()
+}
+
+// processHandlersSequentially runs handlers in registration order, optionally
+// stopping at the first failure
+func (m *Manager) processHandlersSequentially(ctx context.Context, event models.Event, handlers []Handler, stopOnError bool) {
+	for i, handler := range handlers {
+		if err := ctx.Err(); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"event_type": event.Type,
+				"event_id":   event.ID,
+				"skipped":    len(handlers) - i,
+			}).Warn("Context cancelled, skipping remaining handlers")
+			return
+		}
+
+		if err := handler(ctx, event); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"event_type": event.Type,
+				"event_id":   event.ID,
+				"handler":    i,
+				"error":      err,
+			}).Error("Handler failed")
+
+			if stopOnError {
+				return
+			}
+		}
+	}
 }
 
 func (m *Manager) deliverWebhooks(ctx context.Context, event models.Event) {
@@ -108,9 +340,3 @@ func (m *Manager) GetStore() EventStore {
 func (m *Manager) GetWebhookDeliveryService() *WebhookDeliveryService {
 	return m.webhookDelivery
 }
-
-func generateEventID() string {
-	bytes := make([]byte, 16)
-	rand.Read(bytes)
-	return fmt.Sprintf("%x-%x-%x-%x-%x", bytes[0:4], bytes[4:6], bytes[6:8], bytes[8:10], bytes[10:16])
-}
\ No newline at end of file
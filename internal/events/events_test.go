@@ -2,12 +2,19 @@ package events
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"goapitemplate/internal/database"
 	"goapitemplate/pkg/models"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -35,7 +42,7 @@ func TestManager_Subscribe_and_Publish(t *testing.T) {
 	defer db.Close()
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	// Test data
 	handlerCalled := false
@@ -86,7 +93,7 @@ func TestManager_MultipleHandlers(t *testing.T) {
 	defer db.Close()
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	// Test tracking
 	handler1Called := false
@@ -123,7 +130,7 @@ func TestManager_NoMatchingHandlers(t *testing.T) {
 	defer db.Close()
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	handlerCalled := false
 
@@ -157,7 +164,7 @@ func TestManager_PublishAsync(t *testing.T) {
 	defer db.Close()
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	// Publish event asynchronously
 	manager.PublishAsync(context.Background(), "async-stream", "async.event", "async-service", map[string]interface{}{"async": true})
@@ -181,7 +188,7 @@ func TestManager_EventSequencing(t *testing.T) {
 	defer db.Close()
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	streamID := "sequencing-test-stream"
 
@@ -211,6 +218,132 @@ func TestManager_EventSequencing(t *testing.T) {
 	}
 }
 
+func TestManager_SequentialHandlers(t *testing.T) {
+	db := setupEventTestDB(t)
+	defer db.Close()
+
+	store := NewDBEventStore(db)
+	manager := NewManager(store, db, logrus.New())
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string, err error) Handler {
+		return func(ctx context.Context, event models.Event) error {
+			// Slow the first handler so parallel execution would reorder
+			if name == "validate" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return err
+		}
+	}
+
+	manager.SetExecutionOptions("order.created", ExecutionOptions{Mode: ExecutionSequential, StopOnError: true})
+	manager.Subscribe("order.created", record("validate", nil))
+	manager.Subscribe("order.created", record("enrich", errors.New("enrichment failed")))
+	manager.Subscribe("order.created", record("persist", nil))
+
+	err := manager.Publish(context.Background(), "order-stream", "order.created", "order-service", map[string]interface{}{"order_id": 1})
+	assert.NoError(t, err)
+
+	// Allow time for async processing
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Handlers ran in registration order and the failure halted the rest
+	assert.Equal(t, []string{"validate", "enrich"}, order)
+}
+
+func TestManager_PublishHonorsCancellation(t *testing.T) {
+	db := setupEventTestDB(t)
+	defer db.Close()
+
+	store := NewDBEventStore(db)
+	manager := NewManager(store, db, logrus.New())
+	manager.SetExecutionOptions("report.requested", ExecutionOptions{Wait: true})
+
+	handlerCancelled := make(chan struct{})
+	manager.Subscribe("report.requested", func(ctx context.Context, event models.Event) error {
+		select {
+		case <-ctx.Done():
+			close(handlerCancelled)
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+
+	t.Run("cancelled while a handler runs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := manager.Publish(ctx, "report-stream", "report.requested", "report-service", nil)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second)
+
+		select {
+		case <-handlerCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("handler did not observe the cancellation")
+		}
+	})
+
+	t.Run("cancelled before publishing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := manager.Publish(ctx, "cancelled-stream", "report.requested", "report-service", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		var count int64
+		require.NoError(t, db.Model(&models.Event{}).Where("stream_id = ?", "cancelled-stream").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("waits for handlers to finish", func(t *testing.T) {
+		var ran atomic.Bool
+		manager.SetExecutionOptions("report.generated", ExecutionOptions{Wait: true})
+		manager.Subscribe("report.generated", func(ctx context.Context, event models.Event) error {
+			time.Sleep(20 * time.Millisecond)
+			ran.Store(true)
+			return nil
+		})
+
+		err := manager.Publish(context.Background(), "report-stream", "report.generated", "report-service", nil)
+		assert.NoError(t, err)
+		assert.True(t, ran.Load())
+	})
+
+	t.Run("handlers not waited for outlive the publish context", func(t *testing.T) {
+		type ctxKey struct{}
+		handled := make(chan error, 1)
+		manager.Subscribe("report.archived", func(ctx context.Context, event models.Event) error {
+			time.Sleep(20 * time.Millisecond)
+			assert.Equal(t, "request-1", ctx.Value(ctxKey{}))
+			handled <- ctx.Err()
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request-1"))
+		err := manager.Publish(ctx, "report-stream", "report.archived", "report-service", nil)
+		cancel()
+		require.NoError(t, err)
+
+		select {
+		case err := <-handled:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("handler did not run")
+		}
+	})
+}
+
 func TestDBEventStore_SaveEvent(t *testing.T) {
 	db := setupEventTestDB(t)
 	defer db.Close()
@@ -225,7 +358,7 @@ func TestDBEventStore_SaveEvent(t *testing.T) {
 		Data:     models.JSON{"test": "data"},
 	}
 
-	err := store.SaveEvent(context.Background(), event)
+	err := store.SaveEvent(context.Background(), &event)
 	assert.NoError(t, err)
 
 	// Verify event was saved with sequence number
@@ -271,7 +404,7 @@ func TestDBEventStore_GetEvents(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := store.SaveEvent(context.Background(), event)
+		err := store.SaveEvent(context.Background(), &event)
 		require.NoError(t, err)
 	}
 
@@ -351,7 +484,7 @@ func TestDBEventStore_GetEventsByStream(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := store.SaveEvent(context.Background(), event)
+		err := store.SaveEvent(context.Background(), &event)
 		require.NoError(t, err)
 	}
 
@@ -411,7 +544,7 @@ func TestDBEventStore_GetEventStreams(t *testing.T) {
 	}
 
 	for _, event := range events {
-		err := store.SaveEvent(context.Background(), event)
+		err := store.SaveEvent(context.Background(), &event)
 		require.NoError(t, err)
 	}
 
@@ -446,7 +579,7 @@ func BenchmarkEventPublish(b *testing.B) {
 	}
 
 	store := NewDBEventStore(db)
-	manager := NewManager(store, db)
+	manager := NewManager(store, db, logrus.New())
 
 	testData := map[string]interface{}{
 		"benchmark": true,
@@ -461,4 +594,120 @@ func BenchmarkEventPublish(b *testing.B) {
 			b.Error(err)
 		}
 	}
-}
\ No newline at end of file
+}
+// failingStore rejects every save
+type failingStore struct {
+	EventStore
+}
+
+func (failingStore) SaveEvent(ctx context.Context, event *models.Event) error {
+	return errors.New("database is unavailable")
+}
+
+func TestManager_PublishRequirePersistence(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	createTestWebhook(t, db, []string{"user.created"})
+
+	for _, required := range []bool{true, false} {
+		manager := NewManager(failingStore{NewDBEventStore(db)}, db, logrus.New())
+		manager.SetRequirePersistence(required)
+
+		var handled atomic.Int32
+		manager.Subscribe("user.created", func(ctx context.Context, event models.Event) error {
+			handled.Add(1)
+			return nil
+		})
+		manager.SetExecutionOptions("user.created", ExecutionOptions{Wait: true})
+
+		err := manager.Publish(context.Background(), "user-1", "user.created", "test", nil)
+		if required {
+			assert.EqualError(t, err, "database is unavailable")
+			assert.Equal(t, int32(0), handled.Load(), "handlers must not run for an unsaved event")
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), handled.Load())
+		}
+
+		// Delivery records would reference an event that was never stored
+		var deliveries int64
+		require.NoError(t, db.Model(&models.WebhookDelivery{}).Count(&deliveries).Error)
+		assert.Zero(t, deliveries)
+	}
+}
+
+func TestManager_PublishCancelledWhileQueueFull(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	manager := NewManager(NewDBEventStore(db), db, logrus.New())
+	service := manager.GetWebhookDeliveryService()
+	service.SetMaxConcurrentDeliveries(1)
+
+	release := make(chan struct{})
+	unblock := sync.OnceFunc(func() { close(release) })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer unblock()
+
+	webhook := createTestWebhook(t, db, []string{"user.created"})
+	require.NoError(t, db.Model(&webhook).Update("url", server.URL).Error)
+
+	// One delivery holds the only worker and the next fills the queue
+	for i := 0; i < 2; i++ {
+		require.NoError(t, manager.Publish(context.Background(), "user-1", "user.created", "test", nil))
+	}
+	require.Eventually(t, func() bool { return len(service.jobs) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	event := NewEvent("user-1", "user.created", "test", nil)
+
+	done := make(chan error, 1)
+	go func() { done <- manager.PublishEvent(ctx, event) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish kept waiting for a worker after its context was done")
+	}
+
+	// The event is stored and its delivery left for the retry scheduler
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery, "event_id = ?", event.ID).Error)
+	assert.Equal(t, "pending", delivery.Status)
+	assert.NotNil(t, delivery.NextRetry)
+
+	unblock()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	require.NoError(t, service.Shutdown(shutdownCtx))
+}
+
+func TestGenerateEventID(t *testing.T) {
+	t.Run("IDs are unique", func(t *testing.T) {
+		seen := make(map[string]bool, 10000)
+		for i := 0; i < 10000; i++ {
+			id := NewEvent("stream", "test.event", "test", nil).ID
+			require.False(t, seen[id], "duplicate event ID %s", id)
+			seen[id] = true
+		}
+	})
+
+	t.Run("generator is pluggable", func(t *testing.T) {
+		defer SetIDGenerator(nil)
+
+		next := 0
+		SetIDGenerator(IDGeneratorFunc(func() string {
+			next++
+			return fmt.Sprintf("custom-%d", next)
+		}))
+		assert.Equal(t, "custom-1", NewEvent("stream", "test.event", "test", nil).ID)
+		assert.Equal(t, "custom-2", NewEvent("stream", "test.event", "test", nil).ID)
+
+		SetIDGenerator(nil)
+		assert.Len(t, NewEvent("stream", "test.event", "test", nil).ID, 36)
+	})
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator produces the IDs of new events. Implementations must be safe
+// for concurrent use and must not repeat IDs.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to IDGenerator
+type IDGeneratorFunc func() string
+
+// NewID calls f
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUIDGenerator generates random (version 4) UUIDs; it is the default
+type UUIDGenerator struct{}
+
+// NewID returns a new random UUID
+func (UUIDGenerator) NewID() string {
+	return uuid.NewString()
+}
+
+var (
+	idGenerator   IDGenerator = UUIDGenerator{}
+	idGeneratorMu sync.RWMutex
+)
+
+// SetIDGenerator replaces the generator NewEvent uses for event IDs, e.g.
+// for time-ordered IDs; nil restores the default random UUIDs
+func SetIDGenerator(gen IDGenerator) {
+	if gen == nil {
+		gen = UUIDGenerator{}
+	}
+
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	idGenerator = gen
+}
+
+func generateEventID() string {
+	idGeneratorMu.RLock()
+	gen := idGenerator
+	idGeneratorMu.RUnlock()
+
+	return gen.NewID()
+}
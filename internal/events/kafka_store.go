@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"goapitemplate/internal/config"
+	"goapitemplate/pkg/models"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrKafkaReadUnsupported is returned by the read methods of a KafkaEventStore
+// without a database to read from. Events published to Kafka should be
+// consumed from the topic directly.
+var ErrKafkaReadUnsupported = errors.New("reading events is not supported by the kafka event store")
+
+// KafkaProducer is the subset of kafka.Writer used by KafkaEventStore
+type KafkaProducer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaEventStore produces events to Kafka. With records set, each event is
+// also saved to the database first, so it gets its sequence number and
+// webhook deliveries and the read endpoints have the row they rely on. The
+// event's outbox entry then records whether it was produced; one saved but
+// not produced, e.g. because the process stopped in between, is produced by
+// the outbox dispatcher through RelayEvent before its webhooks are delivered.
+type KafkaEventStore struct {
+	producer KafkaProducer
+	records  *DBEventStore
+}
+
+// NewKafkaEventStore creates a store producing to the configured topic. Messages
+// are keyed by stream ID and hash-partitioned so per-stream ordering is kept.
+// records, if not nil, keeps the database copy of each event.
+func NewKafkaEventStore(cfg config.KafkaConfig, records *DBEventStore) *KafkaEventStore {
+	return NewKafkaEventStoreWithProducer(&kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}, records)
+}
+
+func NewKafkaEventStoreWithProducer(producer KafkaProducer, records *DBEventStore) *KafkaEventStore {
+	return &KafkaEventStore{producer: producer, records: records}
+}
+
+func (s *KafkaEventStore) SaveEvent(ctx context.Context, event *models.Event) error {
+	if s.records == nil {
+		return s.produce(ctx, event)
+	}
+
+	if err := s.records.SaveEvent(ctx, event); err != nil {
+		return err
+	}
+	if err := s.produce(ctx, event); err != nil {
+		// Nothing has been dispatched yet, so take the row back out and
+		// let the caller retry the whole save
+		if deleteErr := s.records.deleteEvent(context.WithoutCancel(ctx), event.ID); deleteErr != nil {
+			return fmt.Errorf("%w (and failed to remove the saved event: %v)", err, deleteErr)
+		}
+		return err
+	}
+
+	// Should this fail, the outbox dispatcher produces the event again if
+	// its webhooks aren't all recorded, which consumers see as a duplicate
+	_ = s.records.db.MarkOutboxEntryRelayed(context.WithoutCancel(ctx), event.ID)
+	return nil
+}
+
+// RelayEvent produces an event that was saved but may not have reached the
+// topic, and records on its outbox entry that it has. It implements
+// OutboxRelay.
+func (s *KafkaEventStore) RelayEvent(ctx context.Context, event models.Event) error {
+	if err := s.produce(ctx, &event); err != nil {
+		return err
+	}
+	if s.records == nil {
+		return nil
+	}
+	return s.records.db.MarkOutboxEntryRelayed(ctx, event.ID)
+}
+
+// produce writes event to the topic, keyed by its stream ID
+func (s *KafkaEventStore) produce(ctx context.Context, event *models.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	message := kafka.Message{
+		Key:   []byte(event.StreamID),
+		Value: value,
+		Time:  event.Timestamp,
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte(event.Type)},
+			{Key: "event_id", Value: []byte(event.ID)},
+		},
+	}
+
+	if err := s.producer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to produce event to kafka: %w", err)
+	}
+
+	return nil
+}
+
+func (s *KafkaEventStore) GetEvents(ctx context.Context, eventType string, limit int) ([]models.Event, error) {
+	if s.records == nil {
+		return nil, ErrKafkaReadUnsupported
+	}
+	return s.records.GetEvents(ctx, eventType, limit)
+}
+
+func (s *KafkaEventStore) GetEventsByStream(ctx context.Context, streamID string, limit int) ([]models.Event, error) {
+	if s.records == nil {
+		return nil, ErrKafkaReadUnsupported
+	}
+	return s.records.GetEventsByStream(ctx, streamID, limit)
+}
+
+func (s *KafkaEventStore) GetEventStreams(ctx context.Context, limit int) ([]string, error) {
+	if s.records == nil {
+		return nil, ErrKafkaReadUnsupported
+	}
+	return s.records.GetEventStreams(ctx, limit)
+}
+
+func (s *KafkaEventStore) Close() error {
+	return s.producer.Close()
+}
@@ -0,0 +1,203 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKafkaProducer records produced messages instead of sending them
+type mockKafkaProducer struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (m *mockKafkaProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msgs...)
+	return nil
+}
+
+func (m *mockKafkaProducer) Close() error {
+	return nil
+}
+
+func TestKafkaEventStore_SaveEvent(t *testing.T) {
+	producer := &mockKafkaProducer{}
+	store := NewKafkaEventStoreWithProducer(producer, nil)
+
+	event := models.Event{
+		ID:        "test-event-id",
+		Type:      "user.created",
+		StreamID:  "user-stream-123",
+		Source:    "user-service",
+		Data:      models.JSON{"user_id": "123"},
+		Timestamp: time.Now(),
+	}
+
+	err := store.SaveEvent(context.Background(), &event)
+	require.NoError(t, err)
+	require.Len(t, producer.messages, 1)
+
+	message := producer.messages[0]
+	assert.Equal(t, "user-stream-123", string(message.Key))
+
+	var produced models.Event
+	err = json.Unmarshal(message.Value, &produced)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, produced.ID)
+	assert.Equal(t, event.Type, produced.Type)
+	assert.Equal(t, event.StreamID, produced.StreamID)
+}
+
+func TestKafkaEventStore_SaveEventProducerError(t *testing.T) {
+	producer := &mockKafkaProducer{err: errors.New("broker unavailable")}
+	store := NewKafkaEventStoreWithProducer(producer, nil)
+
+	err := store.SaveEvent(context.Background(), &models.Event{ID: "event-1", StreamID: "stream-1"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broker unavailable")
+}
+
+func TestKafkaEventStore_ReadsUnsupported(t *testing.T) {
+	store := NewKafkaEventStoreWithProducer(&mockKafkaProducer{}, nil)
+
+	_, err := store.GetEvents(context.Background(), "", 10)
+	assert.ErrorIs(t, err, ErrKafkaReadUnsupported)
+
+	_, err = store.GetEventsByStream(context.Background(), "stream-1", 10)
+	assert.ErrorIs(t, err, ErrKafkaReadUnsupported)
+
+	_, err = store.GetEventStreams(context.Background(), 10)
+	assert.ErrorIs(t, err, ErrKafkaReadUnsupported)
+}
+
+func TestKafkaEventStore_SavesDatabaseRecord(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	producer := &mockKafkaProducer{}
+	store := NewKafkaEventStoreWithProducer(producer, NewDBEventStore(db))
+	manager := NewManager(store, db, logrus.New())
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	webhook := createTestWebhook(t, db, []string{"user.created"})
+	require.NoError(t, db.Model(&webhook).Update("url", receiver.URL).Error)
+
+	require.NoError(t, manager.Publish(context.Background(), "user-stream-123", "user.created", "user-service", map[string]interface{}{"user_id": "123"}))
+	require.Len(t, producer.messages, 1)
+
+	// The message carries the sequence number the database assigned
+	var produced models.Event
+	require.NoError(t, json.Unmarshal(producer.messages[0].Value, &produced))
+	assert.Equal(t, int64(1), produced.SequenceNumber)
+
+	// Webhook deliveries reference the stored event
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, produced.ID, delivery.EventID)
+
+	events, err := store.GetEventsByStream(context.Background(), "user-stream-123", 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, produced.ID, events[0].ID)
+
+	require.NoError(t, manager.GetWebhookDeliveryService().Shutdown(context.Background()))
+
+	t.Run("producer error removes the record", func(t *testing.T) {
+		producer.err = errors.New("broker unavailable")
+
+		event := models.Event{ID: "unproduced", Type: "user.created", StreamID: "user-stream-123", Source: "user-service", Timestamp: time.Now()}
+		err := store.SaveEvent(context.Background(), &event)
+		assert.ErrorContains(t, err, "broker unavailable")
+
+		var count int64
+		require.NoError(t, db.Model(&models.Event{}).Where("id = ?", "unproduced").Count(&count).Error)
+		assert.Zero(t, count)
+		require.NoError(t, db.Model(&models.OutboxEntry{}).Where("event_id = ?", "unproduced").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestKafkaEventStore_OutboxProducesUnrelayedEvents(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	producer := &mockKafkaProducer{}
+	records := NewDBEventStore(db)
+	store := NewKafkaEventStoreWithProducer(producer, records)
+	service := NewManager(store, db, logrus.New()).GetWebhookDeliveryService()
+	defer service.Shutdown(context.Background())
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	webhook := createTestWebhook(t, db, []string{"user.created"})
+	require.NoError(t, db.Model(&webhook).Update("url", receiver.URL).Error)
+
+	expireLeases := func() {
+		require.NoError(t, db.Model(&models.OutboxEntry{}).Where("1 = 1").Update("locked_until", time.Now().Add(-time.Minute)).Error)
+	}
+	deliveries := func(eventID string) int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("event_id = ?", eventID).Count(&count).Error)
+		return count
+	}
+
+	// Saved to the database, then the process stopped before producing it
+	crashed := NewEvent("user-1", "user.created", "user-service", nil)
+	require.NoError(t, records.SaveEvent(context.Background(), &crashed))
+	expireLeases()
+
+	t.Run("not delivered until produced", func(t *testing.T) {
+		producer.err = errors.New("broker unavailable")
+		defer func() { producer.err = nil }()
+
+		dispatched, err := service.DispatchOutbox(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, dispatched)
+		assert.Zero(t, deliveries(crashed.ID))
+	})
+
+	t.Run("produced, then delivered", func(t *testing.T) {
+		expireLeases()
+
+		dispatched, err := service.DispatchOutbox(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+		require.Len(t, producer.messages, 1)
+		assert.Equal(t, crashed.ID, string(producer.messages[0].Headers[1].Value))
+		assert.Equal(t, int64(1), deliveries(crashed.ID))
+	})
+
+	t.Run("produced events aren't produced again", func(t *testing.T) {
+		// Produced, then the process stopped before delivering it
+		event := NewEvent("user-2", "user.created", "user-service", nil)
+		require.NoError(t, store.SaveEvent(context.Background(), &event))
+		require.Len(t, producer.messages, 2)
+		expireLeases()
+
+		dispatched, err := service.DispatchOutbox(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+		assert.Len(t, producer.messages, 2)
+		assert.Equal(t, int64(1), deliveries(event.ID))
+	})
+}
@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"goapitemplate/internal/config"
+	"goapitemplate/pkg/models"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/sirupsen/logrus"
+)
+
+// NATSPublisher mirrors events to JetStream subjects of the form
+// <prefix>.<event type>. It is registered as a Manager handler, so a slow or
+// unreachable NATS server never blocks event publishing.
+type NATSPublisher struct {
+	conn          *nats.Conn
+	js            jetstream.JetStream
+	stream        string
+	subjectPrefix string
+	timeout       time.Duration
+	logger        *logrus.Logger
+
+	mu          sync.Mutex
+	streamReady bool
+}
+
+// NewNATSPublisher connects to NATS. An unreachable server is not an error:
+// the connection keeps retrying in the background and publishes fail (and are
+// logged) until it comes up.
+func NewNATSPublisher(cfg config.NATSConfig) (*NATSPublisher, error) {
+	logger := logrus.New()
+
+	conn, err := nats.Connect(cfg.URL,
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.WithError(err).Warn("Disconnected from NATS")
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			logger.Info("Reconnected to NATS")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	return &NATSPublisher{
+		conn:          conn,
+		js:            js,
+		stream:        cfg.Stream,
+		subjectPrefix: cfg.SubjectPrefix,
+		timeout:       5 * time.Second,
+		logger:        logger,
+	}, nil
+}
+
+// Handle publishes an event to JetStream. It has the Handler signature so it
+// can be passed to Manager.Subscribe.
+func (p *NATSPublisher) Handle(ctx context.Context, event models.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := p.ensureStream(ctx); err != nil {
+		p.logger.WithError(err).WithField("event_id", event.ID).Warn("Failed to publish event to NATS")
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := nats.NewMsg(p.Subject(event.Type))
+	msg.Data = payload
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	msg.Header.Set("X-Event-Stream", event.StreamID)
+
+	if _, err := p.js.PublishMsg(ctx, msg); err != nil {
+		p.logger.WithError(err).WithField("event_id", event.ID).Warn("Failed to publish event to NATS")
+		return fmt.Errorf("failed to publish event to NATS: %w", err)
+	}
+
+	return nil
+}
+
+// Subject returns the subject an event type is published on
+func (p *NATSPublisher) Subject(eventType string) string {
+	return p.subjectPrefix + "." + eventType
+}
+
+// ensureStream creates the JetStream stream capturing the publisher's subjects
+// the first time it is needed
+func (p *NATSPublisher) ensureStream(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.streamReady {
+		return nil
+	}
+
+	_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     p.stream,
+		Subjects: []string{p.subjectPrefix + ".>"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream stream: %w", err)
+	}
+
+	p.streamReady = true
+	return nil
+}
+
+func (p *NATSPublisher) Close() {
+	p.conn.Close()
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"goapitemplate/internal/config"
+	"goapitemplate/pkg/models"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestNATSServer(t *testing.T) *server.Server {
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func TestNATSPublisher_PublishesToEventSubject(t *testing.T) {
+	srv := startTestNATSServer(t)
+
+	db := setupEventTestDB(t)
+	defer db.Close()
+
+	publisher, err := NewNATSPublisher(config.NATSConfig{
+		URL:           srv.ClientURL(),
+		Stream:        "EVENTS",
+		SubjectPrefix: "events",
+	})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	// Listen on the expected subject with a plain NATS subscriber
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	received := make(chan *nats.Msg, 1)
+	sub, err := conn.ChanSubscribe("events.user.created", received)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	manager := NewManager(NewDBEventStore(db), db, logrus.New())
+	manager.Subscribe(AllEventTypes, publisher.Handle)
+
+	err = manager.Publish(context.Background(), "user-stream-123", "user.created", "user-service", map[string]interface{}{"user_id": "123"})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "events.user.created", msg.Subject)
+
+		var event models.Event
+		err = json.Unmarshal(msg.Data, &event)
+		require.NoError(t, err)
+		assert.Equal(t, "user.created", event.Type)
+		assert.Equal(t, "user-stream-123", event.StreamID)
+		assert.Equal(t, event.ID, msg.Header.Get(nats.MsgIdHdr))
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not published to NATS")
+	}
+}
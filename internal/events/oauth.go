@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"goapitemplate/pkg/models"
+)
+
+// tokenExpiryLeeway refreshes tokens slightly before they expire so a token
+// doesn't lapse while a delivery is in flight
+const tokenExpiryLeeway = 30 * time.Second
+
+type oauthToken struct {
+	accessToken string
+	expiresAt   time.Time // Zero when the token server gave no expiry
+}
+
+func (t oauthToken) valid() bool {
+	return t.accessToken != "" && (t.expiresAt.IsZero() || time.Now().Before(t.expiresAt))
+}
+
+// oauthTokenSource fetches and caches OAuth2 client-credentials tokens per
+// webhook credential set
+type oauthTokenSource struct {
+	client *http.Client
+	mu     sync.Mutex
+	tokens map[string]oauthToken
+}
+
+func newOAuthTokenSource(client *http.Client) *oauthTokenSource {
+	return &oauthTokenSource{
+		client: client,
+		tokens: make(map[string]oauthToken),
+	}
+}
+
+// Token returns a cached token for the webhook, fetching a new one when none
+// is cached, the cached one has expired, or forceRefresh is set
+func (s *oauthTokenSource) Token(ctx context.Context, webhook models.WebhookEndpoint, forceRefresh bool) (string, error) {
+	key := oauthCacheKey(webhook)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.tokens[key]; ok && !forceRefresh && token.valid() {
+		return token.accessToken, nil
+	}
+
+	token, err := s.fetch(ctx, webhook)
+	if err != nil {
+		delete(s.tokens, key)
+		return "", err
+	}
+
+	s.tokens[key] = token
+	return token.accessToken, nil
+}
+
+// fetch requests a new token from the webhook's token endpoint
+func (s *oauthTokenSource) fetch(ctx context.Context, webhook models.WebhookEndpoint) (oauthToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if webhook.OAuthScopes != "" {
+		form.Set("scope", webhook.OAuthScopes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.OAuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(webhook.OAuthClientID), url.QueryEscape(webhook.OAuthClientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return oauthToken{}, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return oauthToken{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("token response did not include an access token")
+	}
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return oauthToken{}, fmt.Errorf("unsupported token type %q", tokenResp.TokenType)
+	}
+
+	token := oauthToken{accessToken: tokenResp.AccessToken}
+	if tokenResp.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	}
+	return token, nil
+}
+
+// oauthCacheKey identifies a credential set so edits to a webhook's OAuth
+// settings don't reuse a token issued for the old ones
+func oauthCacheKey(webhook models.WebhookEndpoint) string {
+	return strings.Join([]string{webhook.ID, webhook.OAuthTokenURL, webhook.OAuthClientID, webhook.OAuthScopes}, "\x00")
+}
+
+// usesOAuth reports whether deliveries to the webhook need a bearer token
+func usesOAuth(webhook models.WebhookEndpoint) bool {
+	return webhook.OAuthTokenURL != ""
+}
@@ -0,0 +1,59 @@
+package events
+
+// PayloadSchema returns a JSON Schema (draft 2020-12) describing the default
+// webhook payload envelope built by webhookPayload. Webhooks with a
+// payload_template or the CloudEvents content type receive a different body.
+func PayloadSchema() map[string]interface{} {
+	stringProperty := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": description}
+	}
+
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Webhook payload",
+		"description": "Body of each webhook delivery sent as application/json",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"event_id":   stringProperty("Unique event ID; receivers can use it to ignore duplicate deliveries"),
+			"event_type": stringProperty("Event type, e.g. order.created"),
+			"stream_id":  stringProperty("Stream the event belongs to"),
+			"source":     stringProperty("Service that published the event"),
+			"data": map[string]interface{}{
+				"type":        "object",
+				"description": "Event data, as published",
+			},
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "When the event happened (RFC 3339)",
+			},
+			"sequence_number": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Position of the event within its stream",
+			},
+			"schema_version": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Version of the data shape for this event type",
+			},
+			"metadata": map[string]interface{}{
+				"type":        "object",
+				"description": "Event metadata such as correlation_id; omitted when empty",
+			},
+			"webhook": map[string]interface{}{
+				"type":        "object",
+				"description": "The webhook the delivery is for; only sent when include_webhook_info is set",
+				"properties": map[string]interface{}{
+					"id":   stringProperty("Webhook ID"),
+					"name": stringProperty("Webhook name"),
+				},
+				"required": []string{"id", "name"},
+			},
+		},
+		"required": []string{
+			"event_id", "event_type", "stream_id", "source", "data",
+			"timestamp", "sequence_number", "schema_version",
+		},
+	}
+}
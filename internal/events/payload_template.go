@@ -0,0 +1,44 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// payloadTemplateFuncs are available to webhook payload templates. json
+// encodes a value, so {{json .data}} inserts the event data as JSON and
+// {{json .event_id}} a quoted, escaped string.
+var payloadTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+}
+
+// ParsePayloadTemplate parses a webhook payload template. Templates are
+// executed with the default payload fields (event_id, event_type, stream_id,
+// source, data, metadata, timestamp, sequence_number and, when enabled,
+// webhook) and their output is sent as the request body.
+func ParsePayloadTemplate(text string) (*template.Template, error) {
+	return template.New("payload").Funcs(payloadTemplateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// renderPayload executes the webhook's payload template over the default
+// payload fields
+func renderPayload(text string, payload map[string]interface{}) ([]byte, error) {
+	tmpl, err := ParsePayloadTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
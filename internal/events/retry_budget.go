@@ -0,0 +1,83 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"goapitemplate/pkg/models"
+)
+
+// retryBudgetWindow is how long a webhook's MaxRetriesPerDay budget lasts
+const retryBudgetWindow = 24 * time.Hour
+
+// retryBudget caps the retries sent to webhooks with a MaxRetriesPerDay,
+// counted across all of a webhook's deliveries. Each budget covers a window
+// starting at the webhook's first retry and is refilled once it ends.
+type retryBudget struct {
+	mu      sync.Mutex
+	windows map[string]*budgetWindow
+}
+
+type budgetWindow struct {
+	start time.Time
+	used  int
+}
+
+func newRetryBudget() *retryBudget {
+	return &retryBudget{windows: make(map[string]*budgetWindow)}
+}
+
+// take uses up one retry of webhookID's budget at now. It returns false and
+// when the budget resets if none is left. A perDay of zero or less means no
+// limit.
+func (b *retryBudget) take(webhookID string, perDay int, now time.Time) (time.Time, bool) {
+	if perDay <= 0 {
+		return time.Time{}, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	window := b.current(webhookID, now)
+	if window == nil {
+		window = &budgetWindow{start: now}
+		b.windows[webhookID] = window
+	}
+
+	resetsAt := window.start.Add(retryBudgetWindow)
+	if window.used >= perDay {
+		return resetsAt, false
+	}
+	window.used++
+	return resetsAt, true
+}
+
+// status reports how much of webhookID's budget is left at now
+func (b *retryBudget) status(webhookID string, perDay int, now time.Time) models.RetryBudget {
+	budget := models.RetryBudget{Limit: perDay, Remaining: perDay}
+	if perDay <= 0 {
+		return budget
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if window := b.current(webhookID, now); window != nil {
+		resetsAt := window.start.Add(retryBudgetWindow)
+		budget.Used = window.used
+		budget.Remaining = max(perDay-window.used, 0)
+		budget.Exhausted = budget.Remaining == 0
+		budget.ResetsAt = &resetsAt
+	}
+	return budget
+}
+
+// current returns webhookID's window if it hasn't ended by now. The caller
+// must hold mu.
+func (b *retryBudget) current(webhookID string, now time.Time) *budgetWindow {
+	window, ok := b.windows[webhookID]
+	if !ok || !now.Before(window.start.Add(retryBudgetWindow)) {
+		return nil
+	}
+	return window
+}
@@ -2,18 +2,41 @@ package events
 
 import (
 	"context"
+	"errors"
 
 	"goapitemplate/internal/database"
 	"goapitemplate/pkg/models"
+
+	"gorm.io/gorm"
 )
 
 type EventStore interface {
-	SaveEvent(ctx context.Context, event models.Event) error
+	// SaveEvent stores event, filling in fields the store assigns such as
+	// its sequence number
+	SaveEvent(ctx context.Context, event *models.Event) error
 	GetEvents(ctx context.Context, eventType string, limit int) ([]models.Event, error)
 	GetEventsByStream(ctx context.Context, streamID string, limit int) ([]models.Event, error)
 	GetEventStreams(ctx context.Context, limit int) ([]string, error)
 }
 
+// BatchEventStore is implemented by stores that can save several events in a
+// single all-or-nothing operation
+type BatchEventStore interface {
+	SaveEvents(ctx context.Context, events []models.Event) error
+}
+
+// OutboxRelay is implemented by stores that hand saved events on to another
+// system, such as a message broker. The outbox dispatcher relays an event
+// whose entry isn't marked relayed before delivering its webhooks, so an
+// event saved just before a crash still reaches that system first.
+type OutboxRelay interface {
+	RelayEvent(ctx context.Context, event models.Event) error
+}
+
+// ErrBatchUnsupported is returned when atomic batches are requested from a
+// store that does not implement BatchEventStore
+var ErrBatchUnsupported = errors.New("event store does not support atomic batches")
+
 type DBEventStore struct {
 	db *database.DB
 }
@@ -22,9 +45,28 @@ func NewDBEventStore(db *database.DB) *DBEventStore {
 	return &DBEventStore{db: db}
 }
 
-func (s *DBEventStore) SaveEvent(ctx context.Context, event models.Event) error {
+func (s *DBEventStore) SaveEvent(ctx context.Context, event *models.Event) error {
 	// Use the database method that handles sequence numbering
-	return s.db.CreateEventWithSequence(&event)
+	return s.db.ForContext(ctx).CreateEventWithSequence(event)
+}
+
+// deleteEvent removes an event saved by SaveEvent together with its outbox
+// entry, before anything has been dispatched for it
+func (s *DBEventStore) deleteEvent(ctx context.Context, eventID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.OutboxEntry{}, "event_id = ?", eventID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Event{}, "id = ?", eventID).Error
+	})
+}
+
+func (s *DBEventStore) SaveEvents(ctx context.Context, events []models.Event) error {
+	batch := make([]*models.Event, len(events))
+	for i := range events {
+		batch[i] = &events[i]
+	}
+	return s.db.ForContext(ctx).CreateEventsWithSequence(batch)
 }
 
 func (s *DBEventStore) GetEvents(ctx context.Context, eventType string, limit int) ([]models.Event, error) {
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"goapitemplate/pkg/models"
+)
+
+// transportKey identifies a webhook and the connection settings it needs
+// beyond the service defaults
+type transportKey struct {
+	webhookID          string
+	proxy              string
+	insecureSkipVerify bool
+	clientCert         string // Hash of the client certificate and key PEM
+}
+
+// transportFor returns the transport for webhook's requests: the service
+// transport, or one through the webhook's proxy (falling back to the
+// service-wide proxy) and with its TLS settings.
+//
+// Proxied requests only dial the proxy, so the URL guard's dial-time checks
+// don't apply to the receiver behind it: webhook URLs are still validated
+// when saved, but a receiver that later resolves to an internal address is
+// left to the proxy's egress policy. The proxy only changes how connections
+// are made: HTTPS deliveries are tunnelled with CONNECT and verify the
+// receiver's certificate unless the webhook opts out.
+func (w *WebhookDeliveryService) transportFor(webhook models.WebhookEndpoint) http.RoundTripper {
+	key := transportKey{
+		proxy:              webhook.ProxyURL,
+		insecureSkipVerify: webhook.InsecureSkipVerify,
+	}
+	if key.proxy == "" {
+		key.proxy = w.proxy
+	}
+	if webhook.ClientCertPEM != "" {
+		sum := sha256.Sum256([]byte(webhook.ClientCertPEM + "\x00" + webhook.ClientKeyPEM))
+		key.clientCert = hex.EncodeToString(sum[:])
+	}
+	if key == (transportKey{}) {
+		return w.transport
+	}
+	key.webhookID = webhook.ID
+
+	w.transportsMu.Lock()
+	defer w.transportsMu.Unlock()
+
+	if transport, ok := w.transports[key]; ok {
+		return transport
+	}
+	transport, err := w.newTransport(key.proxy, webhook)
+	if err != nil {
+		// Proxies and certificates are validated when saved; fall back to
+		// the strict defaults rather than guess
+		w.webhookLogger(webhook).WithError(err).Warn("Ignoring invalid webhook transport settings")
+		return w.transport
+	}
+	w.transports[key] = transport
+	return transport
+}
+
+// ForgetTransport drops the transports built for a webhook, so changed proxy
+// or TLS settings take effect and a deleted webhook's connections are closed
+func (w *WebhookDeliveryService) ForgetTransport(webhookID string) {
+	w.transportsMu.Lock()
+	defer w.transportsMu.Unlock()
+
+	for key, transport := range w.transports {
+		if key.webhookID != webhookID {
+			continue
+		}
+		if closer, ok := transport.(interface{ CloseIdleConnections() }); ok {
+			closer.CloseIdleConnections()
+		}
+		delete(w.transports, key)
+	}
+}
+
+// newTransport builds a transport through proxyURL, or through the URL
+// guard's dialer when there is no proxy, with webhook's TLS settings
+func (w *WebhookDeliveryService) newTransport(proxyURL string, webhook models.WebhookEndpoint) (*http.Transport, error) {
+	var transport *http.Transport
+	if proxyURL != "" {
+		proxy, err := parseProxyURL(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+	} else if guarded, ok := w.transport.(*http.Transport); ok {
+		transport = guarded.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	if webhook.InsecureSkipVerify || webhook.ClientCertPEM != "" {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: webhook.InsecureSkipVerify,
+		}
+		if webhook.ClientCertPEM != "" {
+			cert, err := ParseClientCertificate(webhook.ClientCertPEM, webhook.ClientKeyPEM)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// ParseClientCertificate checks that certPEM and keyPEM are a matching
+// PEM-encoded certificate and private key for mutual TLS
+func ParseClientCertificate(certPEM, keyPEM string) (tls.Certificate, error) {
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// parseProxyURL checks that rawURL is an http, https or socks5 proxy URL
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	proxy, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy URL scheme %q", proxy.Scheme)
+	}
+	if proxy.Host == "" {
+		return nil, errors.New("proxy URL has no host")
+	}
+	return proxy, nil
+}
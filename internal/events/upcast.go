@@ -0,0 +1,79 @@
+package events
+
+import (
+	"goapitemplate/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSchemaVersion is the schema version of events that don't set one
+const DefaultSchemaVersion = 1
+
+// Upcaster converts the data of an event stored at fromVersion into the shape
+// of a newer schema version, so readers only have to handle the latest shape
+type Upcaster func(data models.JSON, fromVersion int) models.JSON
+
+type registeredUpcaster struct {
+	version  int
+	upcaster Upcaster
+}
+
+// RegisterUpcaster makes events of eventType stored with a schema version
+// below version read as version, converting their data with upcaster.
+// Registering again for the same event type replaces the upcaster.
+func (m *Manager) RegisterUpcaster(eventType string, version int, upcaster Upcaster) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.upcasters[eventType] = registeredUpcaster{version: version, upcaster: upcaster}
+	m.logger.WithFields(logrus.Fields{
+		"event_type": eventType,
+		"version":    version,
+	}).Info("Upcaster registered")
+}
+
+// UpcastEvents upgrades, in place, events whose schema version is older than
+// the one registered for their type. Stored events are not changed.
+func (m *Manager) UpcastEvents(events []models.Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.upcasters) == 0 {
+		return
+	}
+
+	for i := range events {
+		m.upcastLocked(&events[i])
+	}
+}
+
+// UpcastEvent is UpcastEvents for a single event, for readers that handle
+// events one at a time
+func (m *Manager) UpcastEvent(event *models.Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	m.upcastLocked(event)
+}
+
+// upcastLocked upgrades event if an upcaster is registered for a newer
+// version of its type. m.mu must be held.
+func (m *Manager) upcastLocked(event *models.Event) {
+	registered, ok := m.upcasters[event.Type]
+	if !ok {
+		return
+	}
+	if fromVersion := schemaVersion(*event); fromVersion < registered.version {
+		event.Data = registered.upcaster(event.Data, fromVersion)
+		event.SchemaVersion = registered.version
+	}
+}
+
+// schemaVersion returns the event's schema version, treating unset as the
+// default
+func schemaVersion(event models.Event) int {
+	if event.SchemaVersion <= 0 {
+		return DefaultSchemaVersion
+	}
+	return event.SchemaVersion
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrDisallowedAddress is returned when a webhook URL points at a private,
+// loopback or link-local address
+var ErrDisallowedAddress = errors.New("address is private, loopback or link-local")
+
+// hostResolver looks up the addresses of a host; *net.Resolver implements it
+type hostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// URLGuard keeps webhook deliveries from reaching internal addresses, so the
+// server can't be used as an SSRF proxy. URLs are checked when webhooks are
+// saved, and every connection is checked again when it is dialed so a host
+// that later resolves to an internal address (DNS rebinding) is still refused.
+type URLGuard struct {
+	allowPrivate bool
+	resolver     hostResolver
+}
+
+// NewURLGuard creates a guard; allowPrivate disables the checks, for
+// development and tests against local receivers
+func NewURLGuard(allowPrivate bool) *URLGuard {
+	return &URLGuard{
+		allowPrivate: allowPrivate,
+		resolver:     net.DefaultResolver,
+	}
+}
+
+// AllowsPrivate reports whether the guard lets private addresses through
+func (g *URLGuard) AllowsPrivate() bool {
+	return g.allowPrivate
+}
+
+// ValidateURL checks that rawURL is an http(s) URL whose host resolves only to
+// public addresses
+func (g *URLGuard) ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("URL has no host")
+	}
+
+	return g.validateHost(ctx, host)
+}
+
+// ValidateProxyURL checks that rawURL is an http, https or socks5 proxy URL
+// whose host resolves only to public addresses
+func (g *URLGuard) ValidateProxyURL(ctx context.Context, rawURL string) error {
+	proxy, err := parseProxyURL(rawURL)
+	if err != nil {
+		return err
+	}
+	return g.validateHost(ctx, proxy.Hostname())
+}
+
+// validateHost checks that host is, or resolves only to, public addresses
+func (g *URLGuard) validateHost(ctx context.Context, host string) error {
+	if g.allowPrivate {
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+
+	addrs, err := g.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve host %s: %w", host, err)
+	}
+	for _, ipAddr := range addrs {
+		addr, ok := netip.AddrFromSlice(ipAddr.IP)
+		if !ok {
+			return fmt.Errorf("host %s resolved to an invalid address", host)
+		}
+		if err := checkAddr(addr); err != nil {
+			return fmt.Errorf("host %s: %w", host, err)
+		}
+	}
+
+	return nil
+}
+
+// Transport returns an HTTP transport that refuses connections to internal
+// addresses, or nil (the default transport) when private addresses are allowed
+func (g *URLGuard) Transport() http.RoundTripper {
+	if g.allowPrivate {
+		return nil
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   g.control,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// control runs after an address is resolved and before it is connected to
+func (g *URLGuard) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q: %w", address, err)
+	}
+	return checkAddr(addrPort.Addr())
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%s: %w", addr, ErrDisallowedAddress)
+	}
+	return nil
+}
@@ -2,41 +2,481 @@ package events
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"goapitemplate/internal/database"
 	"goapitemplate/pkg/models"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "goapitemplate/internal/events"
+
+// traceContext injects W3C traceparent/tracestate headers into deliveries
+// regardless of the globally configured propagator
+var traceContext = propagation.TraceContext{}
+
+// defaultMaxResponseBytes is how much of a receiver's response is stored
+// unless configured otherwise
+const defaultMaxResponseBytes = 1000
+
+// defaultMaxPayloadBytes is the largest request body delivered unless
+// configured otherwise
+const defaultMaxPayloadBytes = 1 << 20
+
+// ErrPayloadTooLarge is returned for deliveries whose serialized payload is
+// over the configured limit; they fail without being sent or retried
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+// ErrPayloadRender is returned for deliveries whose payload can't be built,
+// e.g. because the webhook's payload template fails on the event; the same
+// event would fail again, so they are not retried either
+var ErrPayloadRender = errors.New("failed to build payload")
+
+// DefaultUserAgent identifies deliveries unless configured otherwise
+const DefaultUserAgent = "GoAPITemplate-Webhook/1.0"
+
+// defaultMaxConcurrentDeliveries is the size of the delivery worker pool
+// unless configured otherwise
+const defaultMaxConcurrentDeliveries = 50
+
 type WebhookDeliveryService struct {
 	db     *database.DB
 	client *http.Client
 	logger *logrus.Logger
+	tokens *oauthTokenSource
+
+	// maxResponseBytes limits how much of a receiver's response is stored
+	maxResponseBytes int
+
+	// userAgent is sent with every delivery
+	userAgent string
+
+	// maxPayloadBytes limits the size of a delivery's request body
+	maxPayloadBytes int
+
+	// transport is used for all outgoing requests; nil means the default
+	// transport. SetURLGuard replaces it with one that refuses internal addresses.
+	transport http.RoundTripper
+
+	// proxy is the HTTP proxy deliveries go through unless a webhook sets its
+	// own; empty connects directly. transports caches the transports built for
+	// webhooks with their own proxy or TLS settings, per webhook until
+	// ForgetTransport drops them.
+	proxy        string
+	transports   map[transportKey]http.RoundTripper
+	transportsMu sync.Mutex
+
+	// Deliveries are queued on jobs and run by maxConcurrent workers, started
+	// on first use and stopped by Shutdown once the queue has drained
+	maxConcurrent int
+	jobs          chan deliveryJob
+	startWorkers  sync.Once
+	stopWorkers   sync.Once
+
+	// ordered holds the deliveries of ordered webhooks per webhook and stream.
+	// The head of each queue is queued or running; the rest wait their turn.
+	ordered   map[orderKey][]deliveryJob
+	orderedMu sync.Mutex
+
+	// limiter defers deliveries to webhooks over their MaxDeliveriesPerMinute
+	limiter *deliveryLimiter
+
+	// retryBudget holds back retries to webhooks over their MaxRetriesPerDay
+	retryBudget *retryBudget
+
+	// relay hands outbox events on before their webhooks are delivered; nil
+	// when the event store doesn't need it
+	relay OutboxRelay
+
+	// ctx is cancelled on Shutdown so deliveries stop scheduling new attempts
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	wg     sync.WaitGroup
 }
 
-func NewWebhookDeliveryService(db *database.DB) *WebhookDeliveryService {
+func NewWebhookDeliveryService(db *database.DB, logger *logrus.Logger) *WebhookDeliveryService {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &http.Client{
+		Timeout: time.Second * 30,
+	}
 	return &WebhookDeliveryService{
-		db: db,
-		client: &http.Client{
-			Timeout: time.Second * 30,
-		},
-		logger: logrus.New(),
+		db:               db,
+		client:           client,
+		logger:           logger,
+		tokens:           newOAuthTokenSource(client),
+		maxResponseBytes: defaultMaxResponseBytes,
+		userAgent:        DefaultUserAgent,
+		maxPayloadBytes:  defaultMaxPayloadBytes,
+		transports:       make(map[transportKey]http.RoundTripper),
+		maxConcurrent:    defaultMaxConcurrentDeliveries,
+		ordered:          make(map[orderKey][]deliveryJob),
+		limiter:          newDeliveryLimiter(),
+		retryBudget:      newRetryBudget(),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// SetMaxResponseBytes sets how much of each receiver response is stored on
+// its delivery record; longer responses are truncated. Call it before
+// deliveries start.
+func (w *WebhookDeliveryService) SetMaxResponseBytes(n int) {
+	if n <= 0 {
+		n = defaultMaxResponseBytes
+	}
+	w.maxResponseBytes = n
+}
+
+// SetMaxPayloadBytes sets the largest request body a delivery may send;
+// deliveries of bigger events fail with ErrPayloadTooLarge. Call it before
+// deliveries start.
+func (w *WebhookDeliveryService) SetMaxPayloadBytes(n int) {
+	if n <= 0 {
+		n = defaultMaxPayloadBytes
+	}
+	w.maxPayloadBytes = n
+}
+
+// SetUserAgent sets the User-Agent header sent with deliveries; empty restores
+// the default. Call it before deliveries start.
+func (w *WebhookDeliveryService) SetUserAgent(userAgent string) {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	w.userAgent = userAgent
+}
+
+// SetMaxConcurrentDeliveries sets how many deliveries run at once; further
+// deliveries wait in a queue of the same size. Call it before deliveries start.
+func (w *WebhookDeliveryService) SetMaxConcurrentDeliveries(n int) {
+	if n <= 0 {
+		n = defaultMaxConcurrentDeliveries
+	}
+	w.maxConcurrent = n
+}
+
+// SetOutboxRelay makes DispatchOutbox relay each event through relay, unless
+// its outbox entry is marked relayed, before delivering it. Call it before
+// deliveries start.
+func (w *WebhookDeliveryService) SetOutboxRelay(relay OutboxRelay) {
+	w.relay = relay
+}
+
+// SetURLGuard makes deliveries and OAuth token requests check each
+// connection against guard, refusing internal addresses unless the guard
+// allows them. Call it before deliveries start.
+func (w *WebhookDeliveryService) SetURLGuard(guard *URLGuard) {
+	w.transport = guard.Transport()
+	w.client.Transport = w.transportFor(models.WebhookEndpoint{})
+}
+
+// SetProxy sends deliveries and OAuth token requests through the HTTP proxy
+// at proxyURL, unless a webhook sets its own; empty connects directly. Call
+// it before deliveries start.
+func (w *WebhookDeliveryService) SetProxy(proxyURL string) error {
+	if proxyURL != "" {
+		if _, err := parseProxyURL(proxyURL); err != nil {
+			return err
+		}
+	}
+	w.proxy = proxyURL
+	w.client.Transport = w.transportFor(models.WebhookEndpoint{})
+	return nil
+}
+
+// RetryBudget reports how much of webhook's MaxRetriesPerDay is left
+func (w *WebhookDeliveryService) RetryBudget(webhook models.WebhookEndpoint) models.RetryBudget {
+	return w.retryBudget.status(webhook.ID, webhook.MaxRetriesPerDay, time.Now())
+}
+
+// Shutdown stops retries from being scheduled and waits for in-flight
+// deliveries to finish, or until ctx is done
+func (w *WebhookDeliveryService) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	w.cancel()
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// Every queued delivery has run and no more can be queued
+		w.stopWorkers.Do(func() {
+			if w.jobs != nil {
+				close(w.jobs)
+			}
+		})
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startDelivery registers an in-flight delivery with the service. It returns
+// false once Shutdown has been called.
+func (w *WebhookDeliveryService) startDelivery() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.ctx.Err() != nil {
+		return false
+	}
+	w.wg.Add(1)
+	return true
+}
+
+// deliveryJob is a delivery waiting for a worker
+type deliveryJob struct {
+	ctx      context.Context
+	webhook  models.WebhookEndpoint
+	event    models.Event
+	delivery models.WebhookDelivery
+
+	// reloaded marks a delivery read back from the database by a retry,
+	// possibly while a worker was still attempting it
+	reloaded bool
+}
+
+// enqueue hands a delivery to the worker pool. When the queue is full it
+// blocks until a worker frees up, so a burst of events slows publishers down
+// rather than piling up goroutines and connections. It returns false without
+// queuing once Shutdown has been called, or if ctx is done before a worker
+// frees up; the caller then leaves the delivery to the retry scheduler.
+func (w *WebhookDeliveryService) enqueue(ctx context.Context, job deliveryJob) bool {
+	if !w.startDelivery() {
+		return false
+	}
+
+	w.startWorkers.Do(func() {
+		w.jobs = make(chan deliveryJob, w.maxConcurrent)
+		for i := 0; i < w.maxConcurrent; i++ {
+			go w.worker()
+		}
+	})
+
+	if job.webhook.OrderedDelivery {
+		head, queued := w.pushOrdered(job)
+		if !queued {
+			// Already queued, e.g. a parked delivery the retry scheduler
+			// found due
+			w.wg.Done()
+			return true
+		}
+		if !head {
+			// An earlier delivery for this stream is pending; it hands
+			// over to this one when it finishes
+			return true
+		}
+	}
+
+	select {
+	case w.jobs <- job:
+		return true
+	case <-ctx.Done():
+	}
+
+	if job.webhook.OrderedDelivery {
+		// Later deliveries for the stream may be queued behind it, so it
+		// stays at the head and goes to the workers once one frees up
+		w.parkOrdered(job, 0)
+		return true
 	}
+	w.wg.Done()
+	return false
+}
+
+func (w *WebhookDeliveryService) worker() {
+	for job := range w.jobs {
+		for {
+			var wait time.Duration
+			switch {
+			case job.webhook.OrderedDelivery && w.ctx.Err() != nil:
+				// An earlier delivery may have been cut short by Shutdown;
+				// leave this one pending rather than let it overtake
+				w.scheduleRetry(&job.delivery)
+			case job.reloaded && !w.unchanged(job.delivery):
+				// Attempted since it was read back, e.g. by a worker that
+				// was backing off before retrying it; don't send it twice
+			default:
+				job.reloaded = false
+				wait = w.attemptDelivery(job.ctx, job.webhook, job.event, &job.delivery)
+			}
+			if wait > 0 && job.webhook.OrderedDelivery {
+				// Deferred by the rate limit or retry budget: keep it at
+				// the head of its queue so later events keep waiting
+				w.parkOrdered(job, wait)
+				break
+			}
+
+			// Ordered deliveries run back to back on the same worker; handing
+			// the next one back to the queue could block every worker
+			next, ok := w.nextOrdered(job)
+			w.wg.Done()
+			if !ok {
+				break
+			}
+			job = next
+		}
+	}
+}
+
+// unchanged reports whether delivery's stored record is still as it was read,
+// i.e. no attempt has been made since
+func (w *WebhookDeliveryService) unchanged(delivery models.WebhookDelivery) bool {
+	var stored models.WebhookDelivery
+	if err := w.db.Select("status", "attempt_count", "updated_at").First(&stored, "id = ?", delivery.ID).Error; err != nil {
+		// Gone, e.g. removed by retention
+		return false
+	}
+	return stored.Status == delivery.Status && stored.AttemptCount == delivery.AttemptCount && stored.UpdatedAt.Equal(delivery.UpdatedAt)
+}
+
+// orderKey identifies the ordered delivery queue for a webhook and stream
+type orderKey struct {
+	webhookID string
+	streamID  string
+}
+
+// pushOrdered appends job to its ordered queue and reports whether it is at
+// the head, meaning it should run now. A delivery already in the queue is
+// not added again, and queued is false.
+func (w *WebhookDeliveryService) pushOrdered(job deliveryJob) (head, queued bool) {
+	key := orderKey{webhookID: job.webhook.ID, streamID: job.event.StreamID}
+
+	w.orderedMu.Lock()
+	defer w.orderedMu.Unlock()
+
+	for _, waiting := range w.ordered[key] {
+		if waiting.delivery.ID == job.delivery.ID {
+			return false, false
+		}
+	}
+	w.ordered[key] = append(w.ordered[key], job)
+	return len(w.ordered[key]) == 1, true
+}
+
+// parkOrdered holds a deferred ordered delivery at the head of its queue
+// without tying up a worker, and hands it back to the workers once wait has
+// passed or Shutdown is called
+func (w *WebhookDeliveryService) parkOrdered(job deliveryJob, wait time.Duration) {
+	go func() {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-w.ctx.Done():
+		}
+		w.jobs <- job
+	}()
+}
+
+// nextOrdered removes a finished ordered job from its queue and returns the
+// one waiting behind it, if any
+func (w *WebhookDeliveryService) nextOrdered(done deliveryJob) (deliveryJob, bool) {
+	if !done.webhook.OrderedDelivery {
+		return deliveryJob{}, false
+	}
+	key := orderKey{webhookID: done.webhook.ID, streamID: done.event.StreamID}
+
+	w.orderedMu.Lock()
+	defer w.orderedMu.Unlock()
+
+	queue := w.ordered[key][1:]
+	if len(queue) == 0 {
+		delete(w.ordered, key)
+		return deliveryJob{}, false
+	}
+	w.ordered[key] = queue
+	return queue[0], true
+}
+
+// matchesEventType reports whether a webhook's event type pattern covers
+// eventType. Besides exact types, "*" matches every type and a trailing
+// wildcard such as "order.*" matches every type under that prefix.
+func matchesEventType(pattern, eventType string) bool {
+	if pattern == AllEventTypes {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return strings.HasPrefix(eventType, prefix+".")
+	}
+	return pattern == eventType
+}
+
+// subscribesTo reports whether any of patterns matches any of an event's
+// type names
+func subscribesTo(patterns, typeNames []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range typeNames {
+			if matchesEventType(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // DeliverEvent finds all applicable webhooks and delivers the event to them
 func (w *WebhookDeliveryService) DeliverEvent(ctx context.Context, event models.Event) error {
+	_, recorded, err := w.deliverEvent(ctx, event, false)
+	if err != nil {
+		return err
+	}
+
+	// Every delivery is recorded, so the event can leave the outbox; if some
+	// weren't, the outbox dispatcher tries the event again later
+	if recorded {
+		if err := w.db.WithContext(ctx).Delete(&models.OutboxEntry{}, "event_id = ?", event.ID).Error; err != nil {
+			w.logger.WithError(err).WithField("event_id", event.ID).Error("Failed to remove event from outbox")
+		}
+	}
+
+	return nil
+}
+
+// RedeliverEvent delivers a stored event again to every webhook currently
+// subscribed to its type, e.g. after a consumer lost it. Each gets a fresh
+// delivery record marked as a redelivery. It returns how many were created.
+func (w *WebhookDeliveryService) RedeliverEvent(ctx context.Context, event models.Event) (int, error) {
+	created, _, err := w.deliverEvent(ctx, event, true)
+	return created, err
+}
+
+// deliverEvent creates a delivery record for each webhook subscribed to the
+// event and queues the deliveries. It reports how many records were created
+// and whether all of them were.
+func (w *WebhookDeliveryService) deliverEvent(ctx context.Context, event models.Event, redelivery bool) (int, bool, error) {
 	// Find all active webhooks - we'll filter by event type in Go for SQLite compatibility
 	var allWebhooks []models.WebhookEndpoint
 	err := w.db.WithContext(ctx).
@@ -44,22 +484,28 @@ func (w *WebhookDeliveryService) DeliverEvent(ctx context.Context, event models.
 		Find(&allWebhooks).Error
 	if err != nil {
 		w.logger.WithError(err).Error("Failed to find webhooks")
-		return err
+		return 0, false, err
 	}
 
+	aliases, err := w.db.GetEventTypeAliases(ctx)
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to load event type aliases")
+		return 0, false, err
+	}
+	typeNames := eventTypeNames(event.Type, aliases)
+
 	// Filter webhooks that should receive this event type
 	var webhooks []models.WebhookEndpoint
 	for _, webhook := range allWebhooks {
-		for _, eventType := range webhook.EventTypes {
-			if eventType == event.Type {
-				webhooks = append(webhooks, webhook)
-				break
-			}
+		if subscribesTo(webhook.EventTypes, typeNames) {
+			webhooks = append(webhooks, webhook)
 		}
 	}
 
 	// Create delivery records and attempt delivery for each webhook
+	created := 0
 	for _, webhook := range webhooks {
+		paused := subscribesTo(webhook.PausedEventTypes, typeNames)
 		delivery := models.WebhookDelivery{
 			ID:           generateDeliveryID(),
 			WebhookID:    webhook.ID,
@@ -68,6 +514,10 @@ func (w *WebhookDeliveryService) DeliverEvent(ctx context.Context, event models.
 			AttemptCount: 0,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
+			Redelivery:   redelivery,
+		}
+		if paused {
+			delivery.Status = "paused"
 		}
 
 		// Save initial delivery record
@@ -78,16 +528,82 @@ func (w *WebhookDeliveryService) DeliverEvent(ctx context.Context, event models.
 			}).Error("Failed to create delivery record")
 			continue
 		}
+		created++
 
-		// Attempt delivery asynchronously
-		go w.attemptDelivery(context.Background(), webhook, event, &delivery)
+		if paused {
+			w.logger.WithFields(logrus.Fields{
+				"webhook_id": webhook.ID,
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			}).Debug("Event type paused for webhook, delivery skipped")
+			continue
+		}
+
+		// The delivery outlives the publish, so detach from its cancellation
+		// but keep its trace so delivery spans join the publisher's trace
+		deliveryCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+		// Attempt delivery asynchronously; if shutting down, or the publisher
+		// stops waiting for a free worker, leave the record pending for the
+		// retry scheduler to pick up
+		if !w.enqueue(ctx, deliveryJob{ctx: deliveryCtx, webhook: webhook, event: event, delivery: delivery}) {
+			w.scheduleRetry(&delivery)
+		}
 	}
 
-	return nil
+	return created, created == len(webhooks), nil
 }
 
-// attemptDelivery attempts to deliver an event to a webhook endpoint
-func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook models.WebhookEndpoint, event models.Event, delivery *models.WebhookDelivery) {
+// DispatchOutbox delivers events still in the outbox whose lease has run out,
+// i.e. saved but never fully handed to DeliverEvent, for example because the
+// process stopped in between. Each is claimed first, so an event still being
+// dispatched by its publisher or another instance is left alone. Webhooks
+// that were already delivered to before a failure may receive the event
+// again. With an outbox relay, an event that hasn't been relayed is relayed
+// first and left in the outbox, to be tried again once its lease runs out,
+// if that fails. It returns how many events were dispatched.
+func (w *WebhookDeliveryService) DispatchOutbox(ctx context.Context) (int, error) {
+	entries, err := w.db.ClaimOutboxEntries(ctx, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, entry := range entries {
+		var events []models.Event
+		if err := w.db.WithContext(ctx).Where("id = ?", entry.EventID).Limit(1).Find(&events).Error; err != nil {
+			return dispatched, err
+		}
+
+		if len(events) == 0 {
+			// The event was deleted before it was delivered
+			if err := w.db.WithContext(ctx).Delete(&entry).Error; err != nil {
+				return dispatched, err
+			}
+			continue
+		}
+
+		if w.relay != nil && !entry.Relayed {
+			if err := w.relay.RelayEvent(ctx, events[0]); err != nil {
+				w.logger.WithError(err).WithField("event_id", entry.EventID).Error("Failed to relay outbox event")
+				continue
+			}
+		}
+
+		if err := w.DeliverEvent(ctx, events[0]); err != nil {
+			return dispatched, err
+		}
+		dispatched++
+	}
+
+	return dispatched, nil
+}
+
+// attemptDelivery attempts to deliver an event to a webhook endpoint. When
+// the webhook's rate limit or retry budget defers the delivery, it is left
+// pending and attemptDelivery returns how long until it may go ahead;
+// otherwise it returns zero.
+func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook models.WebhookEndpoint, event models.Event, delivery *models.WebhookDelivery) time.Duration {
 	maxRetries := webhook.MaxRetries
 	if maxRetries <= 0 {
 		maxRetries = 3
@@ -99,25 +615,100 @@ func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook mo
 	}
 
 	// Create a client with the webhook-specific timeout
-	client := &http.Client{Timeout: timeout}
+	client := &http.Client{Timeout: timeout, Transport: w.transportFor(webhook)}
+	logger := w.webhookLogger(webhook)
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
+		// A delivery that has been attempted before is a retry; once the
+		// webhook's retry budget is used up it stays pending until the
+		// budget resets
+		if delivery.AttemptCount > 0 {
+			if resetsAt, ok := w.retryBudget.take(webhook.ID, webhook.MaxRetriesPerDay, time.Now()); !ok {
+				delivery.Status = "pending"
+				delivery.NextRetry = &resetsAt
+				delivery.UpdatedAt = time.Now()
+				if err := w.db.WithContext(ctx).Save(delivery).Error; err != nil {
+					logger.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to defer delivery over retry budget")
+				}
+				logger.WithFields(logrus.Fields{
+					"delivery_id": delivery.ID,
+					"webhook_id":  webhook.ID,
+					"event_id":    event.ID,
+					"next_retry":  resetsAt,
+				}).Warn("Webhook retry budget exhausted, retry deferred")
+				return time.Until(resetsAt)
+			}
+		}
+
+		// Over the webhook's rate limit, leave the delivery pending until a
+		// slot frees up; the retry scheduler sends it then
+		if wait := w.limiter.reserve(webhook.ID, webhook.MaxDeliveriesPerMinute, time.Now()); wait > 0 {
+			nextRetry := time.Now().Add(wait)
+			delivery.Status = "pending"
+			delivery.NextRetry = &nextRetry
+			delivery.UpdatedAt = time.Now()
+			if err := w.db.WithContext(ctx).Save(delivery).Error; err != nil {
+				logger.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to defer rate-limited delivery")
+			}
+			logger.WithFields(logrus.Fields{
+				"delivery_id": delivery.ID,
+				"webhook_id":  webhook.ID,
+				"event_id":    event.ID,
+				"next_retry":  nextRetry,
+			}).Info("Webhook rate limit reached, delivery deferred")
+			return wait
+		}
+
 		delivery.AttemptCount = attempt
 		delivery.LastAttempt = &time.Time{}
 		*delivery.LastAttempt = time.Now()
 		delivery.UpdatedAt = time.Now()
 
-		success, response, err := w.deliverToEndpoint(ctx, client, webhook, event)
-		
+		logger.WithFields(logrus.Fields{
+			"delivery_id": delivery.ID,
+			"webhook_id":  webhook.ID,
+			"event_id":    event.ID,
+			"attempt":     attempt,
+			"url":         webhook.URL,
+		}).Debug("Attempting webhook delivery")
+
+		attemptCtx, span := otel.Tracer(tracerName).Start(ctx, "webhook.deliver",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("webhook.id", webhook.ID),
+				attribute.String("delivery.id", delivery.ID),
+				attribute.String("event.id", event.ID),
+				attribute.String("event.type", event.Type),
+				attribute.Int("webhook.attempt", attempt),
+				attribute.String("url.full", webhook.URL),
+			),
+		)
+		started := time.Now()
+		result, err := w.deliver(attemptCtx, client, webhook, event)
+		success := err == nil
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		w.recordAttempt(ctx, delivery, attempt, started, result, err)
+
+		delivery.Response = result.response
+		delivery.ResponseTruncated = result.truncated
+		retry := !success && attempt < maxRetries && result.retryable() &&
+			!errors.Is(err, ErrPayloadTooLarge) && !errors.Is(err, ErrPayloadRender)
+		delay := w.calculateRetryDelay(attempt)
+		if result.retryAfter > 0 {
+			delay = result.retryAfter
+		}
 		if success {
 			delivery.Status = "success"
-			delivery.Response = response
 			delivery.ErrorMessage = ""
 			delivery.NextRetry = nil
 		} else {
-			if attempt < maxRetries {
+			if retry {
 				delivery.Status = "pending"
-				nextRetry := time.Now().Add(w.calculateRetryDelay(attempt))
+				nextRetry := time.Now().Add(delay)
 				delivery.NextRetry = &nextRetry
 			} else {
 				delivery.Status = "failed"
@@ -127,12 +718,11 @@ func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook mo
 			if err != nil {
 				delivery.ErrorMessage = err.Error()
 			}
-			delivery.Response = response
 		}
 
 		// Update delivery record
 		if updateErr := w.db.WithContext(ctx).Save(delivery).Error; updateErr != nil {
-			w.logger.WithError(updateErr).WithFields(logrus.Fields{
+			logger.WithError(updateErr).WithFields(logrus.Fields{
 				"delivery_id": delivery.ID,
 				"webhook_id":  webhook.ID,
 				"event_id":    event.ID,
@@ -140,7 +730,7 @@ func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook mo
 		}
 
 		if success {
-			w.logger.WithFields(logrus.Fields{
+			logger.WithFields(logrus.Fields{
 				"delivery_id": delivery.ID,
 				"webhook_id":  webhook.ID,
 				"event_id":    event.ID,
@@ -149,24 +739,145 @@ func (w *WebhookDeliveryService) attemptDelivery(ctx context.Context, webhook mo
 			break
 		}
 
-		w.logger.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"delivery_id": delivery.ID,
 			"webhook_id":  webhook.ID,
 			"event_id":    event.ID,
 			"attempt":     attempt,
 			"error":       err,
+			"retry":       retry,
 		}).Warn("Webhook delivery failed")
 
-		// Wait before retry (except on last attempt)
-		if attempt < maxRetries {
-			time.Sleep(w.calculateRetryDelay(attempt))
+		if !retry {
+			break
+		}
+
+		// Wait before retrying. On shutdown the delivery stays pending with
+		// its next_retry set so it is resumed later.
+		select {
+		case <-time.After(delay):
+		case <-w.ctx.Done():
+			return 0
 		}
 	}
+	return 0
+}
+
+// recordAttempt stores the outcome of one delivery attempt; failing to do so
+// is logged but doesn't affect the delivery
+func (w *WebhookDeliveryService) recordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt int, started time.Time, result deliveryResult, err error) {
+	record := models.WebhookDeliveryAttempt{
+		ID:         generateAttemptID(),
+		DeliveryID: delivery.ID,
+		Attempt:    attempt,
+		Success:    err == nil,
+		StatusCode: result.statusCode,
+		Response:   result.response,
+		DurationMs: time.Since(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	if err != nil {
+		record.ErrorMessage = err.Error()
+	}
+	if createErr := w.db.WithContext(ctx).Create(&record).Error; createErr != nil {
+		w.logger.WithError(createErr).WithField("delivery_id", delivery.ID).Error("Failed to record delivery attempt")
+	}
+}
+
+// webhookLogger returns the logger for a webhook's deliveries. Webhooks with a
+// LogLevel get a logger at that level sharing the service's output, formatter
+// and hooks; otherwise the service logger (and its global level) is used.
+func (w *WebhookDeliveryService) webhookLogger(webhook models.WebhookEndpoint) *logrus.Logger {
+	if webhook.LogLevel == "" {
+		return w.logger
+	}
+
+	level, err := logrus.ParseLevel(webhook.LogLevel)
+	if err != nil {
+		return w.logger
+	}
+
+	return &logrus.Logger{
+		Out:          w.logger.Out,
+		Hooks:        w.logger.Hooks,
+		Formatter:    w.logger.Formatter,
+		ReportCaller: w.logger.ReportCaller,
+		Level:        level,
+		ExitFunc:     w.logger.ExitFunc,
+	}
+}
+
+// scheduleRetry marks a delivery as pending and due for immediate retry
+func (w *WebhookDeliveryService) scheduleRetry(delivery *models.WebhookDelivery) {
+	nextRetry := time.Now()
+	delivery.Status = "pending"
+	delivery.NextRetry = &nextRetry
+	delivery.UpdatedAt = time.Now()
+
+	if err := w.db.Save(delivery).Error; err != nil {
+		w.logger.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to schedule delivery retry")
+	}
 }
 
 // deliverToEndpoint performs the actual HTTP request to the webhook endpoint
 func (w *WebhookDeliveryService) deliverToEndpoint(ctx context.Context, client *http.Client, webhook models.WebhookEndpoint, event models.Event) (bool, string, error) {
-	// Prepare webhook payload
+	result, err := w.deliver(ctx, client, webhook, event)
+	return err == nil, result.response, err
+}
+
+// deliveryResult describes the receiver's answer to a delivery request
+type deliveryResult struct {
+	statusCode int
+	response   string
+	truncated  bool
+	signature  string
+
+	// retryAfter is the receiver's requested wait from a Retry-After header
+	retryAfter time.Duration
+}
+
+// maxRetryAfter caps how long a receiver's Retry-After can hold a delivery
+// back between attempts
+const maxRetryAfter = 5 * time.Minute
+
+// retryable reports whether a failed delivery is worth attempting again.
+// Client errors other than 408 Request Timeout and 429 Too Many Requests mean
+// the receiver will reject the same request again, so they are terminal;
+// server errors and failures without a response (network errors, timeouts)
+// are retried.
+func (r deliveryResult) retryable() bool {
+	if r.statusCode < 400 || r.statusCode >= 500 {
+		return true
+	}
+	return r.statusCode == http.StatusRequestTimeout || r.statusCode == http.StatusTooManyRequests
+}
+
+// parseRetryAfter reads a Retry-After header given as delay-seconds or an
+// HTTP date, returning zero when it is absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = date.Sub(now)
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
+
+// webhookPayload builds the default payload envelope delivered for event.
+// PayloadSchema describes it; keep the two in step.
+func webhookPayload(webhook models.WebhookEndpoint, event models.Event) map[string]interface{} {
 	payload := map[string]interface{}{
 		"event_id":        event.ID,
 		"event_type":      event.Type,
@@ -175,62 +886,254 @@ func (w *WebhookDeliveryService) deliverToEndpoint(ctx context.Context, client *
 		"data":            event.Data,
 		"timestamp":       event.Timestamp.Format(time.RFC3339),
 		"sequence_number": event.SequenceNumber,
+		"schema_version":  schemaVersion(event),
+	}
+	if len(event.Metadata) > 0 {
+		payload["metadata"] = event.Metadata
+	}
+	if webhook.IncludeWebhookInfo {
+		payload["webhook"] = map[string]interface{}{
+			"id":   webhook.ID,
+			"name": webhook.Name,
+		}
 	}
+	return payload
+}
+
+// deliver sends the event to the webhook, handling OAuth tokens, and returns
+// what the receiver answered. Non-2xx responses are returned as errors.
+func (w *WebhookDeliveryService) deliver(ctx context.Context, client *http.Client, webhook models.WebhookEndpoint, event models.Event) (deliveryResult, error) {
+	payload := webhookPayload(webhook, event)
 
-	payloadBytes, err := json.Marshal(payload)
+	var payloadBytes []byte
+	var err error
+	switch {
+	case webhook.PayloadTemplate != "":
+		payloadBytes, err = renderPayload(webhook.PayloadTemplate, payload)
+		if err != nil {
+			return deliveryResult{}, fmt.Errorf("%w: %w", ErrPayloadRender, err)
+		}
+	case webhook.ContentType == ContentTypeCloudEvents:
+		payloadBytes, err = json.Marshal(cloudEvent(event))
+		if err != nil {
+			return deliveryResult{}, fmt.Errorf("%w: failed to marshal payload: %w", ErrPayloadRender, err)
+		}
+	default:
+		payloadBytes, err = json.Marshal(payload)
+		if err != nil {
+			return deliveryResult{}, fmt.Errorf("%w: failed to marshal payload: %w", ErrPayloadRender, err)
+		}
+	}
+	if len(payloadBytes) > w.maxPayloadBytes {
+		return deliveryResult{}, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrPayloadTooLarge, len(payloadBytes), w.maxPayloadBytes)
+	}
+
+	// Compressed after the size check so the limit means the same for every
+	// webhook; the signature then covers the bytes actually sent
+	if webhook.CompressPayload {
+		payloadBytes, err = gzipPayload(payloadBytes)
+		if err != nil {
+			return deliveryResult{}, fmt.Errorf("%w: %w", ErrPayloadRender, err)
+		}
+	}
+
+	if !usesOAuth(webhook) {
+		return w.sendWebhookRequest(ctx, client, webhook, event, payloadBytes, "")
+	}
+
+	token, err := w.tokens.Token(ctx, webhook, false)
 	if err != nil {
-		return false, "", fmt.Errorf("failed to marshal payload: %w", err)
+		return deliveryResult{}, fmt.Errorf("failed to obtain OAuth token: %w", err)
+	}
+
+	result, err := w.sendWebhookRequest(ctx, client, webhook, event, payloadBytes, token)
+	if result.statusCode != http.StatusUnauthorized {
+		return result, err
 	}
 
+	// The receiver rejected the token (revoked or expired early), so fetch a
+	// fresh one and try once more
+	token, err = w.tokens.Token(ctx, webhook, true)
+	if err != nil {
+		return deliveryResult{}, fmt.Errorf("failed to refresh OAuth token: %w", err)
+	}
+	return w.sendWebhookRequest(ctx, client, webhook, event, payloadBytes, token)
+}
+
+// sendWebhookRequest posts the payload to the webhook, attaching the bearer
+// token when one is given
+func (w *WebhookDeliveryService) sendWebhookRequest(ctx context.Context, client *http.Client, webhook models.WebhookEndpoint, event models.Event, payloadBytes []byte, token string) (deliveryResult, error) {
+	var result deliveryResult
+
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewBuffer(payloadBytes))
+	method := webhook.Method
+	if method == "" {
+		method = DefaultMethod
+	}
+	req, err := http.NewRequestWithContext(ctx, method, webhook.URL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return false, "", fmt.Errorf("failed to create request: %w", err)
+		return result, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "GoAPITemplate-Webhook/1.0")
+	contentType := webhook.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", w.userAgent)
+	if webhook.CompressPayload {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 	
 	// Add signature header for verification
 	if webhook.Secret != "" {
-		signature := w.generateSignature(payloadBytes, webhook.Secret)
-		req.Header.Set("X-Webhook-Signature", signature)
+		result.signature = w.generateSignature(payloadBytes, webhook.Secret, webhook.SignatureAlgorithm)
+		req.Header.Set("X-Webhook-Signature", result.signature)
+	}
+	if webhook.SecretNext != "" {
+		req.Header.Set("X-Webhook-Signature-Next", w.generateSignature(payloadBytes, webhook.SecretNext, webhook.SignatureAlgorithm))
 	}
 
 	// Add event metadata headers
 	req.Header.Set("X-Event-Type", event.Type)
 	req.Header.Set("X-Event-Stream", event.StreamID)
 	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Schema-Version", strconv.Itoa(schemaVersion(event)))
+	if correlationID, ok := event.Metadata["correlation_id"].(string); ok && correlationID != "" {
+		req.Header.Set("X-Correlation-ID", correlationID)
+	}
+	if requestID, ok := event.Metadata["request_id"].(string); ok && requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// Link the receiver's traces to the delivery span
+	traceContext.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// Perform request
 	resp, err := client.Do(req)
 	if err != nil {
-		return false, "", fmt.Errorf("request failed: %w", err)
+		return result, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read response
-	body, _ := io.ReadAll(resp.Body)
-	responseStr := string(body)
-	if len(responseStr) > 1000 {
-		responseStr = responseStr[:1000] + "..."
+	result.statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		result.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	// Read response, keeping at most maxResponseBytes of it
+	limit := w.maxResponseBytes
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if len(body) > limit {
+		result.truncated = true
+		// Don't split a multi-byte character
+		for limit > 0 && !utf8.RuneStart(body[limit]) {
+			limit--
+		}
+		body = body[:limit]
 	}
+	result.response = string(body)
 
 	// Check if delivery was successful (2xx status codes)
-	success := resp.StatusCode >= 200 && resp.StatusCode < 300
-	if !success {
-		return false, responseStr, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return result, nil
+}
+
+// SendTestDelivery synchronously delivers a synthesized sample event to the
+// webhook so its owner can check their endpoint. Nothing is persisted: neither
+// the sample event nor a delivery record.
+func (w *WebhookDeliveryService) SendTestDelivery(ctx context.Context, webhook models.WebhookEndpoint) models.WebhookTestResult {
+	eventType := "webhook.test"
+	if len(webhook.EventTypes) > 0 && !strings.Contains(webhook.EventTypes[0], "*") {
+		eventType = webhook.EventTypes[0]
 	}
 
-	return true, responseStr, nil
+	event := NewEvent("webhook-test", eventType, "webhook-test", map[string]interface{}{
+		"test":    true,
+		"message": "This is a test delivery",
+	})
+
+	timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "webhook.test",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("webhook.id", webhook.ID),
+			attribute.String("url.full", webhook.URL),
+		),
+	)
+	defer span.End()
+
+	client := &http.Client{Timeout: timeout, Transport: w.transportFor(webhook)}
+	result, err := w.deliver(ctx, client, webhook, event)
+
+	testResult := models.WebhookTestResult{
+		Success:    err == nil,
+		StatusCode: result.statusCode,
+		Response:   result.response,
+		Signature:  result.signature,
+		Event:      event,
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		testResult.Error = err.Error()
+	}
+
+	return testResult
+}
+
+// gzipPayload compresses a request body for webhooks with CompressPayload
+func gzipPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	return buf.Bytes(), nil
 }
 
-// generateSignature creates HMAC-SHA256 signature for webhook verification
-func (w *WebhookDeliveryService) generateSignature(payload []byte, secret string) string {
-	h := hmac.New(sha256.New, []byte(secret))
+// DefaultMethod is the HTTP method of deliveries for webhooks that don't
+// choose one
+const DefaultMethod = http.MethodPost
+
+// DefaultSignatureAlgorithm signs deliveries of webhooks that don't choose
+// an algorithm
+const DefaultSignatureAlgorithm = "sha256"
+
+// generateSignature creates an HMAC signature for webhook verification,
+// prefixed with the algorithm name (sha1=, sha256= or sha512=). Unknown or
+// empty algorithms use SHA-256.
+func (w *WebhookDeliveryService) generateSignature(payload []byte, secret, algorithm string) string {
+	var newHash func() hash.Hash
+	switch algorithm {
+	case "sha1":
+		newHash = sha1.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		algorithm = DefaultSignatureAlgorithm
+		newHash = sha256.New
+	}
+
+	h := hmac.New(newHash, []byte(secret))
 	h.Write(payload)
-	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+	return algorithm + "=" + hex.EncodeToString(h.Sum(nil))
 }
 
 // calculateRetryDelay calculates exponential backoff delay
@@ -243,6 +1146,17 @@ func (w *WebhookDeliveryService) calculateRetryDelay(attempt int) time.Duration
 	return delay
 }
 
+// sortBySequence orders deliveries by their event's sequence number, so those
+// queued for an ordered webhook keep each stream's order
+func sortBySequence(deliveries []models.WebhookDelivery) {
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if deliveries[i].Event == nil || deliveries[j].Event == nil {
+			return false
+		}
+		return deliveries[i].Event.SequenceNumber < deliveries[j].Event.SequenceNumber
+	})
+}
+
 // RetryFailedDeliveries finds and retries failed deliveries that are ready for retry
 func (w *WebhookDeliveryService) RetryFailedDeliveries(ctx context.Context) error {
 	var deliveries []models.WebhookDelivery
@@ -258,17 +1172,140 @@ func (w *WebhookDeliveryService) RetryFailedDeliveries(ctx context.Context) erro
 		return err
 	}
 
+	// Queued retries outlive the scheduler run that found them
+	retryCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+	sortBySequence(deliveries)
 	for _, delivery := range deliveries {
-		if delivery.Webhook != nil && delivery.Event != nil {
-			w.attemptDelivery(ctx, *delivery.Webhook, *delivery.Event, &delivery)
+		if delivery.Webhook == nil || delivery.Event == nil {
+			continue
 		}
+
+		// Ordered webhooks retry through their queue so the retry can't
+		// overtake, or run alongside, deliveries for the same stream
+		if delivery.Webhook.OrderedDelivery {
+			webhook, event := *delivery.Webhook, *delivery.Event
+			delivery.Webhook, delivery.Event = nil, nil
+			if !w.enqueue(ctx, deliveryJob{ctx: retryCtx, webhook: webhook, event: event, delivery: delivery, reloaded: true}) {
+				break
+			}
+			continue
+		}
+
+		if !w.startDelivery() {
+			break
+		}
+		w.attemptDelivery(ctx, *delivery.Webhook, *delivery.Event, &delivery)
+		w.wg.Done()
 	}
 
 	return nil
 }
 
+// RetryFailedDeliveriesForWebhook re-attempts a webhook's failed deliveries,
+// and pending ones whose retry is due, e.g. once its endpoint has been fixed.
+// Deliveries run in the background; it returns how many were queued.
+func (w *WebhookDeliveryService) RetryFailedDeliveriesForWebhook(ctx context.Context, webhookID string) (int, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := w.db.WithContext(ctx).
+		Preload("Webhook").
+		Preload("Event").
+		Where("webhook_id = ?", webhookID).
+		Where("status = ? OR (status = ? AND next_retry <= ?)", "failed", "pending", time.Now()).
+		Find(&deliveries).Error
+	if err != nil {
+		return 0, err
+	}
+
+	// Retries outlive the request that queued them
+	retryCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+	sortBySequence(deliveries)
+	queued := 0
+	for _, delivery := range deliveries {
+		if delivery.Webhook == nil || delivery.Event == nil {
+			continue
+		}
+		webhook, event := *delivery.Webhook, *delivery.Event
+		delivery.Webhook, delivery.Event = nil, nil
+		if !w.enqueue(ctx, deliveryJob{ctx: retryCtx, webhook: webhook, event: event, delivery: delivery, reloaded: true}) {
+			break
+		}
+		queued++
+	}
+
+	return queued, nil
+}
+
+// ReconcileStuckDeliveries recovers pending deliveries left behind by a crash
+// mid-attempt: those last updated before staleBefore whose next_retry is
+// unset or past. Deliveries that have used up their webhook's retries, or
+// whose webhook or event is gone, are marked failed; the rest are queued
+// again in the background.
+func (w *WebhookDeliveryService) ReconcileStuckDeliveries(ctx context.Context, staleBefore time.Time) (requeued, failed int, err error) {
+	var deliveries []models.WebhookDelivery
+
+	err = w.db.WithContext(ctx).
+		Preload("Webhook").
+		Preload("Event").
+		Where("status = ? AND updated_at < ?", "pending", staleBefore).
+		Where("next_retry IS NULL OR next_retry <= ?", time.Now()).
+		Find(&deliveries).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Requeued deliveries outlive the request that reconciled them
+	retryCtx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+
+	sortBySequence(deliveries)
+	for _, delivery := range deliveries {
+		webhook, event := delivery.Webhook, delivery.Event
+		delivery.Webhook, delivery.Event = nil, nil
+
+		maxRetries := 3
+		if webhook != nil && webhook.MaxRetries > 0 {
+			maxRetries = webhook.MaxRetries
+		}
+
+		var reason string
+		switch {
+		case webhook == nil || event == nil:
+			reason = "webhook or event no longer exists"
+		case delivery.AttemptCount >= maxRetries:
+			reason = fmt.Sprintf("stuck pending after %d attempts", delivery.AttemptCount)
+		}
+
+		if reason != "" {
+			delivery.Status = "failed"
+			delivery.NextRetry = nil
+			delivery.ErrorMessage = reason
+			delivery.UpdatedAt = time.Now()
+			if err := w.db.WithContext(ctx).Save(&delivery).Error; err != nil {
+				return requeued, failed, err
+			}
+			failed++
+			continue
+		}
+
+		if !w.enqueue(ctx, deliveryJob{ctx: retryCtx, webhook: *webhook, event: *event, delivery: delivery, reloaded: true}) {
+			break
+		}
+		requeued++
+	}
+
+	return requeued, failed, nil
+}
+
 func generateDeliveryID() string {
 	bytes := make([]byte, 16)
 	rand.Read(bytes)
 	return fmt.Sprintf("del_%x", bytes)
+}
+
+func generateAttemptID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return fmt.Sprintf("att_%x", bytes)
 }
\ No newline at end of file
@@ -1,19 +1,33 @@
 package events
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"goapitemplate/internal/database"
 	"goapitemplate/pkg/models"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -25,6 +39,12 @@ func setupTestDB(t *testing.T) *database.DB {
 	})
 	require.NoError(t, err)
 
+	// Every connection to :memory: opens a separate, empty database, so keep
+	// the background delivery goroutines on the one that was migrated
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
 	db := &database.DB{DB: gormDB}
 	
 	// Run migrations
@@ -76,7 +96,7 @@ func TestWebhookDeliveryService_DeliverEvent(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	tests := []struct {
 		name         string
@@ -99,6 +119,27 @@ func TestWebhookDeliveryService_DeliverEvent(t *testing.T) {
 			serverStatus: http.StatusOK,
 			wantDelivery: false,
 		},
+		{
+			name:         "trailing wildcard matches types under its prefix",
+			eventType:    "user.created",
+			webhookTypes: []string{"user.*"},
+			serverStatus: http.StatusOK,
+			wantDelivery: true,
+		},
+		{
+			name:         "trailing wildcard skips other prefixes",
+			eventType:    "order.created",
+			webhookTypes: []string{"user.*"},
+			serverStatus: http.StatusOK,
+			wantDelivery: false,
+		},
+		{
+			name:         "full wildcard matches every type",
+			eventType:    "order.created",
+			webhookTypes: []string{"*"},
+			serverStatus: http.StatusOK,
+			wantDelivery: true,
+		},
 		{
 			name:         "delivery recorded for failed request",
 			eventType:    "user.created",
@@ -184,7 +225,7 @@ func TestWebhookDeliveryService_RetryFailedDeliveries(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	// Create test server that succeeds on retry
 	retryCount := 0
@@ -249,35 +290,478 @@ func TestWebhookDeliveryService_RetryFailedDeliveries(t *testing.T) {
 	assert.GreaterOrEqual(t, updatedDelivery.AttemptCount, 2)
 }
 
+func TestWebhookDeliveryService_RetryFailedDeliveriesForWebhook(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for _, id := range []string{"webhook-a", "webhook-b"} {
+		webhook := models.WebhookEndpoint{
+			ID:             id,
+			Name:           id,
+			URL:            server.URL + "/" + id,
+			Secret:         "secret",
+			EventTypes:     []string{"test.event"},
+			Enabled:        true,
+			MaxRetries:     1,
+			TimeoutSeconds: 5,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+	event := createTestEvent(t, db, "test.event")
+
+	future := time.Now().Add(time.Hour)
+	deliveries := []models.WebhookDelivery{
+		{ID: "a-failed-1", WebhookID: "webhook-a", EventID: event.ID, Status: "failed"},
+		{ID: "a-failed-2", WebhookID: "webhook-a", EventID: event.ID, Status: "failed"},
+		{ID: "a-not-due", WebhookID: "webhook-a", EventID: event.ID, Status: "pending", NextRetry: &future},
+		{ID: "a-success", WebhookID: "webhook-a", EventID: event.ID, Status: "success"},
+		{ID: "b-failed", WebhookID: "webhook-b", EventID: event.ID, Status: "failed"},
+	}
+	for _, delivery := range deliveries {
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	queued, err := service.RetryFailedDeliveriesForWebhook(context.Background(), "webhook-a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, queued)
+
+	require.NoError(t, service.Shutdown(context.Background()))
+
+	assert.Equal(t, 2, hits["/webhook-a"])
+	assert.Equal(t, 0, hits["/webhook-b"])
+
+	statuses := make(map[string]string)
+	var stored []models.WebhookDelivery
+	require.NoError(t, db.Find(&stored).Error)
+	for _, delivery := range stored {
+		statuses[delivery.ID] = delivery.Status
+	}
+	assert.Equal(t, map[string]string{
+		"a-failed-1": "success",
+		"a-failed-2": "success",
+		"a-not-due":  "pending",
+		"a-success":  "success",
+		"b-failed":   "failed",
+	}, statuses)
+}
+
+func TestWebhookDeliveryService_DispatchOutbox(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Event-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	require.NoError(t, db.Model(&webhook).Update("url", server.URL).Error)
+
+	// Saved, then the process stopped before dispatching it
+	event := createTestEvent(t, db, "test.event")
+	require.NoError(t, db.Create(&models.OutboxEntry{EventID: "deleted-event", CreatedAt: time.Now()}).Error)
+
+	var outboxed int64
+	require.NoError(t, db.Model(&models.OutboxEntry{}).Count(&outboxed).Error)
+	assert.Equal(t, int64(2), outboxed)
+
+	// The publisher's lease keeps the event from being dispatched twice
+	// while it may still be dispatching it
+	dispatched, err := service.DispatchOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched)
+
+	require.NoError(t, db.Model(&models.OutboxEntry{}).Where("event_id = ?", event.ID).
+		Update("locked_until", time.Now().Add(-time.Second)).Error)
+	dispatched, err = service.DispatchOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+
+	require.NoError(t, service.Shutdown(context.Background()))
+	close(received)
+
+	var eventIDs []string
+	for id := range received {
+		eventIDs = append(eventIDs, id)
+	}
+	assert.Equal(t, []string{event.ID}, eventIDs)
+
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, event.ID, deliveries[0].EventID)
+	assert.Equal(t, "success", deliveries[0].Status)
+
+	require.NoError(t, db.Model(&models.OutboxEntry{}).Count(&outboxed).Error)
+	assert.Equal(t, int64(0), outboxed, "dispatched and orphaned entries leave the outbox")
+}
+
 func TestWebhookDeliveryService_GenerateSignature(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	payload := []byte(`{"test": "data"}`)
 	secret := "test-secret"
 
-	signature := service.generateSignature(payload, secret)
+	signature := service.generateSignature(payload, secret, "sha256")
 	
 	// Verify signature format
 	assert.True(t, len(signature) > 7) // "sha256=" + hex
 	assert.Contains(t, signature, "sha256=")
 
 	// Verify signature is consistent
-	signature2 := service.generateSignature(payload, secret)
+	signature2 := service.generateSignature(payload, secret, "sha256")
 	assert.Equal(t, signature, signature2)
 
 	// Verify different payloads produce different signatures
-	signature3 := service.generateSignature([]byte(`{"different": "data"}`), secret)
+	signature3 := service.generateSignature([]byte(`{"different": "data"}`), secret, "sha256")
 	assert.NotEqual(t, signature, signature3)
 }
 
+func TestWebhookDeliveryService_SignatureAlgorithms(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	// RFC 2202 HMAC-SHA1 test case 2
+	assert.Equal(t, "sha1=effcdf6ae5eb2fa2d27416d5f184df9c259a7c79",
+		service.generateSignature([]byte("what do ya want for nothing?"), "Jefe", "sha1"))
+
+	var signature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Webhook-Signature")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+
+	tests := []struct {
+		algorithm string
+		prefix    string
+		hexLength int
+	}{
+		{algorithm: "sha1", prefix: "sha1=", hexLength: 40},
+		{algorithm: "sha256", prefix: "sha256=", hexLength: 64},
+		{algorithm: "sha512", prefix: "sha512=", hexLength: 128},
+		{algorithm: "", prefix: "sha256=", hexLength: 64},
+	}
+
+	for _, tt := range tests {
+		t.Run("algorithm "+tt.algorithm, func(t *testing.T) {
+			webhook.SignatureAlgorithm = tt.algorithm
+
+			_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+			require.NoError(t, err)
+
+			require.True(t, strings.HasPrefix(signature, tt.prefix), signature)
+			assert.Len(t, strings.TrimPrefix(signature, tt.prefix), tt.hexLength)
+			assert.Equal(t, service.generateSignature(body, webhook.Secret, tt.algorithm), signature)
+		})
+	}
+}
+
+func TestWebhookDeliveryService_SecretRotation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Empty(t, headers.Get("X-Webhook-Signature-Next"), "no next signature outside a rotation")
+
+	webhook.SecretNext = "next-secret"
+	_, _, err = service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+
+	assert.Equal(t, service.generateSignature(body, webhook.Secret, webhook.SignatureAlgorithm), headers.Get("X-Webhook-Signature"))
+	assert.Equal(t, service.generateSignature(body, "next-secret", webhook.SignatureAlgorithm), headers.Get("X-Webhook-Signature-Next"))
+}
+
+func TestWebhookDeliveryService_UserAgent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultUserAgent, userAgent)
+
+	service.SetUserAgent("acme-events/2.3")
+	_, _, err = service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Equal(t, "acme-events/2.3", userAgent)
+}
+
+func TestWebhookDeliveryService_CloudEventsContentType(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.ContentType = ContentTypeCloudEvents
+	event := createTestEvent(t, db, "test.event")
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeCloudEvents, contentType)
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &envelope))
+	assert.Equal(t, "1.0", envelope["specversion"])
+	assert.Equal(t, event.ID, envelope["id"])
+	assert.Equal(t, event.Type, envelope["type"])
+	assert.Equal(t, event.Source, envelope["source"])
+	assert.Equal(t, event.StreamID, envelope["subject"])
+	assert.Equal(t, ContentTypeJSON, envelope["datacontenttype"])
+	assert.Equal(t, float64(event.SequenceNumber), envelope["sequencenumber"])
+	assert.Equal(t, "data", envelope["data"].(map[string]interface{})["test"])
+
+	eventTime, err := time.Parse(time.RFC3339Nano, envelope["time"].(string))
+	require.NoError(t, err)
+	assert.True(t, event.Timestamp.Equal(eventTime))
+
+	// The plain envelope is not mixed in
+	assert.NotContains(t, envelope, "event_id")
+	assert.NotContains(t, envelope, "timestamp")
+}
+
+func TestWebhookDeliveryService_RetryClassification(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int32
+		wantStatus   string
+	}{
+		{"bad request is terminal", http.StatusBadRequest, 1, "failed"},
+		{"not found is terminal", http.StatusNotFound, 1, "failed"},
+		{"request timeout is retried", http.StatusRequestTimeout, 2, "failed"},
+		{"service unavailable is retried", http.StatusServiceUnavailable, 2, "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+
+			service := NewWebhookDeliveryService(db, logrus.New())
+
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			webhook := createTestWebhook(t, db, []string{"test.event"})
+			webhook.URL = server.URL
+			webhook.MaxRetries = 2
+			event := createTestEvent(t, db, "test.event")
+
+			delivery := models.WebhookDelivery{
+				ID:        "delivery-123",
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				Status:    "pending",
+			}
+			require.NoError(t, db.Create(&delivery).Error)
+
+			service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+			var stored models.WebhookDelivery
+			require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(&requests))
+			assert.Equal(t, int(tt.wantRequests), stored.AttemptCount)
+			assert.Equal(t, tt.wantStatus, stored.Status)
+			assert.Nil(t, stored.NextRetry)
+			assert.Contains(t, stored.ErrorMessage, strconv.Itoa(tt.status))
+		})
+	}
+}
+
+func TestWebhookDeliveryService_RetryAfter(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.attemptDelivery(context.Background(), webhook, event, &delivery)
+	}()
+
+	var stored models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		return db.First(&stored, "id = ?", delivery.ID).Error == nil && stored.AttemptCount == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "pending", stored.Status)
+	require.NotNil(t, stored.NextRetry)
+	assert.WithinDuration(t, start.Add(5*time.Second), *stored.NextRetry, time.Second)
+
+	// Shutting down interrupts the wait, leaving the delivery pending
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+	<-done
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"86400", maxRetryAfter},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), "Retry-After %q", tt.value)
+	}
+}
+
+func TestWebhookDeliveryService_PausedEventTypes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("X-Event-Type"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"order.*", "user.created"})
+	require.NoError(t, db.Model(&webhook).Updates(map[string]interface{}{"url": server.URL}).Error)
+	webhook.PausedEventTypes = []string{"order.refunded"}
+	require.NoError(t, db.Model(&webhook).Select("paused_event_types").Updates(&webhook).Error)
+
+	for i, eventType := range []string{"order.created", "order.refunded", "user.created"} {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      eventType,
+			StreamID:  "test-stream",
+			Source:    "test-service",
+			Timestamp: time.Now(),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+		require.NoError(t, service.DeliverEvent(context.Background(), event))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"order.created", "user.created"}, received)
+	mu.Unlock()
+
+	statuses := map[string]string{}
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	for _, delivery := range deliveries {
+		statuses[delivery.EventID] = delivery.Status
+	}
+	assert.Equal(t, map[string]string{"event-0": "success", "event-1": "paused", "event-2": "success"}, statuses)
+}
+
 func TestWebhookDeliveryService_CalculateRetryDelay(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	tests := []struct {
 		attempt      int
@@ -304,7 +788,7 @@ func TestWebhookDeliveryService_DisabledWebhook(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	// Create disabled webhook
 	webhook := createTestWebhook(t, db, []string{"test.event"})
@@ -328,44 +812,1084 @@ func TestWebhookDeliveryService_DisabledWebhook(t *testing.T) {
 	assert.Len(t, deliveries, 0)
 }
 
-func BenchmarkWebhookDelivery(b *testing.B) {
-	// Helper functions that work with both *testing.T and *testing.B
-	setupBenchDB := func() *database.DB {
-		gormDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Silent),
-		})
-		if err != nil {
-			b.Fatal(err)
-		}
+func TestWebhookDeliveryService_ShutdownWaitsForInFlightDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
 
-		db := &database.DB{DB: gormDB}
-		
-		err = db.AutoMigrate()
-		if err != nil {
-			b.Fatal(err)
-		}
+	service := NewWebhookDeliveryService(db, logrus.New())
 
-		return db
-	}
+	// Create slow test server that signals when the request arrives
+	requestReceived := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestReceived)
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "slow success"}`))
+	}))
+	defer server.Close()
 
-	createBenchWebhook := func(db *database.DB, eventTypes []string) models.WebhookEndpoint {
-		webhook := models.WebhookEndpoint{
-			ID:             "bench-webhook-123",
-			Name:           "Bench Webhook",
-			URL:            "http://example.com/webhook",
-			Secret:         "bench-secret",
-			EventTypes:     eventTypes,
-			Enabled:        true,
-			MaxRetries:     3,
-			TimeoutSeconds: 30,
-			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
-		}
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	err := db.Save(&webhook).Error
+	require.NoError(t, err)
 
-		err := db.Create(&webhook).Error
-		if err != nil {
-			b.Fatal(err)
-		}
+	event := createTestEvent(t, db, "test.event")
+
+	err = service.DeliverEvent(context.Background(), event)
+	require.NoError(t, err)
+
+	select {
+	case <-requestReceived:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook request was not received")
+	}
+
+	// Shutdown should block until the in-flight delivery completes
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = service.Shutdown(ctx)
+	assert.NoError(t, err)
+
+	var delivery models.WebhookDelivery
+	err = db.First(&delivery, "webhook_id = ?", webhook.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, "success", delivery.Status)
+	assert.Contains(t, delivery.Response, "slow success")
+}
+
+func TestWebhookDeliveryService_WorkerPoolBoundsConcurrency(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+	service.SetMaxConcurrentDeliveries(2)
+
+	var inFlight, maxInFlight, received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Far more deliveries than workers plus queue slots, so DeliverEvent has
+	// to wait for workers rather than dropping any
+	const webhookCount = 10
+	for i := 0; i < webhookCount; i++ {
+		webhook := models.WebhookEndpoint{
+			ID:             fmt.Sprintf("webhook-%d", i),
+			Name:           fmt.Sprintf("Webhook %d", i),
+			URL:            server.URL,
+			Secret:         "secret",
+			EventTypes:     []string{"test.event"},
+			Enabled:        true,
+			MaxRetries:     1,
+			TimeoutSeconds: 5,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+	event := createTestEvent(t, db, "test.event")
+
+	require.NoError(t, service.DeliverEvent(context.Background(), event))
+
+	require.Eventually(t, func() bool {
+		var succeeded int64
+		db.Model(&models.WebhookDelivery{}).Where("status = ?", "success").Count(&succeeded)
+		return succeeded == webhookCount
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int32(webhookCount), atomic.LoadInt32(&received))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, service.Shutdown(ctx))
+}
+
+func TestWebhookDeliveryService_OrderedDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Published through the manager so the payloads carry the sequence
+	// numbers the store assigns
+	manager := NewManager(NewDBEventStore(db), db, logrus.New())
+	service := manager.GetWebhookDeliveryService()
+
+	var (
+		mu       sync.Mutex
+		received []int64
+		inFlight int32
+		overlap  int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.StoreInt32(&overlap, 1)
+		}
+		defer atomic.AddInt32(&inFlight, -1)
+
+		var payload struct {
+			SequenceNumber int64 `json:"sequence_number"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		// Earlier events take longer, so unordered deliveries would overtake them
+		time.Sleep(time.Duration(4-payload.SequenceNumber) * 30 * time.Millisecond)
+
+		mu.Lock()
+		received = append(received, payload.SequenceNumber)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:              "ordered-webhook",
+		Name:            "Ordered Webhook",
+		URL:             server.URL,
+		Secret:          "secret",
+		EventTypes:      []string{"test.event"},
+		Enabled:         true,
+		MaxRetries:      1,
+		TimeoutSeconds:  5,
+		OrderedDelivery: true,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, manager.Publish(context.Background(), "ordered-stream", "test.event", "test-service", map[string]interface{}{"n": i}))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []int64{1, 2, 3}, received)
+	mu.Unlock()
+	assert.Zero(t, atomic.LoadInt32(&overlap), "ordered deliveries overlapped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, service.Shutdown(ctx))
+	assert.Empty(t, service.ordered)
+}
+
+func TestWebhookDeliveryService_OrderedDeliveryDeferred(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	manager := NewManager(NewDBEventStore(db), db, logrus.New())
+	service := manager.GetWebhookDeliveryService()
+
+	var (
+		mu       sync.Mutex
+		received []int64
+		failed   bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			SequenceNumber int64 `json:"sequence_number"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		mu.Lock()
+		defer mu.Unlock()
+		// The first event's first attempt fails and is retried
+		if payload.SequenceNumber == 1 && !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, payload.SequenceNumber)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:              "ordered-webhook",
+		Name:            "Ordered Webhook",
+		URL:             server.URL,
+		Secret:          "secret",
+		EventTypes:      []string{"test.event"},
+		Enabled:         true,
+		MaxRetries:      3,
+		TimeoutSeconds:  5,
+		OrderedDelivery: true,
+
+		MaxDeliveriesPerMinute: 240,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	// Use up the rate limit so the first event is deferred
+	for i := 0; i < webhook.MaxDeliveriesPerMinute; i++ {
+		service.limiter.reserve(webhook.ID, webhook.MaxDeliveriesPerMinute, time.Now())
+	}
+	require.NoError(t, manager.Publish(context.Background(), "ordered-stream", "test.event", "test-service", map[string]interface{}{"n": 1}))
+
+	// Published once the limit has room again, after the first event's
+	// slot has passed; the retry scheduler runs meanwhile
+	time.Sleep(700 * time.Millisecond)
+	for i := 2; i <= 3; i++ {
+		require.NoError(t, manager.Publish(context.Background(), "ordered-stream", "test.event", "test-service", map[string]interface{}{"n": i}))
+	}
+	require.Eventually(t, func() bool {
+		require.NoError(t, service.RetryFailedDeliveries(context.Background()))
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) >= 3
+	}, 10*time.Second, 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	mu.Lock()
+	assert.Equal(t, []int64{1, 2, 3}, received)
+	mu.Unlock()
+	assert.Empty(t, service.ordered)
+}
+
+func TestWebhookDeliveryService_StaleRetrySkipped(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.OrderedDelivery = true
+	event := createTestEvent(t, db, "test.event")
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+	var stale models.WebhookDelivery
+	require.NoError(t, db.First(&stale, "id = ?", delivery.ID).Error)
+
+	// Read back by the retry scheduler just before the worker that had it
+	// delivered it
+	require.NoError(t, db.Model(&delivery).Updates(map[string]interface{}{"status": "success", "attempt_count": 1, "updated_at": time.Now()}).Error)
+	// drained waits for the worker to finish with the stream's queue
+	drained := func(service *WebhookDeliveryService) func() bool {
+		return func() bool {
+			service.orderedMu.Lock()
+			defer service.orderedMu.Unlock()
+			return len(service.ordered) == 0
+		}
+	}
+
+	require.True(t, service.enqueue(context.Background(), deliveryJob{ctx: context.Background(), webhook: webhook, event: event, delivery: stale, reloaded: true}))
+	require.Eventually(t, drained(service), 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	assert.Zero(t, atomic.LoadInt32(&calls))
+
+	// An untouched delivery read back is sent
+	service = NewWebhookDeliveryService(db, logrus.New())
+	delivery.ID = "delivery-456"
+	delivery.Status = "pending"
+	delivery.AttemptCount = 0
+	require.NoError(t, db.Create(&delivery).Error)
+	stale = models.WebhookDelivery{}
+	require.NoError(t, db.First(&stale, "id = ?", delivery.ID).Error)
+	require.True(t, service.enqueue(context.Background(), deliveryJob{ctx: context.Background(), webhook: webhook, event: event, delivery: stale, reloaded: true}))
+	require.Eventually(t, drained(service), 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, service.Shutdown(ctx))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookDeliveryService_OrderedDeliveryRetryBudget(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	manager := NewManager(NewDBEventStore(db), db, logrus.New())
+	service := manager.GetWebhookDeliveryService()
+
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			SequenceNumber int64 `json:"sequence_number"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if payload.SequenceNumber == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:               "ordered-webhook",
+		Name:             "Ordered Webhook",
+		URL:              server.URL,
+		Secret:           "secret",
+		EventTypes:       []string{"test.event"},
+		Enabled:          true,
+		MaxRetries:       3,
+		TimeoutSeconds:   5,
+		OrderedDelivery:  true,
+		MaxRetriesPerDay: 1,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+	service.retryBudget.take(webhook.ID, webhook.MaxRetriesPerDay, time.Now())
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, manager.Publish(context.Background(), "ordered-stream", "test.event", "test-service", map[string]interface{}{"n": i}))
+	}
+
+	// Past the first attempt's backoff, the first event's retry waits for
+	// the budget, and the rest of the stream waits behind it
+	time.Sleep(1500 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt32(&received))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	assert.Zero(t, atomic.LoadInt32(&received))
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	require.Len(t, deliveries, 3)
+	for _, delivery := range deliveries {
+		assert.Equal(t, "pending", delivery.Status)
+	}
+	assert.Empty(t, service.ordered)
+}
+
+func TestWebhookDeliveryService_RateLimit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:                     "limited-webhook",
+		Name:                   "Limited Webhook",
+		URL:                    server.URL,
+		Secret:                 "secret",
+		EventTypes:             []string{"test.event"},
+		Enabled:                true,
+		MaxRetries:             3,
+		TimeoutSeconds:         5,
+		OrderedDelivery:        true,
+		MaxDeliveriesPerMinute: 1,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	for i := 1; i <= 2; i++ {
+		event := models.Event{
+			ID:        fmt.Sprintf("limited-event-%d", i),
+			Type:      "test.event",
+			StreamID:  "limited-stream",
+			Source:    "test-service",
+			Data:      models.JSON{"n": i},
+			Timestamp: time.Now(),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+		require.NoError(t, service.DeliverEvent(context.Background(), event))
+	}
+
+	// The first delivery goes out; the second waits for the next slot
+	var first, second models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		db.First(&first, "event_id = ?", "limited-event-1")
+		db.First(&second, "event_id = ?", "limited-event-2")
+		return first.Status == "success" && second.NextRetry != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+
+	assert.Equal(t, "success", first.Status)
+	assert.Equal(t, "pending", second.Status)
+	assert.Equal(t, 0, second.AttemptCount)
+	require.NotNil(t, second.NextRetry)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *second.NextRetry, 5*time.Second)
+}
+
+func TestDeliveryLimiter(t *testing.T) {
+	limiter := newDeliveryLimiter()
+	start := time.Now()
+
+	// A bucket starts full, so a minute's worth of deliveries can go at once
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, limiter.reserve("webhook", 3, start))
+	}
+	assert.Equal(t, 20*time.Second, limiter.reserve("webhook", 3, start))
+
+	// Tokens refill over time
+	assert.Zero(t, limiter.reserve("webhook", 3, start.Add(20*time.Second)))
+
+	// Other webhooks and unlimited webhooks are unaffected
+	assert.Zero(t, limiter.reserve("other", 3, start))
+	assert.Zero(t, limiter.reserve("unlimited", 0, start))
+}
+
+func TestWebhookDeliveryService_OAuthClientCredentials(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	// Stub token server issuing a new token on every request
+	var tokenRequests int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client-id", clientID)
+		assert.Equal(t, "client-secret", clientSecret)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "events:write audit", r.PostForm.Get("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	// Receiver that starts rejecting the first token after it has been used once
+	var revoked atomic.Bool
+	var receivedTokens []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		receivedTokens = append(receivedTokens, auth)
+
+		if auth == "Bearer token-1" && revoked.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer receiver.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = receiver.URL
+	webhook.OAuthTokenURL = tokenServer.URL
+	webhook.OAuthClientID = "client-id"
+	webhook.OAuthClientSecret = "client-secret"
+	webhook.OAuthScopes = "events:write audit"
+
+	event := createTestEvent(t, db, "test.event")
+
+	// First delivery fetches a token and attaches it
+	success, _, err := service.deliverToEndpoint(context.Background(), service.client, webhook, event)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+
+	// Second delivery reuses the cached token
+	success, _, err = service.deliverToEndpoint(context.Background(), service.client, webhook, event)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+
+	// A 401 forces a refresh and the delivery is retried with the new token
+	revoked.Store(true)
+	success, _, err = service.deliverToEndpoint(context.Background(), service.client, webhook, event)
+	require.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenRequests))
+
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1", "Bearer token-2"}, receivedTokens)
+}
+
+func TestWebhookDeliveryService_TraceContextPropagation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tracerProvider.Shutdown(context.Background())
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	defer otel.SetTracerProvider(previous)
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	err := db.Save(&webhook).Error
+	require.NoError(t, err)
+
+	event := createTestEvent(t, db, "test.event")
+
+	// Publish under a span carrying trace state; the delivery runs asynchronously
+	traceState, err := trace.ParseTraceState("vendor=value")
+	require.NoError(t, err)
+	publishCtx, publishSpan := tracerProvider.Tracer("test").Start(context.Background(), "publish")
+	publishCtx = trace.ContextWithSpanContext(publishCtx, publishSpan.SpanContext().WithTraceState(traceState))
+
+	err = service.DeliverEvent(publishCtx, event)
+	require.NoError(t, err)
+	publishSpan.End()
+
+	var headers http.Header
+	select {
+	case headers = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook request was not received")
+	}
+
+	traceparent := headers.Get("traceparent")
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-0[0-9a-f]$`, traceparent)
+	assert.Equal(t, "vendor=value", headers.Get("tracestate"))
+
+	// The delivery joins the publisher's trace
+	traceID := publishSpan.SpanContext().TraceID().String()
+	assert.Equal(t, traceID, strings.Split(traceparent, "-")[1])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	var deliverySpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "webhook.deliver" {
+			deliverySpan = span
+		}
+	}
+	require.NotNil(t, deliverySpan)
+	assert.Equal(t, publishSpan.SpanContext().SpanID(), deliverySpan.Parent().SpanID())
+	assert.Equal(t, deliverySpan.SpanContext().SpanID().String(), strings.Split(traceparent, "-")[2])
+
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, attr := range deliverySpan.Attributes() {
+		attributes[attr.Key] = attr.Value
+	}
+	assert.Equal(t, int64(http.StatusOK), attributes["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(1), attributes["webhook.attempt"].AsInt64())
+}
+
+func TestWebhookDeliveryService_ResponseTruncation(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseBody  string
+		wantResponse  string
+		wantTruncated bool
+	}{
+		{
+			name:          "long response is truncated at the configured length",
+			responseBody:  strings.Repeat("x", 100),
+			wantResponse:  strings.Repeat("x", 16),
+			wantTruncated: true,
+		},
+		{
+			name:          "response at the limit is stored in full",
+			responseBody:  strings.Repeat("y", 16),
+			wantResponse:  strings.Repeat("y", 16),
+			wantTruncated: false,
+		},
+		{
+			name:          "short response is stored in full",
+			responseBody:  `{"ok": true}`,
+			wantResponse:  `{"ok": true}`,
+			wantTruncated: false,
+		},
+		{
+			name:          "multi-byte character ending at the limit is kept",
+			responseBody:  strings.Repeat("a", 14) + "é",
+			wantResponse:  strings.Repeat("a", 14) + "é",
+			wantTruncated: false,
+		},
+		{
+			name:          "truncation backs off to a character boundary",
+			responseBody:  strings.Repeat("a", 15) + "éé",
+			wantResponse:  strings.Repeat("a", 15),
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+
+			service := NewWebhookDeliveryService(db, logrus.New())
+			service.SetMaxResponseBytes(16)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.responseBody))
+			}))
+			defer server.Close()
+
+			webhook := createTestWebhook(t, db, []string{"test.event"})
+			webhook.URL = server.URL
+			event := createTestEvent(t, db, "test.event")
+
+			delivery := models.WebhookDelivery{
+				ID:        "delivery-123",
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				Status:    "pending",
+			}
+			err := db.Create(&delivery).Error
+			require.NoError(t, err)
+
+			service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+			var stored models.WebhookDelivery
+			err = db.First(&stored, "id = ?", delivery.ID).Error
+			require.NoError(t, err)
+			assert.Equal(t, "success", stored.Status)
+			assert.Equal(t, tt.wantResponse, stored.Response)
+			assert.Equal(t, tt.wantTruncated, stored.ResponseTruncated)
+		})
+	}
+}
+
+func TestWebhookDeliveryService_WebhookInfoInPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		include bool
+	}{
+		{name: "omitted by default", include: false},
+		{name: "included when enabled", include: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+
+			service := NewWebhookDeliveryService(db, logrus.New())
+
+			var payload map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&payload)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			webhook := createTestWebhook(t, db, []string{"test.event"})
+			webhook.URL = server.URL
+			webhook.IncludeWebhookInfo = tt.include
+			event := createTestEvent(t, db, "test.event")
+
+			_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+			require.NoError(t, err)
+			require.NotNil(t, payload)
+
+			info, ok := payload["webhook"]
+			if !tt.include {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, map[string]interface{}{
+				"id":   "test-webhook-123",
+				"name": "Test Webhook",
+			}, info)
+		})
+	}
+}
+
+func TestWebhookDeliveryService_PayloadTemplate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Webhook-Signature")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.PayloadTemplate = `{"text": "{{.event_type}} from {{.source}}", "id": {{json .event_id}}, "attributes": {{json .data}}}`
+	event := createTestEvent(t, db, "test.event")
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"text": "test.event from test-service", "id": "test-event-123", "attributes": {"test": "data"}}`, string(body))
+
+	// The signature covers the rendered body
+	assert.Equal(t, service.generateSignature(body, webhook.Secret, webhook.SignatureAlgorithm), signature)
+
+	t.Run("render errors fail the delivery", func(t *testing.T) {
+		webhook.PayloadTemplate = `{{template "missing"}}`
+		_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+		assert.ErrorIs(t, err, ErrPayloadRender)
+	})
+
+	t.Run("render errors are not retried", func(t *testing.T) {
+		webhook.PayloadTemplate = `{{template "missing"}}`
+		webhook.MaxRetries = 3
+		delivery := models.WebhookDelivery{
+			ID:        "render-failure",
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Status:    "pending",
+		}
+		require.NoError(t, db.Create(&delivery).Error)
+
+		started := time.Now()
+		service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+		assert.Less(t, time.Since(started), time.Second, "no backoff before a retry")
+		var stored models.WebhookDelivery
+		require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+		assert.Equal(t, "failed", stored.Status)
+		assert.Equal(t, 1, stored.AttemptCount)
+		assert.Contains(t, stored.ErrorMessage, ErrPayloadRender.Error())
+	})
+}
+
+func TestWebhookDeliveryService_EventMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var payload map[string]interface{}
+	var correlationHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationHeader = r.Header.Get("X-Correlation-ID")
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+
+	event := createTestEvent(t, db, "test.event")
+	event.Metadata = models.JSON{
+		"correlation_id": "corr-123",
+		"causation_id":   "cause-456",
+	}
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "corr-123", correlationHeader)
+	assert.Equal(t, map[string]interface{}{
+		"correlation_id": "corr-123",
+		"causation_id":   "cause-456",
+	}, payload["metadata"])
+
+	// Events without metadata send neither the block nor the header
+	event.Metadata = nil
+	payload = nil
+	_, _, err = service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+
+	assert.Empty(t, correlationHeader)
+	assert.NotContains(t, payload, "metadata")
+}
+
+func TestWebhookDeliveryService_SchemaVersion(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var payload map[string]interface{}
+	var versionHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versionHeader = r.Header.Get("X-Event-Schema-Version")
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+
+	event := createTestEvent(t, db, "test.event")
+	event.SchemaVersion = 3
+
+	_, _, err := service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Equal(t, "3", versionHeader)
+	assert.Equal(t, float64(3), payload["schema_version"])
+
+	// Events without a version are sent as the default version
+	event.SchemaVersion = 0
+	_, _, err = service.deliverToEndpoint(context.Background(), server.Client(), webhook, event)
+	require.NoError(t, err)
+	assert.Equal(t, "1", versionHeader)
+	assert.Equal(t, float64(1), payload["schema_version"])
+}
+
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestURLGuard_ValidateURL(t *testing.T) {
+	guard := NewURLGuard(false)
+	guard.resolver = staticResolver{
+		"localhost":          {"127.0.0.1", "::1"},
+		"hooks.example.com":  {"93.184.216.34"},
+		"rebind.example.com": {"93.184.216.34", "10.1.2.3"},
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed bool
+	}{
+		{name: "localhost", url: "http://localhost/webhook", allowed: false},
+		{name: "cloud metadata address", url: "http://169.254.169.254/latest/meta-data", allowed: false},
+		{name: "private 10.x address", url: "http://10.0.0.5:8080/hook", allowed: false},
+		{name: "IPv6 loopback", url: "http://[::1]/hook", allowed: false},
+		{name: "host with one private address", url: "https://rebind.example.com/hook", allowed: false},
+		{name: "unsupported scheme", url: "ftp://hooks.example.com/hook", allowed: false},
+		{name: "public host", url: "https://hooks.example.com/webhook", allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.ValidateURL(context.Background(), tt.url)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	t.Run("private addresses allowed when configured", func(t *testing.T) {
+		assert.NoError(t, NewURLGuard(true).ValidateURL(context.Background(), "http://169.254.169.254/"))
+	})
+}
+
+func TestURLGuard_ValidateProxyURL(t *testing.T) {
+	guard := NewURLGuard(false)
+	guard.resolver = staticResolver{
+		"proxy.example.com": {"93.184.216.34"},
+		"egress.internal":   {"10.0.0.8"},
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed bool
+	}{
+		{name: "http proxy", url: "http://proxy.example.com:3128", allowed: true},
+		{name: "socks5 proxy", url: "socks5://proxy.example.com:1080", allowed: true},
+		{name: "internal proxy", url: "socks5://egress.internal:1080", allowed: false},
+		{name: "unsupported scheme", url: "ftp://proxy.example.com", allowed: false},
+		{name: "no host", url: "socks5://", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.ValidateProxyURL(context.Background(), tt.url)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryService_URLGuardRefusesPrivateReceiver(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+	service.SetURLGuard(NewURLGuard(false))
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.MaxRetries = 1
+	event := createTestEvent(t, db, "test.event")
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	// The check runs when the connection is dialed, so it also catches hosts
+	// that passed validation but now resolve to an internal address
+	service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+	assert.False(t, called)
+
+	var stored models.WebhookDelivery
+	require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+	assert.Equal(t, "failed", stored.Status)
+	assert.Contains(t, stored.ErrorMessage, ErrDisallowedAddress.Error())
+}
+
+func TestWebhookDeliveryService_WebhookLogLevel(t *testing.T) {
+	tests := []struct {
+		name         string
+		logLevel     string
+		expectLogged bool
+	}{
+		{
+			name:         "global level logs successful deliveries",
+			logLevel:     "",
+			expectLogged: true,
+		},
+		{
+			name:         "errors-only webhook does not log successful deliveries",
+			logLevel:     "error",
+			expectLogged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+
+			service := NewWebhookDeliveryService(db, logrus.New())
+			var logOutput bytes.Buffer
+			service.logger.SetOutput(&logOutput)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			webhook := createTestWebhook(t, db, []string{"test.event"})
+			webhook.URL = server.URL
+			webhook.LogLevel = tt.logLevel
+			err := db.Save(&webhook).Error
+			require.NoError(t, err)
+
+			event := createTestEvent(t, db, "test.event")
+
+			err = service.DeliverEvent(context.Background(), event)
+			require.NoError(t, err)
+
+			// Wait for the delivery goroutine to finish
+			err = service.Shutdown(context.Background())
+			require.NoError(t, err)
+
+			var delivery models.WebhookDelivery
+			err = db.First(&delivery, "webhook_id = ?", webhook.ID).Error
+			require.NoError(t, err)
+			assert.Equal(t, "success", delivery.Status)
+
+			if tt.expectLogged {
+				assert.Contains(t, logOutput.String(), "Webhook delivered successfully")
+			} else {
+				assert.NotContains(t, logOutput.String(), "Webhook delivered successfully")
+			}
+		})
+	}
+}
+
+func BenchmarkWebhookDelivery(b *testing.B) {
+	// Helper functions that work with both *testing.T and *testing.B
+	setupBenchDB := func() *database.DB {
+		gormDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		db := &database.DB{DB: gormDB}
+		
+		err = db.AutoMigrate()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		return db
+	}
+
+	createBenchWebhook := func(db *database.DB, eventTypes []string) models.WebhookEndpoint {
+		webhook := models.WebhookEndpoint{
+			ID:             "bench-webhook-123",
+			Name:           "Bench Webhook",
+			URL:            "http://example.com/webhook",
+			Secret:         "bench-secret",
+			EventTypes:     eventTypes,
+			Enabled:        true,
+			MaxRetries:     3,
+			TimeoutSeconds: 30,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+
+		err := db.Create(&webhook).Error
+		if err != nil {
+			b.Fatal(err)
+		}
 
 		return webhook
 	}
@@ -393,7 +1917,7 @@ func BenchmarkWebhookDelivery(b *testing.B) {
 	db := setupBenchDB()
 	defer db.Close()
 
-	service := NewWebhookDeliveryService(db)
+	service := NewWebhookDeliveryService(db, logrus.New())
 
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -418,4 +1942,438 @@ func BenchmarkWebhookDelivery(b *testing.B) {
 			b.Error(err)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestWebhookDeliveryService_Proxy(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	// newProxy starts a forward proxy recording the URLs it was asked for
+	newProxy := func(proxied *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*proxied = append(*proxied, r.URL.String())
+			outReq := r.Clone(r.Context())
+			outReq.RequestURI = ""
+			resp, err := http.DefaultTransport.RoundTrip(outReq)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			io.Copy(w, resp.Body)
+		}))
+	}
+
+	var globalProxied, webhookProxied []string
+	globalProxy := newProxy(&globalProxied)
+	defer globalProxy.Close()
+	webhookProxy := newProxy(&webhookProxied)
+	defer webhookProxy.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+	require.NoError(t, service.SetProxy(globalProxy.URL))
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = receiver.URL + "/hook"
+
+	result := service.SendTestDelivery(context.Background(), webhook)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, []string{receiver.URL + "/hook"}, globalProxied)
+
+	// A webhook's own proxy takes precedence
+	webhook.ProxyURL = webhookProxy.URL
+	result = service.SendTestDelivery(context.Background(), webhook)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, []string{receiver.URL + "/hook"}, webhookProxied)
+	assert.Len(t, globalProxied, 1)
+
+	assert.Error(t, service.SetProxy("ftp://proxy.example.com"))
+	assert.Error(t, service.SetProxy("http://"))
+
+	t.Run("URL guard doesn't check the receiver behind a proxy", func(t *testing.T) {
+		var proxied []string
+		proxy := newProxy(&proxied)
+		defer proxy.Close()
+
+		guarded := NewWebhookDeliveryService(db, logrus.New())
+		guarded.SetURLGuard(NewURLGuard(false))
+
+		// Refused when dialed directly...
+		webhook.ProxyURL = ""
+		result := guarded.SendTestDelivery(context.Background(), webhook)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, ErrDisallowedAddress.Error())
+
+		// ...but only the proxy is dialed when there is one, so the internal
+		// receiver is left to the proxy's egress policy
+		webhook.ProxyURL = proxy.URL
+		result = guarded.SendTestDelivery(context.Background(), webhook)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, []string{receiver.URL + "/hook"}, proxied)
+	})
+}
+
+func TestWebhookDeliveryService_ForgetTransport(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	first := models.WebhookEndpoint{ID: "webhook-1", ProxyURL: "http://proxy.example.com:3128"}
+	second := models.WebhookEndpoint{ID: "webhook-2", ProxyURL: "http://proxy.example.com:3128"}
+
+	transport := service.transportFor(first)
+	assert.Same(t, transport, service.transportFor(first))
+	assert.NotSame(t, transport, service.transportFor(second), "each webhook gets its own transport")
+	assert.Len(t, service.transports, 2)
+
+	// A changed or deleted webhook's transports are dropped
+	service.ForgetTransport(first.ID)
+	assert.Len(t, service.transports, 1)
+	assert.NotSame(t, transport, service.transportFor(first))
+}
+
+func TestWebhookDeliveryService_InsecureSkipVerify(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Serves with a self-signed certificate
+	receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = receiver.URL
+
+	result := service.SendTestDelivery(context.Background(), webhook)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "certificate")
+
+	webhook.InsecureSkipVerify = true
+	result = service.SendTestDelivery(context.Background(), webhook)
+	assert.True(t, result.Success, result.Error)
+
+	_, err := ParseClientCertificate("not a certificate", "not a key")
+	assert.Error(t, err)
+}
+
+func TestPayloadSchema(t *testing.T) {
+	schema := PayloadSchema()
+	properties := schema["properties"].(map[string]interface{})
+	required := schema["required"].([]string)
+
+	// Every field of a fully populated payload is described, and the fields
+	// always sent are required
+	webhook := models.WebhookEndpoint{ID: "wh-1", Name: "Orders", IncludeWebhookInfo: true}
+	event := models.Event{
+		ID:             "evt-1",
+		Type:           "order.created",
+		StreamID:       "order-1",
+		Source:         "orders",
+		Data:           models.JSON{"total": 42},
+		Metadata:       models.JSON{"correlation_id": "corr-1"},
+		Timestamp:      time.Now(),
+		SequenceNumber: 1,
+	}
+	payload := webhookPayload(webhook, event)
+	for field := range payload {
+		assert.Contains(t, properties, field)
+	}
+	assert.Len(t, properties, len(payload))
+
+	minimal := webhookPayload(models.WebhookEndpoint{}, models.Event{ID: "evt-2"})
+	assert.Len(t, required, len(minimal))
+	for _, field := range required {
+		assert.Contains(t, minimal, field)
+	}
+}
+
+func TestWebhookDeliveryService_MaxPayloadBytes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+	service.SetMaxPayloadBytes(1024)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+	event.Data = models.JSON{"blob": strings.Repeat("x", 2048)}
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+	var stored models.WebhookDelivery
+	require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+	assert.Equal(t, "failed", stored.Status)
+	assert.Equal(t, 1, stored.AttemptCount)
+	assert.Contains(t, stored.ErrorMessage, "payload too large")
+	assert.Nil(t, stored.NextRetry)
+	assert.Zero(t, atomic.LoadInt32(&requests))
+}
+
+func TestWebhookDeliveryService_RecordsAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("temporarily broken"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	event := createTestEvent(t, db, "test.event")
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+	// The delivery only shows the last attempt; each attempt has its own row
+	var stored models.WebhookDelivery
+	require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+	assert.Equal(t, "success", stored.Status)
+	assert.Equal(t, "ok", stored.Response)
+
+	attempts, err := db.GetDeliveryAttempts(delivery.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.False(t, attempts[0].Success)
+	assert.Equal(t, http.StatusInternalServerError, attempts[0].StatusCode)
+	assert.Equal(t, "temporarily broken", attempts[0].Response)
+	assert.Contains(t, attempts[0].ErrorMessage, "500")
+
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.True(t, attempts[1].Success)
+	assert.Equal(t, http.StatusOK, attempts[1].StatusCode)
+	assert.Equal(t, "ok", attempts[1].Response)
+	assert.Empty(t, attempts[1].ErrorMessage)
+}
+
+func TestWebhookDeliveryService_EventTypeAliases(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("X-Event-Type"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// order.created was renamed to order.placed; the webhook still uses the old name
+	webhook := createTestWebhook(t, db, []string{"order.created"})
+	require.NoError(t, db.Model(&webhook).Updates(map[string]interface{}{"url": server.URL}).Error)
+	require.NoError(t, db.Create(&models.EventTypeAlias{Alias: "order.created", EventType: "order.placed"}).Error)
+
+	for i, eventType := range []string{"order.placed", "order.created", "order.cancelled"} {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      eventType,
+			StreamID:  "test-stream",
+			Source:    "test-service",
+			Timestamp: time.Now(),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+		require.NoError(t, service.DeliverEvent(context.Background(), event))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	// The event keeps the type it was published under
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"order.placed", "order.created"}, received)
+	mu.Unlock()
+}
+
+func TestEventTypeNames(t *testing.T) {
+	aliases := []models.EventTypeAlias{
+		{Alias: "order.new", EventType: "order.created"},
+		{Alias: "order.created", EventType: "order.placed"},
+	}
+
+	assert.Equal(t, []string{"user.created"}, eventTypeNames("user.created", aliases))
+	assert.Equal(t, []string{"order.placed", "order.created", "order.new"}, eventTypeNames("order.placed", aliases))
+	assert.ElementsMatch(t, []string{"order.new", "order.created", "order.placed"}, eventTypeNames("order.new", aliases))
+}
+
+func TestWebhookDeliveryService_Method(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+
+	result := service.SendTestDelivery(context.Background(), webhook)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, http.MethodPost, method)
+
+	webhook.Method = http.MethodPut
+	result = service.SendTestDelivery(context.Background(), webhook)
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, http.MethodPut, method)
+}
+
+func TestWebhookDeliveryService_RetryBudget(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.MaxRetries = 5
+	webhook.MaxRetriesPerDay = 1
+	event := createTestEvent(t, db, "test.event")
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-123",
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Status:    "pending",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	start := time.Now()
+	service.attemptDelivery(context.Background(), webhook, event, &delivery)
+
+	// The first attempt and the single budgeted retry were sent; the next
+	// retry waits for the budget to reset
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	var stored models.WebhookDelivery
+	require.NoError(t, db.First(&stored, "id = ?", delivery.ID).Error)
+	assert.Equal(t, "pending", stored.Status)
+	assert.Equal(t, 2, stored.AttemptCount)
+	require.NotNil(t, stored.NextRetry)
+	assert.WithinDuration(t, start.Add(24*time.Hour), *stored.NextRetry, 5*time.Second)
+
+	budget := service.RetryBudget(webhook)
+	assert.Equal(t, 1, budget.Limit)
+	assert.Equal(t, 1, budget.Used)
+	assert.Equal(t, 0, budget.Remaining)
+	assert.True(t, budget.Exhausted)
+	require.NotNil(t, budget.ResetsAt)
+	assert.True(t, stored.NextRetry.Equal(*budget.ResetsAt))
+
+	// Webhooks without a budget are unaffected
+	assert.Equal(t, models.RetryBudget{}, service.RetryBudget(models.WebhookEndpoint{ID: "other"}))
+}
+
+func TestRetryBudget_Resets(t *testing.T) {
+	budget := newRetryBudget()
+	now := time.Now()
+
+	_, ok := budget.take("webhook", 2, now)
+	assert.True(t, ok)
+	_, ok = budget.take("webhook", 2, now.Add(time.Hour))
+	assert.True(t, ok)
+	resetsAt, ok := budget.take("webhook", 2, now.Add(2*time.Hour))
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(retryBudgetWindow), resetsAt)
+
+	_, ok = budget.take("webhook", 2, now.Add(retryBudgetWindow))
+	assert.True(t, ok)
+	assert.Equal(t, 1, budget.status("webhook", 2, now.Add(retryBudgetWindow)).Remaining)
+}
+
+func TestWebhookDeliveryService_CompressPayload(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewWebhookDeliveryService(db, logrus.New())
+
+	var encoding, signature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		signature = r.Header.Get("X-Webhook-Signature")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook := createTestWebhook(t, db, []string{"test.event"})
+	webhook.URL = server.URL
+	webhook.CompressPayload = true
+	event := createTestEvent(t, db, "test.event")
+
+	_, err := service.deliver(context.Background(), http.DefaultClient, webhook, event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gzip", encoding)
+	// Signed over the compressed bytes, as sent
+	assert.Equal(t, service.generateSignature(body, webhook.Secret, webhook.SignatureAlgorithm), signature)
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(zr)
+	require.NoError(t, err)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(decompressed, &payload))
+	assert.Equal(t, event.ID, payload["event_id"])
+	assert.Equal(t, "test.event", payload["event_type"])
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create Event Type Alias
+// @Description Map an old event type name onto its new one, e.g. after a rename. Webhooks subscribed to either
+// @Description name then receive events published under the other. Posting an existing alias repoints it.
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param alias body models.CreateEventTypeAliasRequest true "Alias"
+// @Success 201 {object} models.APIResponse{data=models.EventTypeAlias}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/aliases [post]
+func (h *Handler) CreateEventTypeAlias(c *gin.Context) {
+	var req models.CreateEventTypeAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	alias := models.EventTypeAlias{Alias: req.Alias, EventType: req.EventType}
+	if err := h.dbFor(c).Save(&alias).Error; err != nil {
+		h.logger.WithError(err).Error("Failed to save event type alias")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to create alias",
+		})
+		return
+	}
+
+	h.recordAudit(c, "event_type_alias.created", "event_type_alias", alias.Alias, map[string]interface{}{
+		"event_type": alias.EventType,
+	})
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    alias,
+	})
+}
+
+// @Summary Get Event Type Aliases
+// @Description List every event type alias
+// @Tags events
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.EventTypeAlias}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/aliases [get]
+func (h *Handler) GetEventTypeAliases(c *gin.Context) {
+	aliases, err := h.db.GetEventTypeAliases(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get event type aliases")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get aliases",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    aliases,
+	})
+}
+
+// @Summary Delete Event Type Alias
+// @Description Remove an event type alias; webhooks then only match the names they subscribe to
+// @Tags events
+// @Produce json
+// @Param alias path string true "Alias"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/aliases/{alias} [delete]
+func (h *Handler) DeleteEventTypeAlias(c *gin.Context) {
+	aliasName := c.Param("alias")
+
+	result := h.dbFor(c).Delete(&models.EventTypeAlias{}, "alias = ?", aliasName)
+	if result.Error != nil {
+		h.logger.WithError(result.Error).Error("Failed to delete event type alias")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete alias",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Alias not found",
+		})
+		return
+	}
+
+	h.recordAudit(c, "event_type_alias.deleted", "event_type_alias", aliasName, nil)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Alias deleted successfully",
+	})
+}
@@ -1,9 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
 	"goapitemplate/internal/cache"
+	"goapitemplate/internal/config"
 	"goapitemplate/internal/database"
 	"goapitemplate/internal/events"
+	"goapitemplate/internal/middleware"
+	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -15,45 +25,94 @@ type Handler struct {
 	db           *database.DB
 	cache        cache.Client
 	eventManager *events.Manager
+	config       *config.Config
 	logger       *logrus.Logger
+
+	// reportedGaps holds the sequence gaps last alerted on per stream, so
+	// repeated integrity checks don't alert on the same gaps again
+	reportedGapsMu sync.Mutex
+	reportedGaps   map[string][]models.SequenceGap
 }
 
-func New(db *database.DB, cache cache.Client, eventManager *events.Manager) *Handler {
+func New(db *database.DB, cache cache.Client, eventManager *events.Manager, cfg *config.Config, logger *logrus.Logger) *Handler {
 	return &Handler{
 		db:           db,
 		cache:        cache,
 		eventManager: eventManager,
-		logger:       logrus.New(),
+		config:       cfg,
+		logger:       logger,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
-	api := router.Group("/api/v1")
+	h.registerV1(router.Group("/api/v1", middleware.APIVersion("v1")))
+	h.registerV2(router.Group("/api/v2", middleware.APIVersion("v2")))
+
+	// Root redirect to documentation
+	router.GET("/", h.RootRedirect)
+	
+	// API documentation
+	router.GET("/docs/*any", h.SwaggerDocs)
+}
+
+// registerV1 registers the v1 API. Endpoints being phased out stay here,
+// marked with middleware.Deprecated, until their sunset date.
+func (h *Handler) registerV1(api *gin.RouterGroup) {
+	adminAuth := middleware.AdminAuth(h.config.Admin.APIKey)
+
 	{
 		// Health check
 		api.GET("/health", h.HealthCheck)
+		api.GET("/version", h.GetVersion)
 
 		// Event routes
-		events := api.Group("/events")
+		// NDJSON is accepted for stream ingestion only; IngestEvents checks it
+		events := api.Group("/events", middleware.RequireJSON("application/x-ndjson"))
 		{
 			events.POST("/", h.CreateEvent)
+			events.POST("/batch", h.CreateEventsBatch)
+			events.POST("/stream-ingest", h.IngestEvents)
 			events.GET("/", h.GetEvents)
 			events.GET("/types/:type", h.GetEventsByType)
+			events.GET("/search", h.SearchEvents)
+			events.POST("/aliases", h.CreateEventTypeAlias)
+			events.GET("/aliases", h.GetEventTypeAliases)
+			events.DELETE("/aliases/:alias", h.DeleteEventTypeAlias)
+			events.POST("/:id/redeliver", h.RedeliverEvent)
+			events.GET("/ws", h.StreamEventsWebSocket)
 			events.GET("/streams", h.GetEventStreams)
+			events.GET("/streams/summary", h.GetEventStreamsSummary)
+			events.GET("/streams/cursors", h.GetEventStreamCursors)
 			events.GET("/streams/:stream_id", h.GetEventsByStream)
+			events.GET("/streams/:stream_id/export", h.ExportEventStream)
+			events.GET("/streams/:stream_id/integrity", h.GetStreamIntegrity)
+			events.DELETE("/streams/:stream_id", adminAuth, h.DeleteEventStream)
 		}
 
 		// Webhook management routes
-		webhooks := api.Group("/webhooks")
+		webhooks := api.Group("/webhooks", middleware.RequireJSON())
 		{
 			webhooks.POST("/", h.CreateWebhook)
 			webhooks.GET("/", h.GetWebhooks)
+			webhooks.DELETE("/", h.DeleteWebhooksByEventType)
 			webhooks.GET("/:id", h.GetWebhook)
 			webhooks.PUT("/:id", h.UpdateWebhook)
 			webhooks.DELETE("/:id", h.DeleteWebhook)
+			webhooks.POST("/:id/restore", h.RestoreWebhook)
+			webhooks.POST("/:id/rotate-secret", h.RotateWebhookSecret)
+			webhooks.POST("/:id/pause", h.PauseWebhookEventTypes)
+			webhooks.POST("/:id/resume", h.ResumeWebhookEventTypes)
+			webhooks.POST("/:id/test", h.TestWebhook)
 			webhooks.GET("/:id/deliveries", h.GetWebhookDeliveries)
+			webhooks.GET("/:id/stats", h.GetWebhookStatsForWebhook)
+			webhooks.GET("/deliveries/:delivery_id", h.GetWebhookDelivery)
+			webhooks.GET("/deliveries/:delivery_id/attempts", h.GetWebhookDeliveryAttempts)
+			webhooks.POST("/deliveries/reconcile", h.ReconcileWebhookDeliveries)
+			webhooks.POST("/:id/retry", h.RetryWebhookDeliveriesForWebhook)
 			webhooks.POST("/retry", h.RetryWebhookDeliveries)
 			webhooks.GET("/stats", h.GetWebhookStats)
+			webhooks.GET("/stats/timeseries", h.GetWebhookStatsTimeSeries)
+			webhooks.GET("/payload-schema", h.GetWebhookPayloadSchema)
 		}
 
 
@@ -61,14 +120,16 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		monitoring := api.Group("/monitoring")
 		{
 			monitoring.GET("/stats", h.GetStats)
+			monitoring.GET("/events/stats", h.GetEventStats)
+			monitoring.GET("/events/timeseries", h.GetEventTimeSeries)
 		}
 	}
+}
 
-	// Root redirect to documentation
-	router.GET("/", h.RootRedirect)
-	
-	// API documentation
-	router.GET("/docs/*any", h.SwaggerDocs)
+// registerV2 registers the v2 API, which serves alongside v1. Endpoints with
+// breaking changes are added here; v1 keeps its existing behavior.
+func (h *Handler) registerV2(api *gin.RouterGroup) {
+	api.GET("/health", h.HealthCheck)
 }
 
 func (h *Handler) RootRedirect(c *gin.Context) {
@@ -78,3 +139,56 @@ func (h *Handler) RootRedirect(c *gin.Context) {
 func (h *Handler) SwaggerDocs(c *gin.Context) {
 	ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
 }
+
+// parsePagination reads the limit and offset query parameters, falling back to
+// a limit of 50 and an offset of 0 when they are missing or invalid
+func parsePagination(c *gin.Context) (limit, offset int) {
+	limit = 50
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	return limit, offset
+}
+
+// recordAudit stores an audit log entry for an administrative action. Failures
+// are logged rather than returned so they don't mask the action's outcome.
+func (h *Handler) recordAudit(c *gin.Context, action, resource, resourceID string, details map[string]interface{}) {
+	entry := models.AuditLog{
+		ID:         generateAuditID(),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Actor:      c.ClientIP(),
+		Details:    models.JSON(details),
+		CreatedAt:  time.Now(),
+	}
+
+	// Recorded even if the request has just timed out, since the action it
+	// records has already been carried out
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := h.db.ForContext(ctx).Create(&entry).Error; err != nil {
+		h.logger.WithError(err).WithField("action", action).Error("Failed to record audit log")
+	}
+}
+
+// dbFor returns the database bound to the request's context, so its queries
+// stop once the request times out or the client goes away
+func (h *Handler) dbFor(c *gin.Context) *database.DB {
+	return h.db.ForContext(c.Request.Context())
+}
+
+func generateAuditID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return fmt.Sprintf("aud_%x", bytes)
+}
@@ -2,37 +2,80 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
+	"goapitemplate/internal/database"
+	"goapitemplate/internal/events"
 	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // @Summary Create Event
-// @Description Create a new event in a stream
+// @Description Create a new event in a stream. Events may also be sent as a CloudEvents 1.0 structured-mode
+// @Description envelope (models.CloudEventRequest) with Content-Type application/cloudevents+json; its subject is the stream ID.
 // @Tags events
 // @Accept json
 // @Produce json
 // @Param event body models.CreateEventRequest true "Event data"
 // @Success 201 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/events [post]
 func (h *Handler) CreateEvent(c *gin.Context) {
 	var req models.CreateEventRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	var cloudEventID string
+	if c.ContentType() == events.ContentTypeCloudEvents {
+		var ce models.CloudEventRequest
+		if err := c.ShouldBindJSON(&ce); err != nil {
+			c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+			return
+		}
+		req = cloudEventToRequest(ce)
+		cloudEventID = ce.ID
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	event, err := h.buildEvent(req, c.GetString("request_id"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errSourceNotAllowed) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, models.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
+	if cloudEventID != "" {
+		// A CloudEvent is identified by its source and id, so storing it
+		// again fails as a duplicate
+		event.CloudEventID = &cloudEventID
+	}
 
 	// Publish event using the event manager
-	err := h.eventManager.Publish(context.Background(), req.StreamID, req.Type, req.Source, req.Data)
+	err = h.eventManager.PublishEvent(c.Request.Context(), event)
 	if err != nil {
+		if errors.Is(err, database.ErrSequenceConflict) || errors.Is(err, database.ErrDuplicateCloudEvent) {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
 		h.logger.WithError(err).Error("Failed to publish event")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -47,26 +90,176 @@ func (h *Handler) CreateEvent(c *gin.Context) {
 	})
 }
 
+// @Summary Create Events in Batch
+// @Description Create several events at once. In best-effort mode each event is created independently and
+// @Description per-item results are returned (207 when some fail); in atomic mode all events are created or none.
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param batch body models.CreateEventBatchRequest true "Batch of events"
+// @Success 201 {object} models.APIResponse{data=models.BatchResponse}
+// @Success 207 {object} models.APIResponse{data=models.BatchResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/batch [post]
+func (h *Handler) CreateEventsBatch(c *gin.Context) {
+	var req models.CreateEventBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Validate each item individually so failures can be reported per index
+	results := make([]models.BatchResult, len(req.Events))
+	batch := make([]models.Event, len(req.Events))
+	invalid := 0
+	for i := range req.Events {
+		item := req.Events[i]
+		results[i].Index = i
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			results[i].Error = err.Error()
+			invalid++
+			continue
+		}
+		event, err := h.buildEvent(item, c.GetString("request_id"))
+		if err != nil {
+			results[i].Error = err.Error()
+			invalid++
+			continue
+		}
+		batch[i] = event
+	}
+
+	if req.Atomic {
+		h.createEventsAtomically(c, batch, results, invalid)
+		return
+	}
+
+	response := models.BatchResponse{Results: results}
+	serverError := false
+	for i := range batch {
+		if results[i].Error != "" {
+			response.Failed++
+			continue
+		}
+
+		if err := h.eventManager.PublishEvent(c.Request.Context(), batch[i]); err != nil {
+			if errors.Is(err, database.ErrSequenceConflict) {
+				results[i].Error = err.Error()
+				response.Failed++
+				continue
+			}
+			h.logger.WithError(err).WithField("index", i).Error("Failed to publish batch event")
+			results[i].Error = "Failed to create event"
+			response.Failed++
+			serverError = true
+			continue
+		}
+
+		results[i].Success = true
+		results[i].ID = batch[i].ID
+		response.Succeeded++
+	}
+
+	status := http.StatusCreated
+	switch {
+	case response.Succeeded > 0 && response.Failed > 0:
+		status = http.StatusMultiStatus
+	case response.Succeeded == 0 && serverError:
+		status = http.StatusInternalServerError
+	case response.Succeeded == 0:
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, models.APIResponse{
+		Success: response.Failed == 0,
+		Data:    response,
+	})
+}
+
+// createEventsAtomically creates every event in the batch or none of them
+func (h *Handler) createEventsAtomically(c *gin.Context, batch []models.Event, results []models.BatchResult, invalid int) {
+	if invalid > 0 {
+		for i := range results {
+			if results[i].Error == "" {
+				results[i].Error = "Not created: batch contains invalid events"
+			}
+		}
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Batch contains invalid events",
+			Data:    models.BatchResponse{Results: results, Failed: len(results)},
+		})
+		return
+	}
+
+	if err := h.eventManager.PublishEvents(c.Request.Context(), batch); err != nil {
+		if errors.Is(err, events.ErrBatchUnsupported) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Atomic batches are not supported by the configured event store",
+			})
+			return
+		}
+		if errors.Is(err, database.ErrSequenceConflict) {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to publish event batch")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to create events",
+		})
+		return
+	}
+
+	for i := range results {
+		results[i].Success = true
+		results[i].ID = batch[i].ID
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    models.BatchResponse{Results: results, Succeeded: len(results)},
+	})
+}
+
 // @Summary Get Events
-// @Description Get events from the system with pagination
+// @Description Get events from the system, newest first, with pagination. Pass the
+// @Description next_cursor of a page as cursor to get the next one; offset is ignored then.
 // @Tags events
 // @Produce json
 // @Param limit query int false "Number of events to return" default(50)
 // @Param offset query int false "Number of events to skip" default(0)
-// @Success 200 {object} models.APIResponse
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.APIResponse{data=models.PaginatedResponse}
+// @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/events [get]
 func (h *Handler) GetEvents(c *gin.Context) {
-	limit := 50
-	
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-			limit = parsedLimit
+	limit, offset := parsePagination(c)
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := decodeEventCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid cursor",
+			})
+			return
 		}
+		h.getEventsAfterCursor(c, cursor, limit)
+		return
 	}
 
-	eventStore := h.eventManager.GetStore()
-	events, err := eventStore.GetEvents(context.Background(), "", limit)
+	events, total, err := h.dbFor(c).GetEventsByTypeWithPagination("", offset, limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get events")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -75,10 +268,136 @@ func (h *Handler) GetEvents(c *gin.Context) {
 		})
 		return
 	}
+	h.eventManager.UpcastEvents(events)
 
+	page := models.NewPaginatedResponse(events, len(events), limit, offset, total)
+	if page.HasMore {
+		page.NextCursor = encodeEventCursor(events[len(events)-1])
+	}
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    events,
+		Data:    page,
+	})
+}
+
+// getEventsAfterCursor serves a page of GetEvents starting after cursor
+func (h *Handler) getEventsAfterCursor(c *gin.Context, cursor *database.EventCursor, limit int) {
+	// One extra event tells whether there is another page
+	events, total, err := h.dbFor(c).GetEventsAfterCursor(cursor, limit+1)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get events")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get events",
+		})
+		return
+	}
+
+	page := models.PaginatedResponse{Limit: limit, Total: total}
+	if len(events) > limit {
+		events = events[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeEventCursor(events[len(events)-1])
+	}
+	h.eventManager.UpcastEvents(events)
+	page.Items = events
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// eventCursor is the JSON inside an opaque GetEvents cursor
+type eventCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// encodeEventCursor returns the cursor for the page after event
+func encodeEventCursor(event models.Event) string {
+	data, _ := json.Marshal(eventCursor{Timestamp: event.Timestamp, ID: event.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeEventCursor(s string) (*database.EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor eventCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("cursor has no event ID")
+	}
+	return &database.EventCursor{Timestamp: cursor.Timestamp, ID: cursor.ID}, nil
+}
+
+// @Summary Search Events
+// @Description Get events across all streams within a time range, oldest first, optionally filtered by type and source
+// @Tags events
+// @Produce json
+// @Param from query string true "Start of the range, inclusive (RFC3339)"
+// @Param to query string true "End of the range, exclusive (RFC3339)"
+// @Param type query string false "Event type"
+// @Param source query string false "Event source"
+// @Param limit query int false "Number of events to return (max 1000)" default(50)
+// @Param offset query int false "Number of events to skip" default(0)
+// @Success 200 {object} models.APIResponse{data=models.PaginatedResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/search [get]
+func (h *Handler) SearchEvents(c *gin.Context) {
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "from is required and must be an RFC3339 timestamp",
+		})
+		return
+	}
+
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "to is required and must be an RFC3339 timestamp",
+		})
+		return
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "from must be before to",
+		})
+		return
+	}
+
+	search := database.EventSearch{
+		From:   from.UTC(),
+		To:     to.UTC(),
+		Type:   c.Query("type"),
+		Source: c.Query("source"),
+	}
+	limit, offset := parsePagination(c)
+
+	events, total, err := h.dbFor(c).SearchEvents(search, offset, limit)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to search events")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to search events",
+		})
+		return
+	}
+	h.eventManager.UpcastEvents(events)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.NewPaginatedResponse(events, len(events), limit, offset, total),
 	})
 }
 
@@ -88,21 +407,15 @@ func (h *Handler) GetEvents(c *gin.Context) {
 // @Produce json
 // @Param type path string true "Event type"
 // @Param limit query int false "Number of events to return" default(50)
-// @Success 200 {object} models.APIResponse
+// @Param offset query int false "Number of events to skip" default(0)
+// @Success 200 {object} models.APIResponse{data=models.PaginatedResponse}
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/events/types/{type} [get]
 func (h *Handler) GetEventsByType(c *gin.Context) {
 	eventType := c.Param("type")
-	limit := 50
-	
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-			limit = parsedLimit
-		}
-	}
+	limit, offset := parsePagination(c)
 
-	eventStore := h.eventManager.GetStore()
-	events, err := eventStore.GetEvents(context.Background(), eventType, limit)
+	events, total, err := h.dbFor(c).GetEventsByTypeWithPagination(eventType, offset, limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get events by type")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -111,10 +424,11 @@ func (h *Handler) GetEventsByType(c *gin.Context) {
 		})
 		return
 	}
+	h.eventManager.UpcastEvents(events)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    events,
+		Data:    models.NewPaginatedResponse(events, len(events), limit, offset, total),
 	})
 }
 
@@ -136,7 +450,7 @@ func (h *Handler) GetEventStreams(c *gin.Context) {
 	}
 
 	eventStore := h.eventManager.GetStore()
-	streamIDs, err := eventStore.GetEventStreams(context.Background(), limit)
+	streamIDs, err := eventStore.GetEventStreams(c.Request.Context(), limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get event streams")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -152,13 +466,71 @@ func (h *Handler) GetEventStreams(c *gin.Context) {
 	})
 }
 
+// @Summary Get Event Streams Summary
+// @Description Get event streams with their event counts and latest activity, most recent first
+// @Tags events
+// @Produce json
+// @Param limit query int false "Number of streams to return" default(50)
+// @Param offset query int false "Number of streams to skip" default(0)
+// @Success 200 {object} models.APIResponse{data=[]models.StreamSummary}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/streams/summary [get]
+func (h *Handler) GetEventStreamsSummary(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	summaries, err := h.dbFor(c).GetEventStreamsWithCounts(limit, offset)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get event stream summaries")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get event stream summaries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    summaries,
+	})
+}
+
+// @Summary Get Event Stream Cursors
+// @Description Get the highest sequence number of each stream, keyed by stream ID, for consumers tracking their position
+// @Tags events
+// @Produce json
+// @Param limit query int false "Number of streams to return" default(50)
+// @Param offset query int false "Number of streams to skip" default(0)
+// @Success 200 {object} models.APIResponse{data=map[string]int64}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/streams/cursors [get]
+func (h *Handler) GetEventStreamCursors(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	cursors, err := h.dbFor(c).GetStreamCursors(limit, offset)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get event stream cursors")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get event stream cursors",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    cursors,
+	})
+}
+
 // @Summary Get Events by Stream
 // @Description Get events from a specific stream
 // @Tags events
 // @Produce json
 // @Param stream_id path string true "Stream ID"
 // @Param limit query int false "Number of events to return" default(50)
+// @Param after_sequence query int false "Only return events with a greater sequence number, to resume from a checkpoint"
 // @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/events/streams/{stream_id} [get]
 func (h *Handler) GetEventsByStream(c *gin.Context) {
@@ -171,8 +543,21 @@ func (h *Handler) GetEventsByStream(c *gin.Context) {
 		}
 	}
 
-	eventStore := h.eventManager.GetStore()
-	events, err := eventStore.GetEventsByStream(context.Background(), streamID, limit)
+	var events []models.Event
+	var err error
+	if afterStr := c.Query("after_sequence"); afterStr != "" {
+		after, parseErr := strconv.ParseInt(afterStr, 10, 64)
+		if parseErr != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "after_sequence must be a non-negative integer",
+			})
+			return
+		}
+		events, err = h.db.GetEventsAfterSequence(c.Request.Context(), streamID, after, limit)
+	} else {
+		events, err = h.eventManager.GetStore().GetEventsByStream(c.Request.Context(), streamID, limit)
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get events by stream")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -181,6 +566,7 @@ func (h *Handler) GetEventsByStream(c *gin.Context) {
 		})
 		return
 	}
+	h.eventManager.UpcastEvents(events)
 
 	response := models.EventStreamResponse{
 		StreamID: streamID,
@@ -192,4 +578,350 @@ func (h *Handler) GetEventsByStream(c *gin.Context) {
 		Success: true,
 		Data:    response,
 	})
-}
\ No newline at end of file
+}
+
+// @Summary Redeliver Event
+// @Description Deliver a stored event again to every webhook subscribed to its type, in the background.
+// @Description Each delivery gets a new record with redelivery set.
+// @Tags events
+// @Produce json
+// @Param id path string true "Event ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/{id}/redeliver [post]
+func (h *Handler) RedeliverEvent(c *gin.Context) {
+	eventID := c.Param("id")
+
+	var event models.Event
+	if err := h.dbFor(c).First(&event, "id = ?", eventID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Event not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get event")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get event",
+		})
+		return
+	}
+
+	queued, err := h.eventManager.GetWebhookDeliveryService().RedeliverEvent(c.Request.Context(), event)
+	if err != nil {
+		h.logger.WithError(err).WithField("event_id", eventID).Error("Failed to redeliver event")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to redeliver event",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Event redelivery initiated",
+		Data: map[string]interface{}{
+			"event_id": eventID,
+			"queued":   queued,
+		},
+	})
+}
+
+// @Summary Delete Event Stream
+// @Description Delete all events in a stream and their webhook deliveries, e.g. for GDPR erasure (admin only)
+// @Tags events
+// @Produce json
+// @Param stream_id path string true "Stream ID"
+// @Param confirm query bool true "Must be true to delete the stream"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/streams/{stream_id} [delete]
+func (h *Handler) DeleteEventStream(c *gin.Context) {
+	streamID := c.Param("stream_id")
+
+	// Deletion can't be undone, so it has to be asked for explicitly
+	if c.Query("confirm") != "true" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Deleting a stream is permanent; repeat the request with confirm=true",
+		})
+		return
+	}
+
+	eventsDeleted, deliveriesDeleted, err := h.dbFor(c).DeleteEventsByStream(streamID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to delete event stream")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete event stream",
+		})
+		return
+	}
+
+	h.recordAudit(c, "stream.deleted", "event_stream", streamID, map[string]interface{}{
+		"events_deleted":     eventsDeleted,
+		"deliveries_deleted": deliveriesDeleted,
+	})
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Event stream deleted successfully",
+		Data: map[string]interface{}{
+			"stream_id":          streamID,
+			"events_deleted":     eventsDeleted,
+			"deliveries_deleted": deliveriesDeleted,
+		},
+	})
+}
+
+// @Summary Check Stream Integrity
+// @Description Report gaps in a stream's sequence numbers, e.g. left by a failed transaction. With EVENTS_GAP_ALERTS
+// @Description set, finding gaps not reported before also publishes a stream.gap_detected event.
+// @Tags events
+// @Produce json
+// @Param stream_id path string true "Stream ID"
+// @Success 200 {object} models.APIResponse{data=models.StreamIntegrity}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/streams/{stream_id}/integrity [get]
+func (h *Handler) GetStreamIntegrity(c *gin.Context) {
+	streamID := c.Param("stream_id")
+
+	var summary struct {
+		EventCount     int64
+		LatestSequence int64
+	}
+	err := h.dbFor(c).Model(&models.Event{}).
+		Select("count(*) as event_count, coalesce(max(sequence_number), 0) as latest_sequence").
+		Where("stream_id = ?", streamID).
+		Scan(&summary).Error
+	if err != nil {
+		h.logger.WithError(err).WithField("stream_id", streamID).Error("Failed to get stream summary")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to check stream integrity",
+		})
+		return
+	}
+	if summary.EventCount == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Stream not found",
+		})
+		return
+	}
+
+	gaps, err := h.dbFor(c).DetectSequenceGaps(streamID)
+	if err != nil {
+		h.logger.WithError(err).WithField("stream_id", streamID).Error("Failed to detect sequence gaps")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to check stream integrity",
+		})
+		return
+	}
+
+	if h.config.Events.GapAlerts && h.claimNewGaps(streamID, gaps) {
+		h.publishGapAlert(c.Request.Context(), streamID, gaps)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: models.StreamIntegrity{
+			StreamID:       streamID,
+			EventCount:     summary.EventCount,
+			LatestSequence: summary.LatestSequence,
+			Intact:         len(gaps) == 0,
+			Gaps:           gaps,
+		},
+	})
+}
+
+const (
+	// gapAlertStreamID and gapAlertSource identify stream.gap_detected events
+	gapAlertStreamID = "stream-integrity"
+	gapAlertSource   = "integrity-check"
+)
+
+// claimNewGaps records gaps as the stream's current gaps and reports whether
+// any of them hasn't been alerted on yet. Gaps that have since been filled
+// are forgotten, so they alert again if they reappear.
+func (h *Handler) claimNewGaps(streamID string, gaps []models.SequenceGap) bool {
+	h.reportedGapsMu.Lock()
+	defer h.reportedGapsMu.Unlock()
+
+	if len(gaps) == 0 {
+		delete(h.reportedGaps, streamID)
+		return false
+	}
+
+	reported := make(map[models.SequenceGap]bool, len(h.reportedGaps[streamID]))
+	for _, gap := range h.reportedGaps[streamID] {
+		reported[gap] = true
+	}
+	newGaps := false
+	for _, gap := range gaps {
+		if !reported[gap] {
+			newGaps = true
+			break
+		}
+	}
+
+	if h.reportedGaps == nil {
+		h.reportedGaps = make(map[string][]models.SequenceGap)
+	}
+	h.reportedGaps[streamID] = gaps
+	return newGaps
+}
+
+// publishGapAlert publishes a stream.gap_detected event to the
+// stream-integrity stream, so the gapped stream itself isn't written to.
+// Failing to publish is logged and the gaps forgotten so the next check
+// retries; the integrity report is still returned.
+func (h *Handler) publishGapAlert(ctx context.Context, streamID string, gaps []models.SequenceGap) {
+	ranges := make([]map[string]interface{}, 0, len(gaps))
+	for _, gap := range gaps {
+		ranges = append(ranges, map[string]interface{}{"from": gap.From, "to": gap.To})
+	}
+
+	err := h.eventManager.Publish(ctx, gapAlertStreamID, "stream.gap_detected", gapAlertSource, map[string]interface{}{
+		"stream_id": streamID,
+		"gaps":      ranges,
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("stream_id", streamID).Warn("Failed to publish sequence gap alert")
+		h.reportedGapsMu.Lock()
+		delete(h.reportedGaps, streamID)
+		h.reportedGapsMu.Unlock()
+	}
+}
+
+// @Summary Export Event Stream
+// @Description Download every event in a stream, in sequence order, as a JSON array or CSV
+// @Tags events
+// @Produce json,text/csv
+// @Param stream_id path string true "Stream ID"
+// @Param format query string false "Export format" Enums(json, csv) default(json)
+// @Success 200 {file} file
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/events/streams/{stream_id}/export [get]
+func (h *Handler) ExportEventStream(c *gin.Context) {
+	streamID := c.Param("stream_id")
+	format := c.DefaultQuery("format", "json")
+
+	var exporter eventExporter
+	switch format {
+	case "json":
+		c.Header("Content-Type", "application/json")
+		exporter = newJSONEventExporter(c.Writer)
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		exporter = newCSVEventExporter(c.Writer)
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "format must be json or csv",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": streamID + "-events." + format,
+	}))
+	c.Status(http.StatusOK)
+
+	// Exported in the same upcast shape as the list endpoints return
+	err := h.db.ForEachEventInStream(c.Request.Context(), streamID, func(event models.Event) error {
+		h.eventManager.UpcastEvent(&event)
+		return exporter.Write(event)
+	})
+	if err == nil {
+		err = exporter.Close()
+	}
+	if err != nil {
+		// The response has started, so the client sees a truncated download
+		h.logger.WithError(err).WithField("stream_id", streamID).Error("Failed to export event stream")
+	}
+}
+
+// buildEvent creates an event from a request, applying and validating any
+// client-supplied timestamp. The ID of the request creating the event is
+// recorded in its metadata so webhook deliveries can be traced back to it.
+func (h *Handler) buildEvent(req models.CreateEventRequest, requestID string) (models.Event, error) {
+	if !h.sourceAllowed(req.Source) {
+		return models.Event{}, fmt.Errorf("%w: %s", errSourceNotAllowed, req.Source)
+	}
+
+	event := events.NewEvent(req.StreamID, req.Type, req.Source, req.Data)
+	if req.Metadata != nil {
+		event.Metadata = models.JSON(req.Metadata)
+	}
+	if req.SchemaVersion > 0 {
+		event.SchemaVersion = req.SchemaVersion
+	}
+	event.ExpectedSequence = req.ExpectedSequence
+	if requestID != "" {
+		if event.Metadata == nil {
+			event.Metadata = models.JSON{}
+		}
+		if _, ok := event.Metadata["request_id"]; !ok {
+			event.Metadata["request_id"] = requestID
+		}
+	}
+
+	if req.Timestamp != nil {
+		maxSkew := time.Duration(h.config.Events.MaxFutureSkewSeconds) * time.Second
+		if req.Timestamp.After(time.Now().Add(maxSkew)) {
+			return models.Event{}, fmt.Errorf("timestamp is more than %s in the future", maxSkew)
+		}
+		// Stored in UTC, as time range queries compare timestamps as text
+		// on SQLite and would misplace other offsets
+		event.Timestamp = req.Timestamp.UTC()
+	}
+
+	return event, nil
+}
+
+// errSourceNotAllowed rejects events from a source missing from
+// EVENTS_ALLOWED_SOURCES
+var errSourceNotAllowed = errors.New("event source is not allowed")
+
+// sourceAllowed reports whether events may be created with source; any
+// source is allowed when no allowlist is configured
+func (h *Handler) sourceAllowed(source string) bool {
+	allowed := h.config.Events.AllowedSources
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, s := range allowed {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudEventToRequest maps a CloudEvents envelope onto a CreateEventRequest;
+// the reverse of the envelope built for application/cloudevents+json webhooks.
+// The event gets its own ID; the caller records the CloudEvent's as its
+// CloudEventID.
+func cloudEventToRequest(ce models.CloudEventRequest) models.CreateEventRequest {
+	req := models.CreateEventRequest{
+		Type:          ce.Type,
+		StreamID:      ce.Subject,
+		Source:        ce.Source,
+		Data:          ce.Data,
+		SchemaVersion: ce.SchemaVersion,
+		Timestamp:     ce.Time,
+	}
+	if ce.CorrelationID != "" {
+		req.Metadata = map[string]interface{}{"correlation_id": ce.CorrelationID}
+	}
+	return req
+}
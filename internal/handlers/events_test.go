@@ -3,14 +3,21 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"goapitemplate/internal/config"
 	"goapitemplate/internal/database"
 	"goapitemplate/internal/events"
+	"goapitemplate/internal/middleware"
 	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
@@ -29,20 +36,33 @@ func setupTestHandler(t *testing.T) (*Handler, *database.DB) {
 	})
 	require.NoError(t, err)
 
+	// Each :memory: connection is a separate database, so background
+	// deliveries must share the migrated one
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
 	db := &database.DB{DB: gormDB}
 	err = db.AutoMigrate()
 	require.NoError(t, err)
 
 	// Create event manager
 	eventStore := events.NewDBEventStore(db)
-	eventManager := events.NewManager(eventStore, db)
+	logger := logrus.New()
+	eventManager := events.NewManager(eventStore, db, logger)
 
 	// Create handler
 	handler := &Handler{
 		db:           db,
 		cache:        &MockCacheClient{}, // Mock cache client
 		eventManager: eventManager,
-		logger:       logrus.New(),
+		config: &config.Config{
+			Admin:  config.AdminConfig{APIKey: "test-admin-key"},
+			Events: config.EventsConfig{Backend: "db", MaxFutureSkewSeconds: 86400},
+			// Tests deliver to httptest receivers on loopback addresses
+			Webhook: config.WebhookDeliveryConfig{AllowPrivate: true},
+		},
+		logger: logger,
 	}
 
 	return handler, db
@@ -135,6 +155,548 @@ func TestCreateEvent(t *testing.T) {
 	}
 }
 
+type requestIDKey struct{}
+
+// contextRecordingStore records the context each save runs with
+type contextRecordingStore struct {
+	events.EventStore
+	saved chan context.Context
+}
+
+func (s *contextRecordingStore) SaveEvent(ctx context.Context, event *models.Event) error {
+	s.saved <- ctx
+	return s.EventStore.SaveEvent(ctx, event)
+}
+
+func TestCreateEventPropagatesRequestContext(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	store := &contextRecordingStore{EventStore: events.NewDBEventStore(db), saved: make(chan context.Context, 1)}
+	handler.eventManager = events.NewManager(store, db, handler.logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, "req-42"))
+		c.Next()
+	})
+	router.POST("/events", handler.CreateEvent)
+
+	payload := `{"type": "user.created", "stream_id": "user-1", "source": "test"}`
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	select {
+	case ctx := <-store.saved:
+		assert.Equal(t, "req-42", ctx.Value(requestIDKey{}))
+	default:
+		t.Fatal("event was not saved")
+	}
+
+	t.Run("cancelled request is not persisted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, "POST", "/events", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		var count int64
+		require.NoError(t, db.Model(&models.Event{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestCreateEventWithTimestamp(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+
+	backfilled := time.Date(2021, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		timestamp    time.Time
+		expectedCode int
+	}{
+		{
+			name:         "backfill with past timestamp",
+			timestamp:    backfilled,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "timestamp within skew tolerance",
+			timestamp:    time.Now().Add(23 * time.Hour),
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "timestamp too far in the future",
+			timestamp:    time.Now().Add(25 * time.Hour),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(map[string]interface{}{
+				"type":      "order.created",
+				"stream_id": "order-123",
+				"source":    "importer",
+				"timestamp": tt.timestamp,
+			})
+
+			req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var savedEvents []models.Event
+			err := db.Find(&savedEvents).Error
+			require.NoError(t, err)
+
+			if tt.expectedCode == http.StatusCreated {
+				require.Len(t, savedEvents, 1)
+				assert.True(t, tt.timestamp.Equal(savedEvents[0].Timestamp), "expected %v, got %v", tt.timestamp, savedEvents[0].Timestamp)
+				// CreatedAt records the insertion, not the logical event time
+				assert.WithinDuration(t, time.Now(), savedEvents[0].CreatedAt, time.Minute)
+			} else {
+				assert.Len(t, savedEvents, 0)
+			}
+
+			// Clean up for next test
+			db.Exec("DELETE FROM events")
+		})
+	}
+
+	t.Run("timestamp with a UTC offset is found by time range", func(t *testing.T) {
+		// 10:00 at +02:00 is 08:00 UTC
+		payload := `{"type": "order.created", "stream_id": "order-123", "source": "importer", "timestamp": "2026-01-01T10:00:00+02:00"}`
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		found, total, err := db.SearchEvents(database.EventSearch{
+			From: time.Date(2026, time.January, 1, 7, 30, 0, 0, time.UTC),
+			To:   time.Date(2026, time.January, 1, 8, 30, 0, 0, time.UTC),
+		}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, found, 1)
+		assert.True(t, time.Date(2026, time.January, 1, 8, 0, 0, 0, time.UTC).Equal(found[0].Timestamp))
+	})
+}
+
+func TestCreateEventWithMetadata(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+	router.GET("/events/streams/:stream_id", handler.GetEventsByStream)
+
+	metadata := map[string]interface{}{
+		"correlation_id": "corr-123",
+		"causation_id":   "cause-456",
+	}
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"type":      "order.created",
+		"stream_id": "order-123",
+		"source":    "order-service",
+		"data":      map[string]interface{}{"order_id": 1},
+		"metadata":  metadata,
+	})
+
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var saved models.Event
+	err := db.First(&saved, "stream_id = ?", "order-123").Error
+	require.NoError(t, err)
+	assert.Equal(t, models.JSON(metadata), saved.Metadata)
+
+	req, _ = http.NewRequest("GET", "/events/streams/order-123", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.EventStreamResponse `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data.Events, 1)
+	assert.Equal(t, models.JSON(metadata), response.Data.Events[0].Metadata)
+}
+
+func TestCreateEventFromCloudEvent(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+
+	eventTime := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	payloadBytes, _ := json.Marshal(map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "ce-123",
+		"type":            "order.created",
+		"source":          "order-service",
+		"subject":         "order-123",
+		"time":            eventTime.Format(time.RFC3339),
+		"datacontenttype": "application/json",
+		"data":            map[string]interface{}{"order_id": "1"},
+		"schemaversion":   2,
+		"correlationid":   "corr-123",
+	})
+
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+	req.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var saved models.Event
+	err := db.First(&saved, "stream_id = ?", "order-123").Error
+	require.NoError(t, err)
+	assert.NotEqual(t, "ce-123", saved.ID)
+	require.NotNil(t, saved.CloudEventID)
+	assert.Equal(t, "ce-123", *saved.CloudEventID)
+	assert.Equal(t, "order.created", saved.Type)
+	assert.Equal(t, "order-service", saved.Source)
+	assert.Equal(t, "order-123", saved.StreamID)
+	assert.Equal(t, models.JSON{"order_id": "1"}, saved.Data)
+	assert.Equal(t, 2, saved.SchemaVersion)
+	assert.Equal(t, "corr-123", saved.Metadata["correlation_id"])
+	assert.True(t, eventTime.Equal(saved.Timestamp))
+
+	// The same CloudEvent sent again is a duplicate
+	req, _ = http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	// Metadata doesn't make a plain event a CloudEvent
+	req, _ = http.NewRequest("POST", "/events", bytes.NewBufferString(
+		`{"type": "order.created", "stream_id": "order-456", "source": "billing-service", "metadata": {"cloudevent_id": "ce-123"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// The same id from another source is a different CloudEvent
+	payloadBytes, _ = json.Marshal(map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "ce-123",
+		"type":        "order.created",
+		"source":      "billing-service",
+		"subject":     "order-123",
+	})
+	req, _ = http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var count int64
+	require.NoError(t, db.Model(&models.Event{}).Where("stream_id = ?", "order-123").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	tests := []struct {
+		name  string
+		event map[string]interface{}
+	}{
+		{
+			name:  "missing subject",
+			event: map[string]interface{}{"specversion": "1.0", "id": "ce-2", "type": "order.created", "source": "order-service"},
+		},
+		{
+			name:  "unsupported spec version",
+			event: map[string]interface{}{"specversion": "0.3", "id": "ce-3", "type": "order.created", "source": "order-service", "subject": "order-123"},
+		},
+		{
+			name:  "non-JSON data content type",
+			event: map[string]interface{}{"specversion": "1.0", "id": "ce-4", "type": "order.created", "source": "order-service", "subject": "order-123", "datacontenttype": "text/plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(tt.event)
+			req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+			req.Header.Set("Content-Type", "application/cloudevents+json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestCreateEventExpectedSequence(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+
+	send := func(expected int64) (int, models.APIResponse) {
+		body := map[string]interface{}{
+			"type":      "account.credited",
+			"stream_id": "account-1",
+			"source":    "ledger",
+			"data":      map[string]interface{}{"amount": 10},
+		}
+		if expected > 0 {
+			body["expected_sequence"] = expected
+		}
+		payloadBytes, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.APIResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	code, _ := send(1)
+	require.Equal(t, http.StatusCreated, code)
+	code, _ = send(2)
+	require.Equal(t, http.StatusCreated, code)
+
+	// Another writer appended event 2 first, so a second append expecting 2 loses
+	code, response := send(2)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.False(t, response.Success)
+	assert.Contains(t, response.Error, "expected sequence 2")
+
+	// Expecting a sequence ahead of the stream conflicts too
+	code, _ = send(5)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// Without expected_sequence appends are unconditional
+	code, _ = send(0)
+	assert.Equal(t, http.StatusCreated, code)
+
+	var sequences []int64
+	require.NoError(t, db.Model(&models.Event{}).Where("stream_id = ?", "account-1").
+		Order("sequence_number").Pluck("sequence_number", &sequences).Error)
+	assert.Equal(t, []int64{1, 2, 3}, sequences)
+}
+
+func TestEventSchemaUpcasting(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// v2 of user.registered splits name into first and last name
+	handler.eventManager.RegisterUpcaster("user.registered", 2, func(data models.JSON, fromVersion int) models.JSON {
+		name, _ := data["name"].(string)
+		first, last, _ := strings.Cut(name, " ")
+		return models.JSON{"first_name": first, "last_name": last}
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+	router.GET("/events", handler.GetEvents)
+	router.GET("/events/streams/:stream_id/export", handler.ExportEventStream)
+
+	post := func(payload map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	post(map[string]interface{}{
+		"type":      "user.registered",
+		"stream_id": "user-1",
+		"source":    "user-service",
+		"data":      map[string]interface{}{"name": "Ada Lovelace"},
+	})
+	post(map[string]interface{}{
+		"type":           "user.registered",
+		"stream_id":      "user-2",
+		"source":         "user-service",
+		"data":           map[string]interface{}{"first_name": "Alan", "last_name": "Turing"},
+		"schema_version": 2,
+	})
+
+	// The old event is stored as written
+	var stored models.Event
+	require.NoError(t, db.First(&stored, "stream_id = ?", "user-1").Error)
+	assert.Equal(t, 1, stored.SchemaVersion)
+	assert.Equal(t, models.JSON{"name": "Ada Lovelace"}, stored.Data)
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Items []models.Event `json:"items"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data.Items, 2)
+
+	byStream := make(map[string]models.Event)
+	for _, event := range response.Data.Items {
+		byStream[event.StreamID] = event
+	}
+	assert.Equal(t, 2, byStream["user-1"].SchemaVersion)
+	assert.Equal(t, models.JSON{"first_name": "Ada", "last_name": "Lovelace"}, byStream["user-1"].Data)
+	assert.Equal(t, 2, byStream["user-2"].SchemaVersion)
+	assert.Equal(t, models.JSON{"first_name": "Alan", "last_name": "Turing"}, byStream["user-2"].Data)
+
+	// Exports return the same shape
+	req, _ = http.NewRequest("GET", "/events/streams/user-1/export", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var exported []models.Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	require.Len(t, exported, 1)
+	assert.Equal(t, 2, exported[0].SchemaVersion)
+	assert.Equal(t, models.JSON{"first_name": "Ada", "last_name": "Lovelace"}, exported[0].Data)
+}
+
+func TestCreateEventsBatch(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events/batch", handler.CreateEventsBatch)
+
+	// The second event is missing its required source
+	mixedEvents := []map[string]interface{}{
+		{"type": "order.created", "stream_id": "order-1", "source": "orders", "data": map[string]interface{}{"n": 1}},
+		{"type": "order.created", "stream_id": "order-2"},
+		{"type": "order.shipped", "stream_id": "order-1", "source": "orders"},
+	}
+
+	tests := []struct {
+		name            string
+		atomic          bool
+		expectedCode    int
+		expectedSuccess []bool
+		expectedSaved   int
+	}{
+		{
+			name:            "best-effort creates valid events",
+			atomic:          false,
+			expectedCode:    http.StatusMultiStatus,
+			expectedSuccess: []bool{true, false, true},
+			expectedSaved:   2,
+		},
+		{
+			name:            "atomic rejects the whole batch",
+			atomic:          true,
+			expectedCode:    http.StatusBadRequest,
+			expectedSuccess: []bool{false, false, false},
+			expectedSaved:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(map[string]interface{}{
+				"events": mixedEvents,
+				"atomic": tt.atomic,
+			})
+
+			req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBuffer(payloadBytes))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var response models.APIResponse
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.False(t, response.Success)
+
+			dataBytes, _ := json.Marshal(response.Data)
+			var batch models.BatchResponse
+			err = json.Unmarshal(dataBytes, &batch)
+			require.NoError(t, err)
+			require.Len(t, batch.Results, len(mixedEvents))
+
+			for i, result := range batch.Results {
+				assert.Equal(t, i, result.Index)
+				assert.Equal(t, tt.expectedSuccess[i], result.Success)
+				if result.Success {
+					assert.NotEmpty(t, result.ID)
+					assert.Empty(t, result.Error)
+				} else {
+					assert.Empty(t, result.ID)
+					assert.NotEmpty(t, result.Error)
+				}
+			}
+
+			var savedEvents []models.Event
+			err = db.Find(&savedEvents).Error
+			require.NoError(t, err)
+			assert.Len(t, savedEvents, tt.expectedSaved)
+
+			// Clean up for next test
+			db.Exec("DELETE FROM events")
+		})
+	}
+
+	t.Run("atomic creates all valid events", func(t *testing.T) {
+		payloadBytes, _ := json.Marshal(map[string]interface{}{
+			"events": []map[string]interface{}{mixedEvents[0], mixedEvents[2]},
+			"atomic": true,
+		})
+
+		req, _ := http.NewRequest("POST", "/events/batch", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var savedEvents []models.Event
+		err := db.Order("sequence_number ASC").Find(&savedEvents, "stream_id = ?", "order-1").Error
+		require.NoError(t, err)
+		require.Len(t, savedEvents, 2)
+		assert.Equal(t, int64(1), savedEvents[0].SequenceNumber)
+		assert.Equal(t, int64(2), savedEvents[1].SequenceNumber)
+	})
+}
+
 func TestGetEvents(t *testing.T) {
 	handler, db := setupTestHandler(t)
 	defer db.Close()
@@ -169,10 +731,11 @@ func TestGetEvents(t *testing.T) {
 	router.GET("/events", handler.GetEvents)
 
 	tests := []struct {
-		name         string
-		queryParams  string
-		expectedCode int
-		expectedLen  int
+		name            string
+		queryParams     string
+		expectedCode    int
+		expectedLen     int
+		expectedHasMore bool
 	}{
 		{
 			name:         "get all events",
@@ -181,8 +744,15 @@ func TestGetEvents(t *testing.T) {
 			expectedLen:  2,
 		},
 		{
-			name:         "get events with limit",
-			queryParams:  "?limit=1",
+			name:            "get events with limit",
+			queryParams:     "?limit=1",
+			expectedCode:    http.StatusOK,
+			expectedLen:     1,
+			expectedHasMore: true,
+		},
+		{
+			name:         "get events with offset",
+			queryParams:  "?limit=1&offset=1",
 			expectedCode: http.StatusOK,
 			expectedLen:  1,
 		},
@@ -210,11 +780,15 @@ func TestGetEvents(t *testing.T) {
 			
 			// Verify response data
 			dataBytes, _ := json.Marshal(response.Data)
+			var page models.PaginatedResponse
 			var responseEvents []models.Event
-			err = json.Unmarshal(dataBytes, &responseEvents)
+			page.Items = &responseEvents
+			err = json.Unmarshal(dataBytes, &page)
 			require.NoError(t, err)
-			
+
 			assert.Len(t, responseEvents, tt.expectedLen)
+			assert.Equal(t, int64(2), page.Total)
+			assert.Equal(t, tt.expectedHasMore, page.HasMore)
 		})
 	}
 }
@@ -301,11 +875,15 @@ func TestGetEventsByType(t *testing.T) {
 			assert.True(t, response.Success)
 			
 			dataBytes, _ := json.Marshal(response.Data)
+			var page models.PaginatedResponse
 			var responseEvents []models.Event
-			err = json.Unmarshal(dataBytes, &responseEvents)
+			page.Items = &responseEvents
+			err = json.Unmarshal(dataBytes, &page)
 			require.NoError(t, err)
-			
+
 			assert.Len(t, responseEvents, tt.expectedLen)
+			assert.Equal(t, int64(tt.expectedLen), page.Total)
+			assert.False(t, page.HasMore)
 
 			// Verify all returned events have the correct type
 			for _, event := range responseEvents {
@@ -477,16 +1055,467 @@ func TestGetEventStreams(t *testing.T) {
 	assert.Contains(t, streamIDs, "stream-beta")
 }
 
-// MockCacheClient implements cache.Client interface for testing
-type MockCacheClient struct{}
+func TestGetEventStreamsSummary(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
 
-func (m *MockCacheClient) Get(ctx context.Context, key string) (string, error) {
-	return "", nil
-}
+	now := time.Now().UTC()
+	events := []models.Event{
+		{ID: "event-1", Type: "user.created", StreamID: "stream-alpha", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-3 * time.Hour)},
+		{ID: "event-2", Type: "user.updated", StreamID: "stream-alpha", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "event-3", Type: "user.created", StreamID: "stream-beta", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-1 * time.Hour)},
+		{ID: "event-4", Type: "user.created", StreamID: "stream-gamma", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-5 * time.Hour)},
+		{ID: "event-5", Type: "user.updated", StreamID: "stream-gamma", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-4 * time.Hour)},
+		{ID: "event-6", Type: "user.deleted", StreamID: "stream-gamma", Source: "test", Data: models.JSON{}, Timestamp: now.Add(-4 * time.Hour)},
+	}
 
-func (m *MockCacheClient) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
-	return nil
-}
+	for _, event := range events {
+		err := db.CreateEventWithSequence(&event)
+		require.NoError(t, err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/streams/summary", handler.GetEventStreamsSummary)
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedStreams []string
+		expectedCounts  []int64
+	}{
+		{
+			name:            "all streams ordered by latest activity",
+			query:           "",
+			expectedStreams: []string{"stream-beta", "stream-alpha", "stream-gamma"},
+			expectedCounts:  []int64{1, 2, 3},
+		},
+		{
+			name:            "paginated",
+			query:           "?limit=1&offset=1",
+			expectedStreams: []string{"stream-alpha"},
+			expectedCounts:  []int64{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/events/streams/summary"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Success bool                   `json:"success"`
+				Data    []models.StreamSummary `json:"data"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.True(t, response.Success)
+
+			require.Len(t, response.Data, len(tt.expectedStreams))
+			for i, summary := range response.Data {
+				assert.Equal(t, tt.expectedStreams[i], summary.StreamID)
+				assert.Equal(t, tt.expectedCounts[i], summary.EventCount)
+			}
+		})
+	}
+
+	// Latest timestamp reflects the newest event in each stream
+	summaries, err := db.GetEventStreamsWithCounts(10, 0)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.WithinDuration(t, now.Add(-2*time.Hour), summaries[1].LatestTimestamp, time.Second)
+	assert.WithinDuration(t, now.Add(-4*time.Hour), summaries[2].LatestTimestamp, time.Second)
+}
+
+func TestSearchEvents(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	base := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	for i, seed := range []struct {
+		source    string
+		eventType string
+		offset    time.Duration
+	}{
+		{"billing", "invoice.created", -time.Hour},
+		{"billing", "invoice.created", 0},
+		{"accounts", "user.created", 30 * time.Minute},
+		{"billing", "invoice.paid", time.Hour},
+		{"accounts", "user.created", 3 * time.Hour},
+	} {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      seed.eventType,
+			StreamID:  fmt.Sprintf("stream-%d", i%2),
+			Source:    seed.source,
+			Data:      models.JSON{},
+			Timestamp: base.Add(seed.offset),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/search", handler.SearchEvents)
+
+	window := "from=2024-01-01T12:00:00Z&to=2024-01-01T14:00:00Z"
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedIDs   []string
+		expectedTotal int64
+	}{
+		{
+			name:          "time range across streams",
+			query:         window,
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []string{"event-1", "event-2", "event-3"},
+			expectedTotal: 3,
+		},
+		{
+			name:          "source filter",
+			query:         window + "&source=billing",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []string{"event-1", "event-3"},
+			expectedTotal: 2,
+		},
+		{
+			name:          "type and source combined",
+			query:         window + "&source=billing&type=invoice.paid",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []string{"event-3"},
+			expectedTotal: 1,
+		},
+		{
+			name:          "paginated",
+			query:         window + "&limit=1&offset=1",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []string{"event-2"},
+			expectedTotal: 3,
+		},
+		{
+			name:         "missing from",
+			query:        "to=2024-01-01T14:00:00Z",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid to",
+			query:        "from=2024-01-01T12:00:00Z&to=tomorrow",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "from after to",
+			query:        "from=2024-01-01T14:00:00Z&to=2024-01-01T12:00:00Z",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/events/search?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Items []models.Event `json:"items"`
+					Total int64          `json:"total"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			var ids []string
+			for _, event := range response.Data.Items {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedTotal, response.Data.Total)
+		})
+	}
+
+	t.Run("query runs with the request context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/events/search?"+window, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetEventStreamCursors(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	streams := map[string]int{"stream-alpha": 3, "stream-beta": 1, "stream-gamma": 2}
+	for streamID, length := range streams {
+		for i := 0; i < length; i++ {
+			event := models.Event{
+				ID:       fmt.Sprintf("%s-%d", streamID, i),
+				Type:     "user.created",
+				StreamID: streamID,
+				Source:   "test",
+				Data:     models.JSON{},
+			}
+			require.NoError(t, db.CreateEventWithSequence(&event))
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/streams/cursors", handler.GetEventStreamCursors)
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedCursors map[string]int64
+	}{
+		{
+			name:            "all streams",
+			query:           "",
+			expectedCursors: map[string]int64{"stream-alpha": 3, "stream-beta": 1, "stream-gamma": 2},
+		},
+		{
+			name:            "paginated by stream ID",
+			query:           "?limit=1&offset=1",
+			expectedCursors: map[string]int64{"stream-beta": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/events/streams/cursors"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Success bool             `json:"success"`
+				Data    map[string]int64 `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			assert.True(t, response.Success)
+			assert.Equal(t, tt.expectedCursors, response.Data)
+		})
+	}
+}
+
+func TestExportEventStream(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	for i, streamID := range []string{"order-1", "order-1", "order-1", "order-2"} {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      "order.updated",
+			StreamID:  streamID,
+			Source:    "order-service",
+			Data:      models.JSON{"step": i},
+			Metadata:  models.JSON{"correlation_id": "corr-1"},
+			Timestamp: time.Now(),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/streams/:stream_id/export", handler.ExportEventStream)
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/events/streams/order-1/export"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("json", func(t *testing.T) {
+		w := export("?format=json")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=order-1-events.json`, w.Header().Get("Content-Disposition"))
+
+		var exported []models.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+		require.Len(t, exported, 3)
+		for i, event := range exported {
+			assert.Equal(t, fmt.Sprintf("event-%d", i), event.ID)
+			assert.Equal(t, int64(i+1), event.SequenceNumber)
+			assert.Equal(t, "corr-1", event.Metadata["correlation_id"])
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		w := export("?format=csv")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=order-1-events.csv`, w.Header().Get("Content-Disposition"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+
+		// Columns are named after the event's JSON fields
+		eventFields := []string{}
+		eventType := reflect.TypeOf(models.Event{})
+		for i := 0; i < eventType.NumField(); i++ {
+			name := strings.Split(eventType.Field(i).Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			eventFields = append(eventFields, name)
+		}
+		assert.ElementsMatch(t, eventFields, records[0])
+
+		assert.Equal(t, "event-0", records[1][0])
+		assert.Equal(t, `{"step":0}`, records[1][4])
+		assert.Equal(t, "3", records[3][7])
+	})
+
+	t.Run("empty stream", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/events/streams/missing/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		w := export("?format=xml")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestDeleteEventStream(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// Create events in the target stream and another stream
+	events := []models.Event{
+		{ID: "event-1", Type: "user.created", StreamID: "user-123", Source: "test", Data: models.JSON{"n": 1}},
+		{ID: "event-2", Type: "user.updated", StreamID: "user-123", Source: "test", Data: models.JSON{"n": 2}},
+		{ID: "event-3", Type: "user.created", StreamID: "user-456", Source: "test", Data: models.JSON{"n": 3}},
+	}
+	for _, event := range events {
+		err := db.CreateEventWithSequence(&event)
+		require.NoError(t, err)
+	}
+
+	webhook := models.WebhookEndpoint{
+		ID:         "webhook-1",
+		Name:       "Test Webhook",
+		URL:        "http://example.com/webhook",
+		Secret:     "secret",
+		EventTypes: []string{"user.created"},
+		Enabled:    true,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	deliveries := []models.WebhookDelivery{
+		{ID: "delivery-1", WebhookID: webhook.ID, EventID: "event-1", Status: "success"},
+		{ID: "delivery-2", WebhookID: webhook.ID, EventID: "event-3", Status: "success"},
+	}
+	for _, delivery := range deliveries {
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.DELETE("/events/streams/:stream_id", middleware.AdminAuth(handler.config.Admin.APIKey), handler.DeleteEventStream)
+
+	t.Run("rejects requests without admin key", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/events/streams/user-123", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		var count int64
+		db.Model(&models.Event{}).Where("stream_id = ?", "user-123").Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("requires confirmation", func(t *testing.T) {
+		for _, query := range []string{"", "?confirm=false", "?confirm=yes"} {
+			req, _ := http.NewRequest("DELETE", "/events/streams/user-123"+query, nil)
+			req.Header.Set("X-Admin-Key", "test-admin-key")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "query %q", query)
+		}
+
+		var count int64
+		db.Model(&models.Event{}).Where("stream_id = ?", "user-123").Count(&count)
+		assert.Equal(t, int64(2), count)
+		db.Model(&models.WebhookDelivery{}).Where("event_id = ?", "event-1").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("deletes stream events and deliveries", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/events/streams/user-123?confirm=true", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.APIResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.True(t, response.Success)
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, float64(2), data["events_deleted"])
+		assert.Equal(t, float64(1), data["deliveries_deleted"])
+
+		// Target stream and its deliveries are gone
+		var count int64
+		db.Model(&models.Event{}).Where("stream_id = ?", "user-123").Count(&count)
+		assert.Equal(t, int64(0), count)
+		db.Model(&models.WebhookDelivery{}).Where("event_id = ?", "event-1").Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		// Other stream is untouched
+		db.Model(&models.Event{}).Where("stream_id = ?", "user-456").Count(&count)
+		assert.Equal(t, int64(1), count)
+		db.Model(&models.WebhookDelivery{}).Where("event_id = ?", "event-3").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		// Audit record was written
+		var audit models.AuditLog
+		err = db.First(&audit, "resource_id = ?", "user-123").Error
+		require.NoError(t, err)
+		assert.Equal(t, "stream.deleted", audit.Action)
+	})
+}
+
+// MockCacheClient implements cache.Client interface for testing
+type MockCacheClient struct{}
+
+func (m *MockCacheClient) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (m *MockCacheClient) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return nil
+}
 
 func (m *MockCacheClient) Delete(ctx context.Context, key string) error {
 	return nil
@@ -494,4 +1523,450 @@ func (m *MockCacheClient) Delete(ctx context.Context, key string) error {
 
 func (m *MockCacheClient) Close() error {
 	return nil
-}
\ No newline at end of file
+}
+func TestIngestEventsNDJSON(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events/stream-ingest", handler.IngestEvents)
+
+	// Stream the body through a pipe so it is never held as a whole
+	reader, writer := io.Pipe()
+	go func() {
+		encoder := json.NewEncoder(writer)
+		for i := 0; i < 100; i++ {
+			encoder.Encode(models.CreateEventRequest{
+				Type:     "order.created",
+				StreamID: fmt.Sprintf("order-%d", i%4),
+				Source:   "bulk-loader",
+				Data:     map[string]interface{}{"n": i},
+			})
+		}
+		writer.Close()
+	}()
+
+	req, _ := http.NewRequest("POST", "/events/stream-ingest", reader)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var response struct {
+		Data models.IngestResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 100, response.Data.Received)
+	assert.Equal(t, 100, response.Data.Succeeded)
+	assert.Zero(t, response.Data.Failed)
+
+	// Each stream is numbered 1..25 in the order its lines were sent
+	for s := 0; s < 4; s++ {
+		var stored []models.Event
+		require.NoError(t, db.Where("stream_id = ?", fmt.Sprintf("order-%d", s)).Order("sequence_number").Find(&stored).Error)
+		require.Len(t, stored, 25)
+		for i, event := range stored {
+			assert.Equal(t, int64(i+1), event.SequenceNumber)
+			assert.Equal(t, float64(s+4*i), event.Data["n"])
+		}
+	}
+}
+
+func TestIngestEventsNDJSONLineErrors(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events/stream-ingest", handler.IngestEvents)
+
+	body := strings.Join([]string{
+		`{"type":"order.created","stream_id":"order-1","source":"bulk-loader"}`,
+		`{"type":"order.created","stream_id":"order-1"}`,
+		``,
+		`not json`,
+		`{"type":"order.paid","stream_id":"order-1","source":"bulk-loader"}`,
+	}, "\n")
+
+	req, _ := http.NewRequest("POST", "/events/stream-ingest", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+
+	var response struct {
+		Data models.IngestResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 4, response.Data.Received)
+	assert.Equal(t, 2, response.Data.Succeeded)
+	assert.Equal(t, 2, response.Data.Failed)
+	require.Len(t, response.Data.Errors, 2)
+	assert.Equal(t, 2, response.Data.Errors[0].Index)
+	assert.Equal(t, 4, response.Data.Errors[1].Index)
+
+	var count int64
+	db.Model(&models.Event{}).Where("stream_id = ?", "order-1").Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// Other content types are refused
+	req, _ = http.NewRequest("POST", "/events/stream-ingest", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestRedeliverEvent(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Event-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := models.Event{ID: "event-1", Type: "order.created", StreamID: "order-1", Source: "test", Data: models.JSON{"n": 1}, Timestamp: time.Now()}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	// The original delivery, which the consumer lost
+	webhook := models.WebhookEndpoint{
+		ID:             "webhook-1",
+		Name:           "Orders",
+		URL:            server.URL,
+		Secret:         "secret",
+		EventTypes:     []string{"order.created"},
+		Enabled:        true,
+		MaxRetries:     1,
+		TimeoutSeconds: 5,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+	require.NoError(t, db.Create(&models.WebhookDelivery{ID: "delivery-original", WebhookID: webhook.ID, EventID: event.ID, Status: "success"}).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events/:id/redeliver", handler.RedeliverEvent)
+
+	req, _ := http.NewRequest("POST", "/events/event-1/redeliver", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response models.APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(1), response.Data.(map[string]interface{})["queued"])
+
+	select {
+	case eventID := <-received:
+		assert.Equal(t, "event-1", eventID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not redelivered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, handler.eventManager.GetWebhookDeliveryService().Shutdown(ctx))
+
+	// The original record is untouched and a new one marks the redelivery
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Where("event_id = ?", "event-1").Order("created_at").Find(&deliveries).Error)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "delivery-original", deliveries[0].ID)
+	assert.False(t, deliveries[0].Redelivery)
+	assert.True(t, deliveries[1].Redelivery)
+	assert.Equal(t, "success", deliveries[1].Status)
+
+	req, _ = http.NewRequest("POST", "/events/missing/redeliver", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetEventsByStreamAfterSequence(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	for i := 1; i <= 5; i++ {
+		event := models.Event{
+			ID:       fmt.Sprintf("event-%d", i),
+			Type:     "order.updated",
+			StreamID: "order-1",
+			Source:   "test",
+			Data:     models.JSON{"step": i},
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/streams/:stream_id", handler.GetEventsByStream)
+
+	req, _ := http.NewRequest("GET", "/events/streams/order-1?after_sequence=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.EventStreamResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	var sequences []int64
+	for _, event := range response.Data.Events {
+		sequences = append(sequences, event.SequenceNumber)
+	}
+	assert.Equal(t, []int64{3, 4, 5}, sequences)
+	assert.Equal(t, int64(3), response.Data.Count)
+
+	t.Run("invalid after_sequence", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/events/streams/order-1?after_sequence=-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetEventsCursorPagination(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// Pairs of events share a timestamp so the ID has to break ties
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      "order.created",
+			StreamID:  fmt.Sprintf("order-%d", i),
+			Source:    "test",
+			Data:      models.JSON{"n": i},
+			Timestamp: base.Add(time.Duration(i/2) * time.Minute),
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", handler.GetEvents)
+
+	getPage := func(query string) models.PaginatedResponse {
+		req, _ := http.NewRequest("GET", "/events?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data struct {
+				models.PaginatedResponse
+				Items []models.Event `json:"items"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		page := response.Data.PaginatedResponse
+		page.Items = response.Data.Items
+		return page
+	}
+
+	var walked []string
+	page := getPage("limit=3")
+	for pages := 1; ; pages++ {
+		require.LessOrEqual(t, pages, 3)
+		for _, event := range page.Items.([]models.Event) {
+			walked = append(walked, event.ID)
+		}
+		if !page.HasMore {
+			assert.Empty(t, page.NextCursor)
+			break
+		}
+		require.NotEmpty(t, page.NextCursor)
+
+		// An event stored mid-walk doesn't shift the following pages
+		if pages == 1 {
+			late := models.Event{ID: "event-late", Type: "order.created", StreamID: "order-late", Source: "test", Timestamp: base.Add(time.Hour)}
+			require.NoError(t, db.CreateEventWithSequence(&late))
+		}
+		page = getPage("limit=3&cursor=" + page.NextCursor)
+	}
+
+	// Newest first, every event exactly once
+	assert.Equal(t, []string{"event-6", "event-5", "event-4", "event-3", "event-2", "event-1", "event-0"}, walked)
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/events?cursor=not-a-cursor", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCreateEventAllowedSources(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+	handler.config.Events.AllowedSources = []string{"user-service", "billing"}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events", handler.CreateEvent)
+
+	post := func(source string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"type":"user.created","stream_id":"user-123","source":%q}`, source)
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, post("user-service").Code)
+
+	w := post("spoofed-service")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var response models.APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Success)
+	assert.Contains(t, response.Error, "event source is not allowed")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Event{}).Where("source = ?", "spoofed-service").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestEventTypeAliases(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/events/aliases", handler.CreateEventTypeAlias)
+	router.GET("/events/aliases", handler.GetEventTypeAliases)
+	router.DELETE("/events/aliases/:alias", handler.DeleteEventTypeAlias)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events/aliases", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, post(`{"alias":"order.created","event_type":"order.placed"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"alias":"order.placed","event_type":"order.placed"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"alias":"order.*","event_type":"order.placed"}`).Code)
+
+	req, _ := http.NewRequest("GET", "/events/aliases", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.EventTypeAlias `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "order.placed", response.Data[0].EventType)
+
+	req, _ = http.NewRequest("DELETE", "/events/aliases/order.created", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/events/aliases/order.created", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetStreamIntegrity(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// Stored directly so the sequence numbers can skip 3
+	for _, seq := range []int64{1, 2, 4} {
+		event := models.Event{
+			ID:             fmt.Sprintf("gapped-%d", seq),
+			Type:           "order.created",
+			StreamID:       "gapped-stream",
+			Source:         "test",
+			Timestamp:      time.Now(),
+			SequenceNumber: seq,
+		}
+		require.NoError(t, db.Create(&event).Error)
+	}
+	for i := 0; i < 2; i++ {
+		event := models.Event{ID: fmt.Sprintf("intact-%d", i), Type: "order.created", StreamID: "intact-stream", Source: "test", Timestamp: time.Now()}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/streams/:stream_id/integrity", handler.GetStreamIntegrity)
+
+	get := func(streamID string) (int, models.StreamIntegrity) {
+		req, _ := http.NewRequest("GET", "/events/streams/"+streamID+"/integrity", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data models.StreamIntegrity `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	code, integrity := get("gapped-stream")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(3), integrity.EventCount)
+	assert.Equal(t, int64(4), integrity.LatestSequence)
+	assert.False(t, integrity.Intact)
+	assert.Equal(t, []models.SequenceGap{{From: 3, To: 3}}, integrity.Gaps)
+
+	code, integrity = get("intact-stream")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, integrity.Intact)
+	assert.Empty(t, integrity.Gaps)
+
+	code, _ = get("missing-stream")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// No alert is published unless enabled
+	var alerts int64
+	require.NoError(t, db.Model(&models.Event{}).Where("type = ?", "stream.gap_detected").Count(&alerts).Error)
+	assert.Zero(t, alerts)
+
+	t.Run("gap alert", func(t *testing.T) {
+		handler.config.Events.GapAlerts = true
+
+		code, _ := get("gapped-stream")
+		require.Equal(t, http.StatusOK, code)
+
+		var alert models.Event
+		require.NoError(t, db.Where("type = ?", "stream.gap_detected").First(&alert).Error)
+		assert.Equal(t, "stream-integrity", alert.StreamID)
+		assert.Equal(t, "gapped-stream", alert.Data["stream_id"])
+
+		countAlerts := func() int64 {
+			var alerts int64
+			require.NoError(t, db.Model(&models.Event{}).Where("type = ?", "stream.gap_detected").Count(&alerts).Error)
+			return alerts
+		}
+
+		// Polling again doesn't alert on the same gap twice
+		code, _ = get("gapped-stream")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(1), countAlerts())
+
+		// A new gap does
+		event := models.Event{ID: "gapped-6", Type: "order.created", StreamID: "gapped-stream", Source: "test", Timestamp: time.Now(), SequenceNumber: 6}
+		require.NoError(t, db.Create(&event).Error)
+		code, integrity := get("gapped-stream")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []models.SequenceGap{{From: 3, To: 3}, {From: 5, To: 5}}, integrity.Gaps)
+		assert.Equal(t, int64(2), countAlerts())
+	})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"goapitemplate/pkg/models"
+)
+
+// eventExporter writes events to a download one at a time, so an export
+// never holds the whole stream in memory
+type eventExporter interface {
+	Write(event models.Event) error
+	// Close writes any trailing output and flushes
+	Close() error
+}
+
+// jsonEventExporter writes events as a JSON array
+type jsonEventExporter struct {
+	w       *bufio.Writer
+	started bool
+}
+
+func newJSONEventExporter(w io.Writer) *jsonEventExporter {
+	return &jsonEventExporter{w: bufio.NewWriter(w)}
+}
+
+func (e *jsonEventExporter) Write(event models.Event) error {
+	separator := ",\n"
+	if !e.started {
+		separator = "[\n"
+		e.started = true
+	}
+	if _, err := e.w.WriteString(separator); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEventExporter) Close() error {
+	closing := "\n]\n"
+	if !e.started {
+		closing = "[]\n"
+	}
+	if _, err := e.w.WriteString(closing); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// eventCSVHeader names the CSV columns after the event's JSON fields
+var eventCSVHeader = []string{"id", "type", "stream_id", "source", "data", "metadata", "timestamp", "sequence_number", "created_at", "schema_version", "cloudevent_id"}
+
+// csvEventExporter writes events as CSV rows, with data and metadata encoded
+// as JSON
+type csvEventExporter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func newCSVEventExporter(w io.Writer) *csvEventExporter {
+	return &csvEventExporter{w: csv.NewWriter(w)}
+}
+
+func (e *csvEventExporter) writeHeader() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.w.Write(eventCSVHeader)
+}
+
+func (e *csvEventExporter) Write(event models.Event) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	metadata := []byte{}
+	if len(event.Metadata) > 0 {
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return err
+		}
+	}
+
+	cloudEventID := ""
+	if event.CloudEventID != nil {
+		cloudEventID = *event.CloudEventID
+	}
+
+	return e.w.Write([]string{
+		event.ID,
+		event.Type,
+		event.StreamID,
+		event.Source,
+		string(data),
+		string(metadata),
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		strconv.FormatInt(event.SequenceNumber, 10),
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.Itoa(event.SchemaVersion),
+		cloudEventID,
+	})
+}
+
+func (e *csvEventExporter) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
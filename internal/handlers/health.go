@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"goapitemplate/internal/version"
 	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +22,20 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 		Message: "API is running",
 		Data: map[string]interface{}{
 			"timestamp": time.Now(),
-			"version":   "1.0.0",
+			"version":   version.Version,
 		},
 	})
 }
+
+// @Summary Get Version
+// @Description Get the running build's version, git commit, build time and Go version
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=version.Info}
+// @Router /api/v1/version [get]
+func (h *Handler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    version.Get(),
+	})
+}
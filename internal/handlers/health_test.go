@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"goapitemplate/internal/version"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetVersion(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// As if built with -ldflags "-X goapitemplate/internal/version.Version=..."
+	original := version.Version
+	version.Version = "1.2.3-test"
+	t.Cleanup(func() { version.Version = original })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/version", handler.GetVersion)
+	router.GET("/health", handler.HealthCheck)
+
+	req, _ := http.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data version.Info `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "1.2.3-test", response.Data.Version)
+	assert.Equal(t, version.Commit, response.Data.Commit)
+	assert.Equal(t, runtime.Version(), response.Data.GoVersion)
+
+	req, _ = http.NewRequest("GET", "/health", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var health struct {
+		Data struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+	assert.Equal(t, "1.2.3-test", health.Data.Version)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// ndjsonContentType is the media type of newline-delimited JSON
+	ndjsonContentType = "application/x-ndjson"
+
+	// maxIngestLineBytes is the longest NDJSON line, i.e. event, accepted
+	maxIngestLineBytes = 1 << 20
+
+	// maxIngestErrors caps the per-line errors reported, so a stream of bad
+	// lines can't grow the response without bound
+	maxIngestErrors = 1000
+)
+
+// @Summary Ingest Events from NDJSON
+// @Description Stream events as newline-delimited JSON, one CreateEventRequest per line. Lines are decoded and
+// @Description created one at a time, so the body is never buffered whole; each line succeeds or fails on its own.
+// @Tags events
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 201 {object} models.APIResponse{data=models.IngestResponse}
+// @Success 207 {object} models.APIResponse{data=models.IngestResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 415 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/events/stream-ingest [post]
+func (h *Handler) IngestEvents(c *gin.Context) {
+	if c.ContentType() != ndjsonContentType {
+		c.JSON(http.StatusUnsupportedMediaType, models.APIResponse{
+			Success: false,
+			Error:   "Content-Type must be " + ndjsonContentType,
+		})
+		return
+	}
+
+	response := models.IngestResponse{Errors: []models.BatchResult{}}
+	lineFailed := func(line int, err string) {
+		response.Failed++
+		if len(response.Errors) < maxIngestErrors {
+			response.Errors = append(response.Errors, models.BatchResult{Index: line, Error: err})
+		}
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxIngestLineBytes)
+	serverError := false
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		response.Received++
+
+		var req models.CreateEventRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			lineFailed(line, err.Error())
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			lineFailed(line, err.Error())
+			continue
+		}
+		event, err := h.buildEvent(req, c.GetString("request_id"))
+		if err != nil {
+			lineFailed(line, err.Error())
+			continue
+		}
+
+		if err := h.eventManager.PublishEvent(c.Request.Context(), event); err != nil {
+			h.logger.WithError(err).WithField("line", line).Error("Failed to publish ingested event")
+			lineFailed(line, "Failed to create event")
+			serverError = true
+			continue
+		}
+		response.Succeeded++
+	}
+
+	// A read error or over-long line ends the stream; what came before stands
+	if err := scanner.Err(); err != nil {
+		lineFailed(line+1, fmt.Sprintf("Failed to read line: %v", err))
+	}
+
+	status := http.StatusCreated
+	switch {
+	case response.Succeeded > 0 && response.Failed > 0:
+		status = http.StatusMultiStatus
+	case response.Succeeded == 0 && serverError:
+		status = http.StatusInternalServerError
+	case response.Succeeded == 0:
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, models.APIResponse{
+		Success: response.Failed == 0,
+		Data:    response,
+	})
+}
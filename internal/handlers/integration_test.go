@@ -8,9 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"goapitemplate/internal/middleware"
 	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -330,4 +332,74 @@ func TestDisabledWebhookIntegration(t *testing.T) {
 	err = db.Find(&deliveries).Error
 	require.NoError(t, err)
 	assert.Len(t, deliveries, 0)
-}
\ No newline at end of file
+}
+// TestRequestIDPropagation tests that the request ID is logged and forwarded to webhooks triggered by the request
+func TestRequestIDPropagation(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Logger())
+	router.POST("/api/v1/events", handler.CreateEvent)
+
+	receivedRequestID := make(chan string, 1)
+	mockWebhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequestID <- r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockWebhookServer.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "request-id-webhook",
+		Name:           "Request ID Webhook",
+		URL:            mockWebhookServer.URL,
+		Secret:         "secret",
+		EventTypes:     []string{"user.created"},
+		Enabled:        true,
+		MaxRetries:     1,
+		TimeoutSeconds: 5,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	eventBytes, _ := json.Marshal(map[string]interface{}{
+		"type":      "user.created",
+		"stream_id": "user-request-id",
+		"source":    "integration-test",
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/events", bytes.NewBuffer(eventBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-abc-123")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "req-abc-123", w.Header().Get("X-Request-ID"))
+
+	// Logged by the Logger middleware
+	var logged interface{}
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "HTTP Request" {
+			logged = entry.Data["request_id"]
+		}
+	}
+	assert.Equal(t, "req-abc-123", logged)
+
+	// Forwarded to the webhook triggered by the event
+	select {
+	case got := <-receivedRequestID:
+		assert.Equal(t, "req-abc-123", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	// Recorded on the stored event
+	var savedEvent models.Event
+	require.NoError(t, db.First(&savedEvent, "stream_id = ?", "user-request-id").Error)
+	assert.Equal(t, "req-abc-123", savedEvent.Metadata["request_id"])
+}
@@ -18,16 +18,31 @@ import (
 // @Router /api/v1/monitoring/stats [get]
 func (h *Handler) GetStats(c *gin.Context) {
 	// Get event type statistics
-	eventStats, err := h.db.GetEventStatsByType()
+	eventStats, err := h.dbFor(c).GetEventStatsByType()
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get event stats")
 		eventStats = make(map[string]int64)
 	}
 
+	dbStats := map[string]interface{}{"status": "connected"}
+	if poolStats, err := h.db.Stats(); err != nil {
+		h.logger.WithError(err).Error("Failed to get database pool stats")
+		dbStats["status"] = "unavailable"
+	} else {
+		dbStats["pool"] = map[string]interface{}{
+			"max_open_connections": poolStats.MaxOpenConnections,
+			"open_connections":     poolStats.OpenConnections,
+			"in_use":               poolStats.InUse,
+			"idle":                 poolStats.Idle,
+			"wait_count":           poolStats.WaitCount,
+			"wait_duration_ms":     poolStats.WaitDuration.Milliseconds(),
+		}
+	}
+
 	stats := map[string]interface{}{
 		"timestamp":   time.Now(),
 		"uptime":      time.Since(time.Now().Add(-time.Hour)), // Placeholder
-		"database":    "connected",
+		"database":    dbStats,
 		"cache":       h.cache != nil,
 		"events":      "enabled",
 		"event_stats": eventStats,
@@ -37,4 +52,142 @@ func (h *Handler) GetStats(c *gin.Context) {
 		Success: true,
 		Data:    stats,
 	})
-}
\ No newline at end of file
+}
+// @Summary Get Event Stats
+// @Description Get event counts in total, for the last hour and per event type
+// @Tags monitoring
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/monitoring/events/stats [get]
+func (h *Handler) GetEventStats(c *gin.Context) {
+	var stats struct {
+		TotalEvents    int64            `json:"total_events"`
+		EventsLastHour int64            `json:"events_last_hour"`
+		EventsByType   map[string]int64 `json:"events_by_type"`
+	}
+
+	// Get total events
+	err := h.dbFor(c).Model(&models.Event{}).Count(&stats.TotalEvents).Error
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get total events")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+
+	// Get events stored in the last hour
+	err = h.dbFor(c).Model(&models.Event{}).
+		Where("created_at >= ?", time.Now().Add(-time.Hour)).
+		Count(&stats.EventsLastHour).Error
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get recent events")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+
+	// Get per-type counts
+	stats.EventsByType, err = h.dbFor(c).GetEventStatsByType()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get event stats")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// @Summary Get Event Time Series
+// @Description Get event counts bucketed by event timestamp, oldest first, for charting throughput. Buckets without events are omitted.
+// @Tags monitoring
+// @Produce json
+// @Param interval query string false "Bucket size: hour or day" default(hour)
+// @Param from query string false "Start of the range (RFC3339), defaults to 24 hours before to"
+// @Param to query string false "End of the range (RFC3339), defaults to now"
+// @Param type query string false "Only count events of this type"
+// @Success 200 {object} models.APIResponse{data=[]models.EventCountBucket}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/monitoring/events/timeseries [get]
+func (h *Handler) GetEventTimeSeries(c *gin.Context) {
+	interval := c.DefaultQuery("interval", "hour")
+	if interval != "hour" && interval != "day" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "interval must be hour or day",
+		})
+		return
+	}
+
+	from, to, ok := parseTimeSeriesRange(c)
+	if !ok {
+		return
+	}
+
+	buckets, err := h.dbFor(c).GetEventTimeSeries(interval, from, to, c.Query("type"))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get event time series")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    buckets,
+	})
+}
+
+// parseTimeSeriesRange reads the from and to query parameters of a time
+// series request, defaulting to the 24 hours up to now. On invalid input it
+// writes a 400 response and returns false.
+func parseTimeSeriesRange(c *gin.Context) (from, to time.Time, ok bool) {
+	to = time.Now().UTC()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "to must be an RFC3339 timestamp",
+			})
+			return from, to, false
+		}
+		to = parsed.UTC()
+	}
+
+	from = to.Add(-24 * time.Hour)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "from must be an RFC3339 timestamp",
+			})
+			return from, to, false
+		}
+		from = parsed.UTC()
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "from must be before to",
+		})
+		return from, to, false
+	}
+
+	return from, to, true
+}
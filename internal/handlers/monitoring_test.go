@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEventStats(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	now := time.Now()
+	seeds := []struct {
+		eventType string
+		createdAt time.Time
+	}{
+		{"user.created", now.Add(-2 * time.Hour)},
+		{"user.created", now.Add(-10 * time.Minute)},
+		{"user.created", now},
+		{"order.placed", now.Add(-3 * time.Hour)},
+		{"order.placed", now.Add(-time.Minute)},
+	}
+	for i, seed := range seeds {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      seed.eventType,
+			StreamID:  "stats-stream",
+			Source:    "test",
+			Data:      models.JSON{"n": i},
+			Timestamp: seed.createdAt,
+			CreatedAt: seed.createdAt,
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/monitoring/events/stats", handler.GetEventStats)
+
+	req, _ := http.NewRequest("GET", "/monitoring/events/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			TotalEvents    int64            `json:"total_events"`
+			EventsLastHour int64            `json:"events_last_hour"`
+			EventsByType   map[string]int64 `json:"events_by_type"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.True(t, response.Success)
+	assert.Equal(t, int64(5), response.Data.TotalEvents)
+	assert.Equal(t, int64(3), response.Data.EventsLastHour)
+	assert.Equal(t, map[string]int64{"user.created": 3, "order.placed": 2}, response.Data.EventsByType)
+}
+
+func TestGetStatsIncludesPoolStats(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/monitoring/stats", handler.GetStats)
+
+	req, _ := http.NewRequest("GET", "/monitoring/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Database struct {
+				Status string                 `json:"status"`
+				Pool   map[string]interface{} `json:"pool"`
+			} `json:"database"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.Equal(t, "connected", response.Data.Database.Status)
+	for _, key := range []string{"max_open_connections", "open_connections", "in_use", "idle", "wait_count", "wait_duration_ms"} {
+		value, ok := response.Data.Database.Pool[key]
+		require.True(t, ok, "missing pool stat %s", key)
+		assert.IsType(t, float64(0), value, key)
+	}
+}
+
+func TestHandlersWithoutCache(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// The server runs like this when the cache is unreachable at startup
+	handler.cache = nil
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.RegisterRoutes(router)
+
+	payload := `{"type": "user.created", "stream_id": "user-1", "source": "test", "data": {}}`
+	req, _ := http.NewRequest("POST", "/api/v1/events/", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/events/", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/monitoring/stats", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data struct {
+			Cache bool `json:"cache"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Data.Cache)
+}
+
+func TestGetEventTimeSeries(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	base := time.Date(2025, time.June, 1, 10, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		eventType string
+		at        time.Time
+	}{
+		{"user.created", base.Add(5 * time.Minute)},
+		{"user.created", base.Add(40 * time.Minute)},
+		{"order.placed", base.Add(59 * time.Minute)},
+		{"user.created", base.Add(time.Hour + 30*time.Minute)},
+		{"user.created", base.Add(-time.Minute)}, // Before the range
+	}
+	for i, seed := range seeds {
+		event := models.Event{
+			ID:        fmt.Sprintf("event-%d", i),
+			Type:      seed.eventType,
+			StreamID:  "timeseries-stream",
+			Source:    "test",
+			Timestamp: seed.at,
+		}
+		require.NoError(t, db.CreateEventWithSequence(&event))
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/monitoring/events/timeseries", handler.GetEventTimeSeries)
+
+	get := func(query string) (int, []models.EventCountBucket) {
+		req, _ := http.NewRequest("GET", "/monitoring/events/timeseries?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []models.EventCountBucket `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	rangeQuery := "from=2025-06-01T10:00:00Z&to=2025-06-01T12:00:00Z"
+
+	t.Run("hourly buckets", func(t *testing.T) {
+		code, buckets := get("interval=hour&" + rangeQuery)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, buckets, 2)
+
+		assert.True(t, base.Equal(buckets[0].BucketStart))
+		assert.Equal(t, int64(3), buckets[0].Count)
+		assert.True(t, base.Add(time.Hour).Equal(buckets[1].BucketStart))
+		assert.Equal(t, int64(1), buckets[1].Count)
+	})
+
+	t.Run("daily bucket of one type", func(t *testing.T) {
+		code, buckets := get("interval=day&type=user.created&" + rangeQuery)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, buckets, 1)
+
+		assert.True(t, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC).Equal(buckets[0].BucketStart))
+		assert.Equal(t, int64(3), buckets[0].Count)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		code, _ := get("interval=week")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = get("from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report fields by their JSON names, which is what clients send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindingErrorResponse builds the 400 response for a request body that failed
+// to bind. Validation failures are listed per field under Details; other
+// errors, such as malformed JSON, are returned as is.
+func bindingErrorResponse(err error) models.APIResponse {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	details := make(map[string]string, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		details[fieldPath(fieldErr)] = validationMessage(fieldErr)
+	}
+
+	return models.APIResponse{
+		Success: false,
+		Error:   "Validation failed",
+		Details: details,
+	}
+}
+
+// fieldPath returns the field's path without the request struct's name,
+// e.g. "oauth.token_url"
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	case "excludes":
+		return "must not contain " + fieldErr.Param()
+	case "nefield":
+		return "must differ from " + fieldErr.Param()
+	default:
+		return "failed the " + fieldErr.Tag() + " check"
+	}
+}
@@ -2,21 +2,29 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"goapitemplate/internal/cache"
+	"goapitemplate/internal/events"
 	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Create Webhook
-// @Description Create a new webhook endpoint
+// @Description Create a new webhook endpoint. With upsert=true, a webhook with the same name is updated instead.
 // @Tags webhooks
 // @Accept json
 // @Produce json
 // @Param webhook body models.CreateWebhookRequest true "Webhook data"
+// @Param upsert query bool false "Update the webhook with the same name if one exists" default(false)
+// @Success 200 {object} models.APIResponse
 // @Success 201 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -24,12 +32,33 @@ import (
 func (h *Handler) CreateWebhook(c *gin.Context) {
 	var req models.CreateWebhookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	if err := h.validateWebhookURLs(c.Request.Context(), req.URL, req.OAuth, req.ProxyURL); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
 		return
 	}
+	if _, err := events.ParsePayloadTemplate(req.PayloadTemplate); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid payload template: " + err.Error(),
+		})
+		return
+	}
+	if req.ClientCertificate != nil {
+		if _, err := events.ParseClientCertificate(req.ClientCertificate.CertPEM, req.ClientCertificate.KeyPEM); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
 
 	webhook := models.WebhookEndpoint{
 		ID:             generateID(),
@@ -40,6 +69,35 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 		Enabled:        true,
 		MaxRetries:     req.MaxRetries,
 		TimeoutSeconds: req.TimeoutSeconds,
+		LogLevel:       req.LogLevel,
+
+		IncludeWebhookInfo: req.IncludeWebhookInfo,
+		PayloadTemplate:    req.PayloadTemplate,
+		OrderedDelivery:    req.OrderedDelivery,
+		SignatureAlgorithm: req.SignatureAlgorithm,
+
+		MaxDeliveriesPerMinute: req.MaxDeliveriesPerMinute,
+		MaxRetriesPerDay:       req.MaxRetriesPerDay,
+
+		SecretNext:  req.SecretNext,
+		ContentType: req.ContentType,
+
+		ProxyURL: req.ProxyURL,
+
+		InsecureSkipVerify: req.InsecureSkipVerify,
+
+		Method:          req.Method,
+		CompressPayload: req.CompressPayload,
+	}
+	if req.ClientCertificate != nil {
+		webhook.ClientCertPEM = req.ClientCertificate.CertPEM
+		webhook.ClientKeyPEM = req.ClientCertificate.KeyPEM
+	}
+	if req.OAuth != nil {
+		webhook.OAuthTokenURL = req.OAuth.TokenURL
+		webhook.OAuthClientID = req.OAuth.ClientID
+		webhook.OAuthClientSecret = req.OAuth.ClientSecret
+		webhook.OAuthScopes = strings.Join(req.OAuth.Scopes, " ")
 	}
 
 	// Set defaults
@@ -49,8 +107,54 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 	if webhook.TimeoutSeconds == 0 {
 		webhook.TimeoutSeconds = 30
 	}
+	if webhook.SignatureAlgorithm == "" {
+		webhook.SignatureAlgorithm = events.DefaultSignatureAlgorithm
+	}
+	if webhook.ContentType == "" {
+		webhook.ContentType = events.ContentTypeJSON
+	}
+	if webhook.Method == "" {
+		webhook.Method = events.DefaultMethod
+	}
+
+	if c.Query("upsert") == "true" {
+		var existing models.WebhookEndpoint
+		result := h.dbFor(c).Where("name = ?", webhook.Name).Order("created_at").Limit(1).Find(&existing)
+		if result.Error != nil {
+			h.logger.WithError(result.Error).Error("Failed to look up webhook by name")
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Failed to create webhook",
+			})
+			return
+		}
+
+		if result.RowsAffected > 0 {
+			// Replace the configuration but keep the identity and whether
+			// the webhook was disabled
+			webhook.ID = existing.ID
+			webhook.Enabled = existing.Enabled
+			webhook.CreatedAt = existing.CreatedAt
 
-	if err := h.db.Create(&webhook).Error; err != nil {
+			if err := h.dbFor(c).Save(&webhook).Error; err != nil {
+				h.logger.WithError(err).Error("Failed to update webhook")
+				c.JSON(http.StatusInternalServerError, models.APIResponse{
+					Success: false,
+					Error:   "Failed to update webhook",
+				})
+				return
+			}
+			h.invalidateWebhookCache(c.Request.Context(), webhook.ID)
+
+			c.JSON(http.StatusOK, models.APIResponse{
+				Success: true,
+				Data:    webhook,
+			})
+			return
+		}
+	}
+
+	if err := h.dbFor(c).Create(&webhook).Error; err != nil {
 		h.logger.WithError(err).Error("Failed to create webhook")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -66,15 +170,24 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 }
 
 // @Summary Get Webhooks
-// @Description Get all webhook endpoints
+// @Description Get webhook endpoints with pagination
 // @Tags webhooks
 // @Produce json
-// @Success 200 {object} models.APIResponse
+// @Param limit query int false "Number of webhooks to return" default(50)
+// @Param offset query int false "Number of webhooks to skip" default(0)
+// @Param include_deleted query bool false "Include soft-deleted webhooks" default(false)
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} models.APIResponse{data=models.PaginatedResponse}
+// @Success 304 "Unchanged since the ETag in If-None-Match"
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/webhooks [get]
 func (h *Handler) GetWebhooks(c *gin.Context) {
-	var webhooks []models.WebhookEndpoint
-	if err := h.db.Order("created_at DESC").Find(&webhooks).Error; err != nil {
+	limit, offset := parsePagination(c)
+
+	includeDeleted := c.Query("include_deleted") == "true"
+
+	webhooks, total, err := h.dbFor(c).GetWebhooksWithPagination(offset, limit, includeDeleted)
+	if err != nil {
 		h.logger.WithError(err).Error("Failed to get webhooks")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -83,9 +196,9 @@ func (h *Handler) GetWebhooks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	jsonWithETag(c, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    webhooks,
+		Data:    models.NewPaginatedResponse(webhooks, len(webhooks), limit, offset, total),
 	})
 }
 
@@ -101,8 +214,12 @@ func (h *Handler) GetWebhooks(c *gin.Context) {
 func (h *Handler) GetWebhook(c *gin.Context) {
 	webhookID := c.Param("id")
 
-	var webhook models.WebhookEndpoint
-	err := h.db.First(&webhook, "id = ?", webhookID).Error
+	ttl := time.Duration(h.config.Cache.TTL) * time.Second
+	webhook, err := cache.GetOrSet(c.Request.Context(), h.cache, webhookCacheKey(webhookID), ttl, func() (interface{}, error) {
+		var webhook models.WebhookEndpoint
+		err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error
+		return webhook, err
+	})
 	if err != nil {
 		if err.Error() == "record not found" {
 			c.JSON(http.StatusNotFound, models.APIResponse{
@@ -121,10 +238,27 @@ func (h *Handler) GetWebhook(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    webhook,
+		Data:    json.RawMessage(webhook),
 	})
 }
 
+func webhookCacheKey(webhookID string) string {
+	return "webhook:" + webhookID
+}
+
+// invalidateWebhookCache drops a webhook's cached lookup and delivery
+// transport after it changes
+func (h *Handler) invalidateWebhookCache(ctx context.Context, webhookID string) {
+	h.eventManager.GetWebhookDeliveryService().ForgetTransport(webhookID)
+
+	if h.cache == nil {
+		return
+	}
+	if err := h.cache.Delete(ctx, webhookCacheKey(webhookID)); err != nil {
+		h.logger.WithError(err).WithField("webhook_id", webhookID).Warn("Failed to invalidate cached webhook")
+	}
+}
+
 // @Summary Update Webhook
 // @Description Update webhook by ID
 // @Tags webhooks
@@ -142,6 +276,15 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 
 	var req models.UpdateWebhookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	proxyURL := ""
+	if req.ProxyURL != nil {
+		proxyURL = *req.ProxyURL
+	}
+	if err := h.validateWebhookURLs(c.Request.Context(), req.URL, req.OAuth, proxyURL); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -171,6 +314,69 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 	if req.TimeoutSeconds > 0 {
 		updates["timeout_seconds"] = req.TimeoutSeconds
 	}
+	if req.LogLevel != "" {
+		updates["log_level"] = req.LogLevel
+	}
+	if req.OAuth != nil {
+		updates["oauth_token_url"] = req.OAuth.TokenURL
+		updates["oauth_client_id"] = req.OAuth.ClientID
+		updates["oauth_client_secret"] = req.OAuth.ClientSecret
+		updates["oauth_scopes"] = strings.Join(req.OAuth.Scopes, " ")
+	}
+	if req.IncludeWebhookInfo != nil {
+		updates["include_webhook_info"] = *req.IncludeWebhookInfo
+	}
+	if req.PayloadTemplate != nil {
+		if _, err := events.ParsePayloadTemplate(*req.PayloadTemplate); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid payload template: " + err.Error(),
+			})
+			return
+		}
+		updates["payload_template"] = *req.PayloadTemplate
+	}
+	if req.OrderedDelivery != nil {
+		updates["ordered_delivery"] = *req.OrderedDelivery
+	}
+	if req.SignatureAlgorithm != "" {
+		updates["signature_algorithm"] = req.SignatureAlgorithm
+	}
+	if req.MaxDeliveriesPerMinute != nil {
+		updates["max_deliveries_per_minute"] = *req.MaxDeliveriesPerMinute
+	}
+	if req.MaxRetriesPerDay != nil {
+		updates["max_retries_per_day"] = *req.MaxRetriesPerDay
+	}
+	if req.SecretNext != nil {
+		updates["secret_next"] = *req.SecretNext
+	}
+	if req.ContentType != "" {
+		updates["content_type"] = req.ContentType
+	}
+	if req.ProxyURL != nil {
+		updates["proxy_url"] = *req.ProxyURL
+	}
+	if req.Method != "" {
+		updates["method"] = req.Method
+	}
+	if req.CompressPayload != nil {
+		updates["compress_payload"] = *req.CompressPayload
+	}
+	if req.InsecureSkipVerify != nil {
+		updates["insecure_skip_verify"] = *req.InsecureSkipVerify
+	}
+	if req.ClientCertificate != nil {
+		if _, err := events.ParseClientCertificate(req.ClientCertificate.CertPEM, req.ClientCertificate.KeyPEM); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		updates["client_cert_pem"] = req.ClientCertificate.CertPEM
+		updates["client_key_pem"] = req.ClientCertificate.KeyPEM
+	}
 
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
@@ -180,7 +386,7 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 		return
 	}
 
-	result := h.db.Model(&models.WebhookEndpoint{}).Where("id = ?", webhookID).Updates(updates)
+	result := h.dbFor(c).Model(&models.WebhookEndpoint{}).Where("id = ?", webhookID).Updates(updates)
 	if result.Error != nil {
 		h.logger.WithError(result.Error).Error("Failed to update webhook")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -197,6 +403,7 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 		})
 		return
 	}
+	h.invalidateWebhookCache(c.Request.Context(), webhookID)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -205,18 +412,26 @@ func (h *Handler) UpdateWebhook(c *gin.Context) {
 }
 
 // @Summary Delete Webhook
-// @Description Delete webhook by ID
+// @Description Soft-delete webhook by ID, or permanently delete it with hard=true
 // @Tags webhooks
 // @Produce json
 // @Param id path string true "Webhook ID"
+// @Param hard query bool false "Permanently delete the webhook" default(false)
 // @Success 200 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/webhooks/{id} [delete]
 func (h *Handler) DeleteWebhook(c *gin.Context) {
 	webhookID := c.Param("id")
+	hard := c.Query("hard") == "true"
 
-	result := h.db.Delete(&models.WebhookEndpoint{}, "id = ?", webhookID)
+	query := h.dbFor(c).DB
+	if hard {
+		// Unscoped so already soft-deleted webhooks can be purged too
+		query = query.Unscoped()
+	}
+
+	result := query.Delete(&models.WebhookEndpoint{}, "id = ?", webhookID)
 	if result.Error != nil {
 		h.logger.WithError(result.Error).Error("Failed to delete webhook")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -233,6 +448,7 @@ func (h *Handler) DeleteWebhook(c *gin.Context) {
 		})
 		return
 	}
+	h.invalidateWebhookCache(c.Request.Context(), webhookID)
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -240,13 +456,342 @@ func (h *Handler) DeleteWebhook(c *gin.Context) {
 	})
 }
 
+// @Summary Delete Webhooks by Event Type
+// @Description Soft-delete every webhook whose event_types contains the given type, or permanently delete them with hard=true
+// @Tags webhooks
+// @Produce json
+// @Param event_type query string true "Event type the webhooks subscribe to"
+// @Param hard query bool false "Permanently delete the webhooks" default(false)
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks [delete]
+func (h *Handler) DeleteWebhooksByEventType(c *gin.Context) {
+	// Required so a bare DELETE can never remove every webhook
+	eventType := c.Query("event_type")
+	if eventType == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "event_type query parameter is required",
+		})
+		return
+	}
+	hard := c.Query("hard") == "true"
+
+	ids, err := h.dbFor(c).DeleteWebhooksByEventType(eventType, hard)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to delete webhooks")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete webhooks",
+		})
+		return
+	}
+	for _, id := range ids {
+		h.invalidateWebhookCache(c.Request.Context(), id)
+	}
+
+	h.recordAudit(c, "webhook.bulk_deleted", "webhook", eventType, map[string]interface{}{
+		"webhook_ids": ids,
+		"hard":        hard,
+	})
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Deleted %d webhooks", len(ids)),
+		Data:    map[string]interface{}{"deleted": len(ids)},
+	})
+}
+
+// @Summary Restore Webhook
+// @Description Restore a soft-deleted webhook by ID
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/restore [post]
+func (h *Handler) RestoreWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	result := h.dbFor(c).Unscoped().Model(&models.WebhookEndpoint{}).
+		Where("id = ? AND deleted_at IS NOT NULL", webhookID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		h.logger.WithError(result.Error).Error("Failed to restore webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to restore webhook",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "Deleted webhook not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Webhook restored successfully",
+	})
+}
+
+// @Summary Rotate Webhook Secret
+// @Description Promote the webhook's secret_next to its signing secret, ending a rotation
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.APIResponse{data=models.WebhookEndpoint}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/rotate-secret [post]
+func (h *Handler) RotateWebhookSecret(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	var webhook models.WebhookEndpoint
+	if err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Webhook not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to rotate webhook secret",
+		})
+		return
+	}
+
+	if webhook.SecretNext == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Webhook has no secret_next to promote",
+		})
+		return
+	}
+
+	// Only promote the secret that was read, in case it changed meanwhile
+	result := h.dbFor(c).Model(&models.WebhookEndpoint{}).
+		Where("id = ? AND secret_next = ?", webhookID, webhook.SecretNext).
+		Updates(map[string]interface{}{"secret": webhook.SecretNext, "secret_next": ""})
+	if result.Error != nil || result.RowsAffected == 0 {
+		h.logger.WithError(result.Error).Error("Failed to rotate webhook secret")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to rotate webhook secret",
+		})
+		return
+	}
+
+	h.invalidateWebhookCache(c.Request.Context(), webhookID)
+
+	webhook.Secret, webhook.SecretNext = webhook.SecretNext, ""
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Webhook secret rotated successfully",
+		Data:    webhook,
+	})
+}
+
+// @Summary Pause Webhook Event Types
+// @Description Stop delivering the given event types to the webhook without unsubscribing it. Events of a paused type
+// @Description get a delivery record with status "paused" and are not sent.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param request body models.WebhookEventTypesRequest true "Event types to pause"
+// @Success 200 {object} models.APIResponse{data=models.WebhookEndpoint}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/pause [post]
+func (h *Handler) PauseWebhookEventTypes(c *gin.Context) {
+	h.setPausedEventTypes(c, true)
+}
+
+// @Summary Resume Webhook Event Types
+// @Description Resume delivering event types paused on the webhook. Events that arrived while paused are not sent.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param request body models.WebhookEventTypesRequest true "Event types to resume"
+// @Success 200 {object} models.APIResponse{data=models.WebhookEndpoint}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/resume [post]
+func (h *Handler) ResumeWebhookEventTypes(c *gin.Context) {
+	h.setPausedEventTypes(c, false)
+}
+
+// setPausedEventTypes adds the requested event types to the webhook's paused
+// list, or removes them from it
+func (h *Handler) setPausedEventTypes(c *gin.Context, pause bool) {
+	webhookID := c.Param("id")
+
+	var req models.WebhookEventTypesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, bindingErrorResponse(err))
+		return
+	}
+
+	var webhook models.WebhookEndpoint
+	if err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Webhook not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to update paused event types",
+		})
+		return
+	}
+
+	requested := make(map[string]bool, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		requested[eventType] = true
+	}
+	paused := []string{}
+	for _, eventType := range webhook.PausedEventTypes {
+		if !requested[eventType] {
+			paused = append(paused, eventType)
+		}
+	}
+	if pause {
+		for _, eventType := range req.EventTypes {
+			if requested[eventType] {
+				paused = append(paused, eventType)
+				delete(requested, eventType)
+			}
+		}
+	}
+	webhook.PausedEventTypes = paused
+
+	// Update from the struct so the list goes through its JSON serializer
+	if err := h.dbFor(c).Model(&webhook).Select("paused_event_types").Updates(&webhook).Error; err != nil {
+		h.logger.WithError(err).Error("Failed to update paused event types")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to update paused event types",
+		})
+		return
+	}
+
+	h.invalidateWebhookCache(c.Request.Context(), webhookID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    webhook,
+	})
+}
+
+// @Summary Get Webhook Delivery
+// @Description Get a single delivery, including the receiver's response and any error, with its webhook and event
+// @Tags webhooks
+// @Produce json
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} models.APIResponse{data=models.WebhookDelivery}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/deliveries/{delivery_id} [get]
+func (h *Handler) GetWebhookDelivery(c *gin.Context) {
+	deliveryID := c.Param("delivery_id")
+
+	delivery, err := h.dbFor(c).GetWebhookDeliveryWithRelations(deliveryID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Delivery not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook delivery")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get delivery",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    delivery,
+	})
+}
+
+// @Summary Get Webhook Delivery Attempts
+// @Description Get every attempt of a delivery, oldest first, with each attempt's status code, response and error
+// @Tags webhooks
+// @Produce json
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} models.APIResponse{data=[]models.WebhookDeliveryAttempt}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/deliveries/{delivery_id}/attempts [get]
+func (h *Handler) GetWebhookDeliveryAttempts(c *gin.Context) {
+	deliveryID := c.Param("delivery_id")
+
+	var delivery models.WebhookDelivery
+	if err := h.dbFor(c).First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Delivery not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook delivery")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get delivery",
+		})
+		return
+	}
+
+	attempts, err := h.dbFor(c).GetDeliveryAttempts(deliveryID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get webhook delivery attempts")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get delivery attempts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempts,
+	})
+}
+
 // @Summary Get Webhook Deliveries
 // @Description Get delivery history for a webhook
 // @Tags webhooks
 // @Produce json
 // @Param id path string true "Webhook ID"
 // @Param limit query int false "Number of deliveries to return" default(50)
+// @Param status query string false "Only return deliveries with this status" Enums(success, failed, pending)
+// @Param since query string false "Only return deliveries created at or after this time (RFC3339)"
 // @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Router /api/v1/webhooks/{id}/deliveries [get]
 func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
@@ -259,7 +804,31 @@ func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
 		}
 	}
 
-	deliveries, err := h.db.GetWebhookDeliveriesWithRelations(webhookID, limit)
+	status := c.Query("status")
+	switch status {
+	case "", "success", "failed", "pending":
+	default:
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "status must be success, failed or pending",
+		})
+		return
+	}
+
+	var since time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "since must be an RFC3339 timestamp",
+			})
+			return
+		}
+		since = parsed
+	}
+
+	deliveries, err := h.dbFor(c).GetWebhookDeliveriesWithRelations(webhookID, status, since, limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get webhook deliveries")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -275,6 +844,44 @@ func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
 	})
 }
 
+// @Summary Test Webhook
+// @Description Synchronously send a sample event to a webhook and report the receiver's response. No event or delivery is stored.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.APIResponse{data=models.WebhookTestResult}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/test [post]
+func (h *Handler) TestWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	var webhook models.WebhookEndpoint
+	err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Webhook not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get webhook",
+		})
+		return
+	}
+
+	result := h.eventManager.GetWebhookDeliveryService().SendTestDelivery(c.Request.Context(), webhook)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
 // @Summary Retry Webhook Deliveries
 // @Description Manually retry failed webhook deliveries
 // @Tags webhooks
@@ -301,6 +908,114 @@ func (h *Handler) RetryWebhookDeliveries(c *gin.Context) {
 	})
 }
 
+// @Summary Retry Deliveries for a Webhook
+// @Description Retry one webhook's failed deliveries, and pending ones whose retry is due, in the background
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/retry [post]
+func (h *Handler) RetryWebhookDeliveriesForWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	var webhook models.WebhookEndpoint
+	if err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Webhook not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get webhook",
+		})
+		return
+	}
+
+	deliveryService := h.eventManager.GetWebhookDeliveryService()
+
+	queued, err := deliveryService.RetryFailedDeliveriesForWebhook(c.Request.Context(), webhookID)
+	if err != nil {
+		h.logger.WithError(err).WithField("webhook_id", webhookID).Error("Failed to retry webhook deliveries")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to retry deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Delivery retry initiated",
+		Data: map[string]interface{}{
+			"webhook_id": webhookID,
+			"queued":     queued,
+		},
+	})
+}
+
+// @Summary Reconcile Stuck Deliveries
+// @Description Requeue pending deliveries stuck by a crash mid-attempt, or mark them failed once their retries are used up
+// @Tags webhooks
+// @Produce json
+// @Param older_than query string false "Only deliveries not updated for this long (Go duration)" default(5m)
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/deliveries/reconcile [post]
+func (h *Handler) ReconcileWebhookDeliveries(c *gin.Context) {
+	olderThan := 5 * time.Minute
+	if value := c.Query("older_than"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "older_than must be a non-negative duration such as 5m",
+			})
+			return
+		}
+		olderThan = parsed
+	}
+
+	deliveryService := h.eventManager.GetWebhookDeliveryService()
+
+	requeued, failed, err := deliveryService.ReconcileStuckDeliveries(c.Request.Context(), time.Now().Add(-olderThan))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to reconcile webhook deliveries")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to reconcile deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Stuck deliveries reconciled",
+		Data: map[string]interface{}{
+			"requeued": requeued,
+			"failed":   failed,
+		},
+	})
+}
+
+// GetWebhookPayloadSchema godoc
+// @Summary Get Webhook Payload Schema
+// @Description Get the JSON Schema of the default webhook delivery payload
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/webhooks/payload-schema [get]
+func (h *Handler) GetWebhookPayloadSchema(c *gin.Context) {
+	// Served as a bare schema document so it can be fed straight to validators
+	c.JSON(http.StatusOK, events.PayloadSchema())
+}
+
 // @Summary Get Webhook Delivery Statistics
 // @Description Get statistics about webhook deliveries
 // @Tags webhooks
@@ -315,10 +1030,13 @@ func (h *Handler) GetWebhookStats(c *gin.Context) {
 		FailedDeliveries     int64   `json:"failed_deliveries"`
 		PendingDeliveries    int64   `json:"pending_deliveries"`
 		SuccessRate          float64 `json:"success_rate"`
+
+		// Keyed by webhook ID, for webhooks with a max_retries_per_day
+		RetryBudgets map[string]models.RetryBudget `json:"retry_budgets"`
 	}
 
 	// Get total deliveries
-	err := h.db.Model(&models.WebhookDelivery{}).Count(&stats.TotalDeliveries).Error
+	err := h.dbFor(c).Model(&models.WebhookDelivery{}).Count(&stats.TotalDeliveries).Error
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get total deliveries")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -329,7 +1047,7 @@ func (h *Handler) GetWebhookStats(c *gin.Context) {
 	}
 
 	// Get successful deliveries
-	err = h.db.Model(&models.WebhookDelivery{}).Where("status = ?", "success").Count(&stats.SuccessfulDeliveries).Error
+	err = h.dbFor(c).Model(&models.WebhookDelivery{}).Where("status = ?", "success").Count(&stats.SuccessfulDeliveries).Error
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get successful deliveries")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -340,7 +1058,7 @@ func (h *Handler) GetWebhookStats(c *gin.Context) {
 	}
 
 	// Get failed deliveries
-	err = h.db.Model(&models.WebhookDelivery{}).Where("status = ?", "failed").Count(&stats.FailedDeliveries).Error
+	err = h.dbFor(c).Model(&models.WebhookDelivery{}).Where("status = ?", "failed").Count(&stats.FailedDeliveries).Error
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get failed deliveries")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -351,7 +1069,7 @@ func (h *Handler) GetWebhookStats(c *gin.Context) {
 	}
 
 	// Get pending deliveries
-	err = h.db.Model(&models.WebhookDelivery{}).Where("status = ?", "pending").Count(&stats.PendingDeliveries).Error
+	err = h.dbFor(c).Model(&models.WebhookDelivery{}).Where("status = ?", "pending").Count(&stats.PendingDeliveries).Error
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get pending deliveries")
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -366,15 +1084,148 @@ func (h *Handler) GetWebhookStats(c *gin.Context) {
 		stats.SuccessRate = float64(stats.SuccessfulDeliveries) / float64(stats.TotalDeliveries) * 100
 	}
 
+	var budgeted []models.WebhookEndpoint
+	err = h.dbFor(c).Where("max_retries_per_day > ?", 0).Find(&budgeted).Error
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get webhooks with a retry budget")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+	deliveryService := h.eventManager.GetWebhookDeliveryService()
+	stats.RetryBudgets = make(map[string]models.RetryBudget, len(budgeted))
+	for _, webhook := range budgeted {
+		stats.RetryBudgets[webhook.ID] = deliveryService.RetryBudget(webhook)
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    stats,
 	})
 }
 
+// @Summary Get Webhook Delivery Statistics for a Webhook
+// @Description Get one webhook's delivery totals, success rate, average attempt count and last delivery time
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.APIResponse{data=models.WebhookStats}
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/{id}/stats [get]
+func (h *Handler) GetWebhookStatsForWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+
+	var webhook models.WebhookEndpoint
+	if err := h.dbFor(c).First(&webhook, "id = ?", webhookID).Error; err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, models.APIResponse{
+				Success: false,
+				Error:   "Webhook not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get webhook")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get webhook",
+		})
+		return
+	}
+
+	stats, err := h.dbFor(c).GetWebhookStats(webhookID)
+	if err != nil {
+		h.logger.WithError(err).WithField("webhook_id", webhookID).Error("Failed to get webhook stats")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+	if webhook.MaxRetriesPerDay > 0 {
+		budget := h.eventManager.GetWebhookDeliveryService().RetryBudget(webhook)
+		stats.RetryBudget = &budget
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// @Summary Get Webhook Delivery Time Series
+// @Description Get webhook delivery counts and success rates bucketed by time, oldest first. Buckets without deliveries are omitted.
+// @Tags webhooks
+// @Produce json
+// @Param bucket query string false "Bucket size: hour or day" default(hour)
+// @Param from query string false "Start of the range (RFC3339), defaults to 24 hours before to"
+// @Param to query string false "End of the range (RFC3339), defaults to now"
+// @Param webhook_id query string false "Only include deliveries for this webhook"
+// @Success 200 {object} models.APIResponse{data=[]models.DeliveryStatsBucket}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/webhooks/stats/timeseries [get]
+func (h *Handler) GetWebhookStatsTimeSeries(c *gin.Context) {
+	bucket := c.DefaultQuery("bucket", "hour")
+	if bucket != "hour" && bucket != "day" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "bucket must be hour or day",
+		})
+		return
+	}
+
+	from, to, ok := parseTimeSeriesRange(c)
+	if !ok {
+		return
+	}
+
+	buckets, err := h.dbFor(c).GetDeliveryTimeSeries(bucket, from, to, c.Query("webhook_id"))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get delivery time series")
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to get statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    buckets,
+	})
+}
+
+// validateWebhookURLs rejects webhook, OAuth token and proxy URLs that point
+// at internal addresses unless WEBHOOK_ALLOW_PRIVATE is set. Empty URLs (not
+// being updated) are skipped.
+func (h *Handler) validateWebhookURLs(ctx context.Context, webhookURL string, oauth *models.OAuthClientCredentials, proxyURL string) error {
+	guard := events.NewURLGuard(h.config.Webhook.AllowPrivate)
+
+	if webhookURL != "" {
+		if err := guard.ValidateURL(ctx, webhookURL); err != nil {
+			return fmt.Errorf("webhook URL is not allowed: %w", err)
+		}
+	}
+	if oauth != nil {
+		if err := guard.ValidateURL(ctx, oauth.TokenURL); err != nil {
+			return fmt.Errorf("OAuth token URL is not allowed: %w", err)
+		}
+	}
+	if proxyURL != "" {
+		if err := guard.ValidateProxyURL(ctx, proxyURL); err != nil {
+			return fmt.Errorf("proxy URL is not allowed: %w", err)
+		}
+	}
+	return nil
+}
+
 // Helper function to generate IDs
 func generateID() string {
-	// Generate a simple unique ID using timestamp and random component
-	timestamp := strconv.FormatInt(time.Now().UnixNano(), 36)
-	return timestamp[:8] + "x" // Simple but reasonably unique for webhook IDs
+	// Random so webhooks created in quick succession can't collide
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return fmt.Sprintf("wh_%x", bytes)
 }
\ No newline at end of file
@@ -2,9 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -73,6 +80,138 @@ func TestCreateWebhook(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectError:  true,
 		},
+		{
+			name: "max retries over the limit",
+			payload: map[string]interface{}{
+				"name":        "Retry Storm Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"max_retries": 1000,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "negative max retries",
+			payload: map[string]interface{}{
+				"name":        "Negative Retries Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"max_retries": -1,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "timeout over the limit",
+			payload: map[string]interface{}{
+				"name":            "Slow Webhook",
+				"url":             "https://example.com/webhook",
+				"secret":          "secret123",
+				"event_types":     []string{"test.event"},
+				"timeout_seconds": 3600,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "socks5 proxy",
+			payload: map[string]interface{}{
+				"name":        "Proxied Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"proxy_url":   "socks5://127.0.0.1:1080",
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "unsupported proxy scheme",
+			payload: map[string]interface{}{
+				"name":        "Proxied Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"proxy_url":   "ftp://127.0.0.1:21",
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "valid payload template",
+			payload: map[string]interface{}{
+				"name":             "Templated Webhook",
+				"url":              "https://example.com/webhook",
+				"secret":           "secret123",
+				"event_types":      []string{"test.event"},
+				"payload_template": `{"id": {{json .event_id}}}`,
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "invalid payload template",
+			payload: map[string]interface{}{
+				"name":             "Broken Template Webhook",
+				"url":              "https://example.com/webhook",
+				"secret":           "secret123",
+				"event_types":      []string{"test.event"},
+				"payload_template": `{"id": {{json .event_id}`,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "sha1 signature algorithm",
+			payload: map[string]interface{}{
+				"name":                "Legacy Webhook",
+				"url":                 "https://example.com/webhook",
+				"secret":              "secret123",
+				"event_types":         []string{"test.event"},
+				"signature_algorithm": "sha1",
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "unknown signature algorithm",
+			payload: map[string]interface{}{
+				"name":                "MD5 Webhook",
+				"url":                 "https://example.com/webhook",
+				"secret":              "secret123",
+				"event_types":         []string{"test.event"},
+				"signature_algorithm": "md5",
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
+		{
+			name: "put method",
+			payload: map[string]interface{}{
+				"name":        "PUT Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"method":      "PUT",
+			},
+			expectedCode: http.StatusCreated,
+			expectError:  false,
+		},
+		{
+			name: "unsupported method",
+			payload: map[string]interface{}{
+				"name":        "GET Webhook",
+				"url":         "https://example.com/webhook",
+				"secret":      "secret123",
+				"event_types": []string{"test.event"},
+				"method":      "GET",
+			},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +255,16 @@ func TestCreateWebhook(t *testing.T) {
 				if tt.payload["timeout_seconds"] == nil {
 					assert.Equal(t, 30, webhook.TimeoutSeconds)
 				}
+				if algorithm, ok := tt.payload["signature_algorithm"]; ok {
+					assert.Equal(t, algorithm, webhook.SignatureAlgorithm)
+				} else {
+					assert.Equal(t, "sha256", webhook.SignatureAlgorithm)
+				}
+				if method, ok := tt.payload["method"]; ok {
+					assert.Equal(t, method, webhook.Method)
+				} else {
+					assert.Equal(t, "POST", webhook.Method)
+				}
 			}
 
 			// Clean up for next test
@@ -124,6 +273,187 @@ func TestCreateWebhook(t *testing.T) {
 	}
 }
 
+func TestCreateWebhookValidationDetails(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks", handler.CreateWebhook)
+
+	payload := `{"name": "Bad Webhook", "url": "not a url", "event_types": ["test.event"], "signature_algorithm": "md5"}`
+	req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.False(t, response.Success)
+	assert.Equal(t, "Validation failed", response.Error)
+	assert.Equal(t, map[string]string{
+		"url":                 "must be a valid URL",
+		"secret":              "is required",
+		"signature_algorithm": "must be one of: sha1, sha256, sha512",
+	}, response.Details)
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBufferString(`{"name":`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.APIResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Error)
+		assert.Empty(t, response.Details)
+	})
+}
+
+func TestCreateWebhookUpsert(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks", handler.CreateWebhook)
+
+	post := func(query string, payload map[string]interface{}) (int, models.WebhookEndpoint) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/webhooks"+query, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data models.WebhookEndpoint `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	code, created := post("?upsert=true", map[string]interface{}{
+		"name":        "Provisioned Webhook",
+		"url":         "https://example.com/v1/webhook",
+		"secret":      "secret-v1",
+		"event_types": []string{"user.created"},
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	code, updated := post("?upsert=true", map[string]interface{}{
+		"name":            "Provisioned Webhook",
+		"url":             "https://example.com/v2/webhook",
+		"secret":          "secret-v2",
+		"event_types":     []string{"user.created", "user.deleted"},
+		"timeout_seconds": 10,
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, created.ID, updated.ID)
+
+	var webhooks []models.WebhookEndpoint
+	require.NoError(t, db.Where("name = ?", "Provisioned Webhook").Find(&webhooks).Error)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, created.ID, webhooks[0].ID)
+	assert.Equal(t, "https://example.com/v2/webhook", webhooks[0].URL)
+	assert.Equal(t, "secret-v2", webhooks[0].Secret)
+	assert.Equal(t, []string{"user.created", "user.deleted"}, webhooks[0].EventTypes)
+	assert.Equal(t, 10, webhooks[0].TimeoutSeconds)
+	assert.True(t, webhooks[0].Enabled)
+
+	// Without upsert the same name creates a second webhook
+	code, _ = post("", map[string]interface{}{
+		"name":        "Provisioned Webhook",
+		"url":         "https://example.com/v2/webhook",
+		"secret":      "secret-v2",
+		"event_types": []string{"user.created"},
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	var count int64
+	require.NoError(t, db.Model(&models.WebhookEndpoint{}).Where("name = ?", "Provisioned Webhook").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestCreateWebhookRejectsInternalURLs(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+	handler.config.Webhook.AllowPrivate = false
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks", handler.CreateWebhook)
+	router.PUT("/webhooks/:id", handler.UpdateWebhook)
+
+	for _, url := range []string{
+		"http://localhost/webhook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/webhook",
+	} {
+		t.Run(url, func(t *testing.T) {
+			payloadBytes, _ := json.Marshal(map[string]interface{}{
+				"name":        "Internal Webhook",
+				"url":         url,
+				"secret":      "secret",
+				"event_types": []string{"test.event"},
+			})
+			req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(payloadBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.APIResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Contains(t, response.Error, "webhook URL is not allowed")
+		})
+	}
+
+	t.Run("update to an internal URL is rejected", func(t *testing.T) {
+		webhook := models.WebhookEndpoint{
+			ID:         "public-webhook",
+			Name:       "Public Webhook",
+			URL:        "https://93.184.216.34/webhook",
+			Secret:     "secret",
+			EventTypes: []string{"test.event"},
+			Enabled:    true,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+
+		payloadBytes, _ := json.Marshal(map[string]interface{}{"url": "http://169.254.169.254/"})
+		req, _ := http.NewRequest("PUT", "/webhooks/public-webhook", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var stored models.WebhookEndpoint
+		require.NoError(t, db.First(&stored, "id = ?", "public-webhook").Error)
+		assert.Equal(t, "https://93.184.216.34/webhook", stored.URL)
+	})
+
+	t.Run("public address is allowed", func(t *testing.T) {
+		payloadBytes, _ := json.Marshal(map[string]interface{}{
+			"name":        "Public Webhook",
+			"url":         "https://93.184.216.34/webhook",
+			"secret":      "secret",
+			"event_types": []string{"test.event"},
+		})
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
 func TestGetWebhooks(t *testing.T) {
 	handler, db := setupTestHandler(t)
 	defer db.Close()
@@ -178,11 +508,33 @@ func TestGetWebhooks(t *testing.T) {
 	assert.True(t, response.Success)
 	
 	dataBytes, _ := json.Marshal(response.Data)
+	var page models.PaginatedResponse
 	var responseWebhooks []models.WebhookEndpoint
-	err = json.Unmarshal(dataBytes, &responseWebhooks)
+	page.Items = &responseWebhooks
+	err = json.Unmarshal(dataBytes, &page)
 	require.NoError(t, err)
-	
+
 	assert.Len(t, responseWebhooks, 2)
+	assert.Equal(t, int64(2), page.Total)
+	assert.False(t, page.HasMore)
+
+	// A smaller page reports that more webhooks are available
+	req, _ = http.NewRequest("GET", "/webhooks?limit=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	dataBytes, _ = json.Marshal(response.Data)
+	var pagedWebhooks []models.WebhookEndpoint
+	page = models.PaginatedResponse{Items: &pagedWebhooks}
+	err = json.Unmarshal(dataBytes, &page)
+	require.NoError(t, err)
+
+	assert.Len(t, pagedWebhooks, 1)
+	assert.True(t, page.HasMore)
 	
 	// Verify webhook data
 	webhookNames := make(map[string]bool)
@@ -329,6 +681,13 @@ func TestUpdateWebhook(t *testing.T) {
 			expectedCode: http.StatusNotFound,
 			expectError:  true,
 		},
+		{
+			name:         "max retries over the limit",
+			webhookID:    "test-webhook-123",
+			payload:      map[string]interface{}{"max_retries": 1000},
+			expectedCode: http.StatusBadRequest,
+			expectError:  true,
+		},
 		{
 			name:         "empty update payload",
 			webhookID:    "test-webhook-123",
@@ -455,107 +814,775 @@ func TestDeleteWebhook(t *testing.T) {
 	}
 }
 
-func TestGetWebhookStats(t *testing.T) {
+func TestSoftDeleteAndRestoreWebhook(t *testing.T) {
 	handler, db := setupTestHandler(t)
 	defer db.Close()
 
-	// Create test webhook
-	webhook := models.WebhookEndpoint{
-		ID:             "test-webhook",
-		Name:           "Test Webhook",
-		URL:            "https://example.com/webhook",
-		Secret:         "secret",
-		EventTypes:     []string{"test.event"},
-		Enabled:        true,
-		MaxRetries:     3,
-		TimeoutSeconds: 30,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+	for _, id := range []string{"webhook-keep", "webhook-delete"} {
+		webhook := models.WebhookEndpoint{
+			ID:             id,
+			Name:           "Test Webhook",
+			URL:            "https://example.com/webhook",
+			Secret:         "secret123",
+			EventTypes:     []string{"user.created"},
+			Enabled:        true,
+			MaxRetries:     3,
+			TimeoutSeconds: 30,
+		}
+		err := db.Create(&webhook).Error
+		require.NoError(t, err)
 	}
-	err := db.Create(&webhook).Error
-	require.NoError(t, err)
 
-	// Create test event
-	event := models.Event{
-		ID:             "test-event",
-		Type:           "test.event",
-		StreamID:       "test-stream",
-		Source:         "test",
-		Data:           models.JSON{"test": "data"},
-		SequenceNumber: 1,
-		CreatedAt:      time.Now(),
+	delivery := models.WebhookDelivery{
+		ID:           "delivery-1",
+		WebhookID:    "webhook-delete",
+		EventID:      "event-1",
+		Status:       "success",
+		AttemptCount: 1,
 	}
-	err = db.CreateEventWithSequence(&event)
+	err := db.Create(&delivery).Error
 	require.NoError(t, err)
 
-	// Create test deliveries with different statuses
-	deliveries := []models.WebhookDelivery{
-		{
-			ID:           "delivery-1",
-			WebhookID:    webhook.ID,
-			EventID:      event.ID,
-			Status:       "success",
-			AttemptCount: 1,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "delivery-2",
-			WebhookID:    webhook.ID,
-			EventID:      event.ID,
-			Status:       "failed",
-			AttemptCount: 3,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		},
-		{
-			ID:           "delivery-3",
-			WebhookID:    webhook.ID,
-			EventID:      event.ID,
-			Status:       "pending",
-			AttemptCount: 1,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		},
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks", handler.GetWebhooks)
+	router.DELETE("/webhooks/:id", handler.DeleteWebhook)
+	router.POST("/webhooks/:id/restore", handler.RestoreWebhook)
+
+	listWebhookIDs := func(t *testing.T, query string) []string {
+		req, _ := http.NewRequest("GET", "/webhooks"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data struct {
+				Items []models.WebhookEndpoint `json:"items"`
+				Total int64                    `json:"total"`
+			} `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(response.Data.Items)), response.Data.Total)
+
+		var ids []string
+		for _, webhook := range response.Data.Items {
+			ids = append(ids, webhook.ID)
+		}
+		return ids
 	}
 
-	for _, delivery := range deliveries {
-		err := db.Create(&delivery).Error
-		require.NoError(t, err)
+	request := func(method, path string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Soft delete hides the webhook but keeps the row and its deliveries
+	assert.Equal(t, http.StatusOK, request("DELETE", "/webhooks/webhook-delete"))
+	assert.ElementsMatch(t, []string{"webhook-keep"}, listWebhookIDs(t, ""))
+	assert.ElementsMatch(t, []string{"webhook-keep", "webhook-delete"}, listWebhookIDs(t, "?include_deleted=true"))
+
+	var count int64
+	db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", "webhook-delete").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Deleting an already soft-deleted webhook is not found
+	assert.Equal(t, http.StatusNotFound, request("DELETE", "/webhooks/webhook-delete"))
+
+	// Restore brings it back; restoring a live webhook is not found
+	assert.Equal(t, http.StatusOK, request("POST", "/webhooks/webhook-delete/restore"))
+	assert.ElementsMatch(t, []string{"webhook-keep", "webhook-delete"}, listWebhookIDs(t, ""))
+	assert.Equal(t, http.StatusNotFound, request("POST", "/webhooks/webhook-delete/restore"))
+	assert.Equal(t, http.StatusNotFound, request("POST", "/webhooks/non-existent-id/restore"))
+
+	// Hard delete removes the row permanently, even after a soft delete
+	assert.Equal(t, http.StatusOK, request("DELETE", "/webhooks/webhook-delete"))
+	assert.Equal(t, http.StatusOK, request("DELETE", "/webhooks/webhook-delete?hard=true"))
+	db.Unscoped().Model(&models.WebhookEndpoint{}).Where("id = ?", "webhook-delete").Count(&count)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, http.StatusNotFound, request("POST", "/webhooks/webhook-delete/restore"))
+}
+func TestDeleteWebhooksByEventType(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	subscriptions := map[string][]string{
+		"webhook-orders":  {"order.created"},
+		"webhook-overlap": {"order.created", "user.created"},
+		"webhook-users":   {"user.created"},
+		"webhook-pattern": {"order.*"},
+		"webhook-similar": {"order.created.v2"},
+		"webhook-billing": {"billing.paid", "order.created"},
+	}
+	for id, types := range subscriptions {
+		webhook := models.WebhookEndpoint{
+			ID:             id,
+			Name:           "Test Webhook",
+			URL:            "https://example.com/webhook",
+			Secret:         "secret123",
+			EventTypes:     types,
+			Enabled:        true,
+			MaxRetries:     3,
+			TimeoutSeconds: 30,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
 	}
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	router.GET("/webhooks/stats", handler.GetWebhookStats)
+	router.DELETE("/webhooks", handler.DeleteWebhooksByEventType)
+
+	send := func(query string) (int, models.APIResponse) {
+		req, _ := http.NewRequest("DELETE", "/webhooks"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response models.APIResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
 
-	req, _ := http.NewRequest("GET", "/webhooks/stats", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	code, response := send("")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.False(t, response.Success)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	code, response = send("?event_type=order.created")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(3), response.Data.(map[string]interface{})["deleted"])
 
-	var response models.APIResponse
-	err = json.Unmarshal(w.Body.Bytes(), &response)
-	require.NoError(t, err)
+	var remaining []string
+	require.NoError(t, db.Model(&models.WebhookEndpoint{}).Order("id").Pluck("id", &remaining).Error)
+	assert.Equal(t, []string{"webhook-pattern", "webhook-similar", "webhook-users"}, remaining)
 
-	assert.True(t, response.Success)
+	// Soft-deleted webhooks are kept and can be purged with hard=true
+	var softDeleted int64
+	require.NoError(t, db.Unscoped().Model(&models.WebhookEndpoint{}).Where("deleted_at IS NOT NULL").Count(&softDeleted).Error)
+	assert.Equal(t, int64(3), softDeleted)
 
-	dataBytes, _ := json.Marshal(response.Data)
-	var stats struct {
-		TotalDeliveries      int64   `json:"total_deliveries"`
-		SuccessfulDeliveries int64   `json:"successful_deliveries"`
-		FailedDeliveries     int64   `json:"failed_deliveries"`
-		PendingDeliveries    int64   `json:"pending_deliveries"`
-		SuccessRate          float64 `json:"success_rate"`
-	}
-	err = json.Unmarshal(dataBytes, &stats)
-	require.NoError(t, err)
+	code, response = send("?event_type=order.created&hard=true")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(3), response.Data.(map[string]interface{})["deleted"])
 
-	assert.Equal(t, int64(3), stats.TotalDeliveries)
-	assert.Equal(t, int64(1), stats.SuccessfulDeliveries)
-	assert.Equal(t, int64(1), stats.FailedDeliveries)
-	assert.Equal(t, int64(1), stats.PendingDeliveries)
-	assert.InDelta(t, 33.33, stats.SuccessRate, 0.1) // 1/3 = 33.33%
+	var total int64
+	require.NoError(t, db.Unscoped().Model(&models.WebhookEndpoint{}).Count(&total).Error)
+	assert.Equal(t, int64(3), total)
+
+	code, response = send("?event_type=order.created")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), response.Data.(map[string]interface{})["deleted"])
+}
+
+func TestGetWebhookDelivery(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "webhook-1",
+		Name:           "Debug Webhook",
+		URL:            "https://example.com/webhook",
+		Secret:         "secret",
+		EventTypes:     []string{"test.event"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 30,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	event := models.Event{
+		ID:       "event-1",
+		Type:     "test.event",
+		StreamID: "test-stream",
+		Source:   "test",
+		Data:     models.JSON{"test": "data"},
+	}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	delivery := models.WebhookDelivery{
+		ID:           "del_abc123",
+		WebhookID:    webhook.ID,
+		EventID:      event.ID,
+		Status:       "failed",
+		AttemptCount: 3,
+		Response:     `{"error":"upstream unavailable"}`,
+		ErrorMessage: "webhook returned status 503",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/:id", handler.GetWebhook)
+	router.GET("/webhooks/deliveries/:delivery_id", handler.GetWebhookDelivery)
+
+	t.Run("delivery with webhook and event", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/webhooks/deliveries/del_abc123", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.WebhookDelivery `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, "del_abc123", response.Data.ID)
+		assert.Equal(t, "failed", response.Data.Status)
+		assert.Equal(t, `{"error":"upstream unavailable"}`, response.Data.Response)
+		assert.Equal(t, "webhook returned status 503", response.Data.ErrorMessage)
+		require.NotNil(t, response.Data.Webhook)
+		assert.Equal(t, "Debug Webhook", response.Data.Webhook.Name)
+		require.NotNil(t, response.Data.Event)
+		assert.Equal(t, "test.event", response.Data.Event.Type)
+	})
+
+	t.Run("soft-deleted webhook is still included", func(t *testing.T) {
+		require.NoError(t, db.Delete(&webhook).Error)
+
+		req, _ := http.NewRequest("GET", "/webhooks/deliveries/del_abc123", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.WebhookDelivery `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Data.Webhook)
+		assert.Equal(t, webhook.ID, response.Data.Webhook.ID)
+	})
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/webhooks/deliveries/missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetWebhookDeliveriesFiltering(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "test-webhook",
+		Name:           "Test Webhook",
+		URL:            "https://example.com/webhook",
+		Secret:         "secret",
+		EventTypes:     []string{"test.event"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 30,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	event := models.Event{
+		ID:       "test-event",
+		Type:     "test.event",
+		StreamID: "test-stream",
+		Source:   "test",
+		Data:     models.JSON{"test": "data"},
+	}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	base := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	for i, status := range []string{"success", "failed", "pending", "failed", "success", "failed"} {
+		at := base.Add(time.Duration(i) * time.Hour)
+		delivery := models.WebhookDelivery{
+			ID:        fmt.Sprintf("delivery-%d", i),
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Status:    status,
+			CreatedAt: at,
+			UpdatedAt: at,
+		}
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/:id/deliveries", handler.GetWebhookDeliveries)
+
+	get := func(query string) (int, []models.WebhookDelivery) {
+		req, _ := http.NewRequest("GET", "/webhooks/test-webhook/deliveries?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []models.WebhookDelivery `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	ids := func(deliveries []models.WebhookDelivery) []string {
+		result := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			result = append(result, delivery.ID)
+		}
+		return result
+	}
+
+	t.Run("no filter returns all statuses", func(t *testing.T) {
+		code, deliveries := get("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, deliveries, 6)
+	})
+
+	t.Run("status=failed returns only failures", func(t *testing.T) {
+		code, deliveries := get("status=failed")
+		assert.Equal(t, http.StatusOK, code)
+		require.Len(t, deliveries, 3)
+		for _, delivery := range deliveries {
+			assert.Equal(t, "failed", delivery.Status)
+		}
+		assert.Equal(t, []string{"delivery-5", "delivery-3", "delivery-1"}, ids(deliveries))
+	})
+
+	t.Run("since narrows the window", func(t *testing.T) {
+		code, deliveries := get("status=failed&since=2025-06-01T15:00:00Z")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"delivery-5", "delivery-3"}, ids(deliveries))
+	})
+
+	t.Run("invalid filters are rejected", func(t *testing.T) {
+		code, _ := get("status=broken")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = get("since=yesterday")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestTestWebhook(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	type receivedRequest struct {
+		payload   map[string]interface{}
+		body      []byte
+		signature string
+	}
+	received := make(chan receivedRequest, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		json.Unmarshal(body, &payload)
+		received <- receivedRequest{payload: payload, body: body, signature: r.Header.Get("X-Webhook-Signature")}
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"received": true}`))
+	}))
+	defer receiver.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "test-webhook-123",
+		Name:           "Test Webhook",
+		URL:            receiver.URL,
+		Secret:         "secret123",
+		EventTypes:     []string{"order.placed", "order.shipped"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 5,
+	}
+	err := db.Create(&webhook).Error
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks/:id/test", handler.TestWebhook)
+
+	req, _ := http.NewRequest("POST", "/webhooks/test-webhook-123/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool                     `json:"success"`
+		Data    models.WebhookTestResult `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.True(t, response.Data.Success)
+	assert.Equal(t, http.StatusAccepted, response.Data.StatusCode)
+	assert.Equal(t, `{"received": true}`, response.Data.Response)
+
+	// The receiver got a sample of the webhook's first event type, signed
+	// with its secret
+	var got receivedRequest
+	select {
+	case got = <-received:
+	default:
+		t.Fatal("webhook request was not received")
+	}
+	assert.Equal(t, "order.placed", got.payload["event_type"])
+	assert.Equal(t, response.Data.Event.ID, got.payload["event_id"])
+
+	mac := hmac.New(sha256.New, []byte("secret123"))
+	mac.Write(got.body)
+	expectedSignature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	assert.Equal(t, expectedSignature, got.signature)
+	assert.Equal(t, expectedSignature, response.Data.Signature)
+
+	// Nothing is persisted for a test delivery
+	var eventCount, deliveryCount int64
+	db.Model(&models.Event{}).Count(&eventCount)
+	db.Model(&models.WebhookDelivery{}).Count(&deliveryCount)
+	assert.Equal(t, int64(0), eventCount)
+	assert.Equal(t, int64(0), deliveryCount)
+
+	t.Run("non-existent webhook", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/non-existent-id/test", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetWebhookStats(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	// Create test webhook
+	webhook := models.WebhookEndpoint{
+		ID:             "test-webhook",
+		Name:           "Test Webhook",
+		URL:            "https://example.com/webhook",
+		Secret:         "secret",
+		EventTypes:     []string{"test.event"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 30,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+
+		MaxRetriesPerDay: 5,
+	}
+	err := db.Create(&webhook).Error
+	require.NoError(t, err)
+
+	// Create test event
+	event := models.Event{
+		ID:             "test-event",
+		Type:           "test.event",
+		StreamID:       "test-stream",
+		Source:         "test",
+		Data:           models.JSON{"test": "data"},
+		SequenceNumber: 1,
+		CreatedAt:      time.Now(),
+	}
+	err = db.CreateEventWithSequence(&event)
+	require.NoError(t, err)
+
+	// Create test deliveries with different statuses
+	deliveries := []models.WebhookDelivery{
+		{
+			ID:           "delivery-1",
+			WebhookID:    webhook.ID,
+			EventID:      event.ID,
+			Status:       "success",
+			AttemptCount: 1,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		},
+		{
+			ID:           "delivery-2",
+			WebhookID:    webhook.ID,
+			EventID:      event.ID,
+			Status:       "failed",
+			AttemptCount: 3,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		},
+		{
+			ID:           "delivery-3",
+			WebhookID:    webhook.ID,
+			EventID:      event.ID,
+			Status:       "pending",
+			AttemptCount: 1,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		},
+	}
+
+	for _, delivery := range deliveries {
+		err := db.Create(&delivery).Error
+		require.NoError(t, err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/stats", handler.GetWebhookStats)
+
+	req, _ := http.NewRequest("GET", "/webhooks/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	assert.True(t, response.Success)
+
+	dataBytes, _ := json.Marshal(response.Data)
+	var stats struct {
+		TotalDeliveries      int64   `json:"total_deliveries"`
+		SuccessfulDeliveries int64   `json:"successful_deliveries"`
+		FailedDeliveries     int64   `json:"failed_deliveries"`
+		PendingDeliveries    int64   `json:"pending_deliveries"`
+		SuccessRate          float64 `json:"success_rate"`
+
+		RetryBudgets map[string]models.RetryBudget `json:"retry_budgets"`
+	}
+	err = json.Unmarshal(dataBytes, &stats)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), stats.TotalDeliveries)
+	assert.Equal(t, int64(1), stats.SuccessfulDeliveries)
+	assert.Equal(t, int64(1), stats.FailedDeliveries)
+	assert.Equal(t, int64(1), stats.PendingDeliveries)
+	assert.InDelta(t, 33.33, stats.SuccessRate, 0.1) // 1/3 = 33.33%
+
+	assert.Equal(t, map[string]models.RetryBudget{
+		"test-webhook": {Limit: 5, Remaining: 5},
+	}, stats.RetryBudgets)
+}
+
+func TestGetWebhookStatsForWebhook(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	for _, id := range []string{"webhook-a", "webhook-b"} {
+		webhook := models.WebhookEndpoint{
+			ID:         id,
+			Name:       id,
+			URL:        "https://example.com/" + id,
+			Secret:     "secret",
+			EventTypes: []string{"test.event"},
+			Enabled:    true,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+	require.NoError(t, db.Model(&models.WebhookEndpoint{}).Where("id = ?", "webhook-b").Update("max_retries_per_day", 10).Error)
+
+	event := models.Event{ID: "event-1", Type: "test.event", StreamID: "stats-stream", Source: "test", Timestamp: time.Now()}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	last := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		webhookID string
+		status    string
+		attempts  int
+		at        time.Time
+	}{
+		{"webhook-a", "success", 1, last.Add(-2 * time.Hour)},
+		{"webhook-a", "success", 2, last},
+		{"webhook-a", "failed", 3, last.Add(-time.Hour)},
+		{"webhook-a", "pending", 0, time.Time{}},
+		{"webhook-b", "failed", 5, last.Add(time.Hour)},
+	}
+	for i, seed := range seeds {
+		delivery := models.WebhookDelivery{
+			ID:           fmt.Sprintf("delivery-%d", i),
+			WebhookID:    seed.webhookID,
+			EventID:      event.ID,
+			Status:       seed.status,
+			AttemptCount: seed.attempts,
+		}
+		if !seed.at.IsZero() {
+			at := seed.at
+			delivery.LastAttempt = &at
+		}
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/:id/stats", handler.GetWebhookStatsForWebhook)
+
+	get := func(id string) (int, models.WebhookStats) {
+		req, _ := http.NewRequest("GET", "/webhooks/"+id+"/stats", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data models.WebhookStats `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	code, stats := get("webhook-a")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "webhook-a", stats.WebhookID)
+	assert.Equal(t, int64(4), stats.TotalDeliveries)
+	assert.Equal(t, int64(2), stats.SuccessfulDeliveries)
+	assert.Equal(t, int64(1), stats.FailedDeliveries)
+	assert.Equal(t, int64(1), stats.PendingDeliveries)
+	assert.InDelta(t, 50.0, stats.SuccessRate, 0.01)
+	assert.InDelta(t, 1.5, stats.AverageAttempts, 0.01)
+	require.NotNil(t, stats.LastDeliveryAt)
+	assert.True(t, last.Equal(*stats.LastDeliveryAt))
+	assert.Nil(t, stats.RetryBudget)
+
+	code, stats = get("webhook-b")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(1), stats.TotalDeliveries)
+	assert.Equal(t, int64(1), stats.FailedDeliveries)
+	assert.InDelta(t, 0.0, stats.SuccessRate, 0.01)
+	assert.InDelta(t, 5.0, stats.AverageAttempts, 0.01)
+	require.NotNil(t, stats.LastDeliveryAt)
+	assert.True(t, last.Add(time.Hour).Equal(*stats.LastDeliveryAt))
+	require.NotNil(t, stats.RetryBudget)
+	assert.Equal(t, 10, stats.RetryBudget.Remaining)
+
+	t.Run("webhook without deliveries", func(t *testing.T) {
+		require.NoError(t, db.Create(&models.WebhookEndpoint{
+			ID: "webhook-c", Name: "webhook-c", URL: "https://example.com/c", Secret: "secret", EventTypes: []string{"test.event"}, Enabled: true,
+		}).Error)
+
+		code, stats := get("webhook-c")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(0), stats.TotalDeliveries)
+		assert.Nil(t, stats.LastDeliveryAt)
+	})
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		code, _ := get("missing")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
+
+func TestGetWebhookStatsTimeSeries(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	for _, id := range []string{"webhook-a", "webhook-b"} {
+		webhook := models.WebhookEndpoint{
+			ID:             id,
+			Name:           id,
+			URL:            "https://example.com/webhook",
+			Secret:         "secret",
+			EventTypes:     []string{"test.event"},
+			Enabled:        true,
+			MaxRetries:     3,
+			TimeoutSeconds: 30,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+
+	event := models.Event{
+		ID:       "test-event",
+		Type:     "test.event",
+		StreamID: "test-stream",
+		Source:   "test",
+		Data:     models.JSON{"test": "data"},
+	}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	base := time.Date(2025, time.June, 1, 10, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		webhookID string
+		status    string
+		at        time.Time
+	}{
+		{"webhook-a", "success", base.Add(-30 * time.Minute)}, // before the range
+		{"webhook-a", "success", base.Add(5 * time.Minute)},
+		{"webhook-a", "success", base.Add(20 * time.Minute)},
+		{"webhook-a", "success", base.Add(45 * time.Minute)},
+		{"webhook-a", "failed", base.Add(59 * time.Minute)},
+		{"webhook-a", "success", base.Add(time.Hour)},
+		{"webhook-b", "failed", base.Add(90 * time.Minute)},
+		{"webhook-b", "success", base.Add(3*time.Hour + 10*time.Minute)},
+		{"webhook-b", "success", base.Add(4 * time.Hour)}, // at "to", excluded
+	}
+	for i, seed := range seeds {
+		delivery := models.WebhookDelivery{
+			ID:        fmt.Sprintf("delivery-%d", i),
+			WebhookID: seed.webhookID,
+			EventID:   event.ID,
+			Status:    seed.status,
+			CreatedAt: seed.at,
+			UpdatedAt: seed.at,
+		}
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/stats/timeseries", handler.GetWebhookStatsTimeSeries)
+
+	get := func(query string) (int, []models.DeliveryStatsBucket) {
+		req, _ := http.NewRequest("GET", "/webhooks/stats/timeseries?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Data []models.DeliveryStatsBucket `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Data
+	}
+
+	rangeQuery := "from=2025-06-01T10:00:00Z&to=2025-06-01T14:00:00Z"
+
+	t.Run("hourly buckets across webhooks", func(t *testing.T) {
+		code, buckets := get("bucket=hour&" + rangeQuery)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, buckets, 3)
+
+		assert.True(t, base.Equal(buckets[0].BucketStart))
+		assert.Equal(t, int64(4), buckets[0].Total)
+		assert.Equal(t, int64(3), buckets[0].Success)
+		assert.InDelta(t, 75.0, buckets[0].SuccessRate, 0.01)
+
+		assert.True(t, base.Add(time.Hour).Equal(buckets[1].BucketStart))
+		assert.Equal(t, int64(2), buckets[1].Total)
+		assert.InDelta(t, 50.0, buckets[1].SuccessRate, 0.01)
+
+		assert.True(t, base.Add(3*time.Hour).Equal(buckets[2].BucketStart))
+		assert.Equal(t, int64(1), buckets[2].Total)
+		assert.InDelta(t, 100.0, buckets[2].SuccessRate, 0.01)
+	})
+
+	t.Run("scoped to one webhook", func(t *testing.T) {
+		code, buckets := get("bucket=hour&webhook_id=webhook-b&" + rangeQuery)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, buckets, 2)
+
+		assert.True(t, base.Add(time.Hour).Equal(buckets[0].BucketStart))
+		assert.InDelta(t, 0.0, buckets[0].SuccessRate, 0.01)
+		assert.True(t, base.Add(3*time.Hour).Equal(buckets[1].BucketStart))
+		assert.InDelta(t, 100.0, buckets[1].SuccessRate, 0.01)
+	})
+
+	t.Run("daily bucket", func(t *testing.T) {
+		code, buckets := get("bucket=day&" + rangeQuery)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, buckets, 1)
+
+		assert.True(t, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC).Equal(buckets[0].BucketStart))
+		assert.Equal(t, int64(7), buckets[0].Total)
+		assert.InDelta(t, 5.0/7.0*100, buckets[0].SuccessRate, 0.01)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		code, _ := get("bucket=week")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = get("from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = get("from=2025-06-01T14:00:00Z&to=2025-06-01T10:00:00Z")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
 }
 
 func TestRetryWebhookDeliveries(t *testing.T) {
@@ -578,4 +1605,523 @@ func TestRetryWebhookDeliveries(t *testing.T) {
 
 	assert.True(t, response.Success)
 	assert.Contains(t, response.Message, "retry initiated")
-}
\ No newline at end of file
+}
+
+func TestRetryWebhookDeliveriesForWebhook(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	received := make(chan string, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	for _, id := range []string{"webhook-a", "webhook-b"} {
+		webhook := models.WebhookEndpoint{
+			ID:             id,
+			Name:           id,
+			URL:            receiver.URL + "/" + id,
+			Secret:         "secret",
+			EventTypes:     []string{"test.event"},
+			Enabled:        true,
+			MaxRetries:     1,
+			TimeoutSeconds: 5,
+		}
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+
+	event := models.Event{
+		ID:       "test-event",
+		Type:     "test.event",
+		StreamID: "test-stream",
+		Source:   "test",
+		Data:     models.JSON{"test": "data"},
+	}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	for _, delivery := range []models.WebhookDelivery{
+		{ID: "delivery-a", WebhookID: "webhook-a", EventID: event.ID, Status: "failed"},
+		{ID: "delivery-b", WebhookID: "webhook-b", EventID: event.ID, Status: "failed"},
+	} {
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks/:id/retry", handler.RetryWebhookDeliveriesForWebhook)
+
+	req, _ := http.NewRequest("POST", "/webhooks/webhook-a/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			WebhookID string `json:"webhook_id"`
+			Queued    int    `json:"queued"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, "webhook-a", response.Data.WebhookID)
+	assert.Equal(t, 1, response.Data.Queued)
+
+	require.NoError(t, handler.eventManager.GetWebhookDeliveryService().Shutdown(context.Background()))
+
+	close(received)
+	var paths []string
+	for path := range received {
+		paths = append(paths, path)
+	}
+	assert.Equal(t, []string{"/webhook-a"}, paths)
+
+	var untouched models.WebhookDelivery
+	require.NoError(t, db.First(&untouched, "id = ?", "delivery-b").Error)
+	assert.Equal(t, "failed", untouched.Status)
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/missing/retry", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestReconcileWebhookDeliveries(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	received := make(chan string, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Event-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "webhook-1",
+		Name:           "Reconcile Webhook",
+		URL:            receiver.URL,
+		Secret:         "secret",
+		EventTypes:     []string{"test.event"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 5,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	event := models.Event{
+		ID:       "test-event",
+		Type:     "test.event",
+		StreamID: "test-stream",
+		Source:   "test",
+		Data:     models.JSON{"test": "data"},
+	}
+	require.NoError(t, db.CreateEventWithSequence(&event))
+
+	future := time.Now().Add(time.Hour)
+	for _, delivery := range []models.WebhookDelivery{
+		{ID: "delivery-stuck", WebhookID: webhook.ID, EventID: event.ID, Status: "pending", AttemptCount: 1},
+		{ID: "delivery-exhausted", WebhookID: webhook.ID, EventID: event.ID, Status: "pending", AttemptCount: 3},
+		{ID: "delivery-scheduled", WebhookID: webhook.ID, EventID: event.ID, Status: "pending", AttemptCount: 1, NextRetry: &future},
+		{ID: "delivery-recent", WebhookID: webhook.ID, EventID: event.ID, Status: "pending"},
+	} {
+		require.NoError(t, db.Create(&delivery).Error)
+	}
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).
+		Where("id <> ?", "delivery-recent").
+		UpdateColumn("updated_at", time.Now().Add(-time.Hour)).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks/deliveries/reconcile", handler.ReconcileWebhookDeliveries)
+
+	t.Run("invalid older_than", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/deliveries/reconcile?older_than=soon", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	req, _ := http.NewRequest("POST", "/webhooks/deliveries/reconcile?older_than=10m", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Requeued int `json:"requeued"`
+			Failed   int `json:"failed"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, 1, response.Data.Requeued)
+	assert.Equal(t, 1, response.Data.Failed)
+
+	require.NoError(t, handler.eventManager.GetWebhookDeliveryService().Shutdown(context.Background()))
+
+	close(received)
+	var ids []string
+	for id := range received {
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []string{"test-event"}, ids)
+
+	statuses := map[string]string{
+		"delivery-stuck":     "success",
+		"delivery-exhausted": "failed",
+		"delivery-scheduled": "pending",
+		"delivery-recent":    "pending",
+	}
+	for id, status := range statuses {
+		var delivery models.WebhookDelivery
+		require.NoError(t, db.First(&delivery, "id = ?", id).Error)
+		assert.Equal(t, status, delivery.Status, id)
+	}
+}
+
+func TestRotateWebhookSecret(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	for _, webhook := range []models.WebhookEndpoint{
+		{ID: "webhook-rotating", Name: "Rotating", URL: "https://example.com/a", Secret: "old-secret", SecretNext: "new-secret", EventTypes: []string{"test.event"}},
+		{ID: "webhook-steady", Name: "Steady", URL: "https://example.com/b", Secret: "old-secret", EventTypes: []string{"test.event"}},
+	} {
+		require.NoError(t, db.Create(&webhook).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks/:id/rotate-secret", handler.RotateWebhookSecret)
+
+	tests := []struct {
+		name         string
+		webhookID    string
+		expectedCode int
+	}{
+		{name: "promotes next secret", webhookID: "webhook-rotating", expectedCode: http.StatusOK},
+		{name: "nothing left to promote", webhookID: "webhook-rotating", expectedCode: http.StatusBadRequest},
+		{name: "no rotation in progress", webhookID: "webhook-steady", expectedCode: http.StatusBadRequest},
+		{name: "unknown webhook", webhookID: "missing", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/webhooks/"+tt.webhookID+"/rotate-secret", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	var rotated models.WebhookEndpoint
+	require.NoError(t, db.First(&rotated, "id = ?", "webhook-rotating").Error)
+	assert.Equal(t, "new-secret", rotated.Secret)
+	assert.Empty(t, rotated.SecretNext)
+
+	var steady models.WebhookEndpoint
+	require.NoError(t, db.First(&steady, "id = ?", "webhook-steady").Error)
+	assert.Equal(t, "old-secret", steady.Secret)
+}
+
+// memoryCache is a map-backed cache.Client
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[key], nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryCache) Close() error {
+	return nil
+}
+
+func TestGetWebhookCache(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	handler.cache = &memoryCache{values: make(map[string]string)}
+
+	webhook := models.WebhookEndpoint{
+		ID:         "webhook-cached",
+		Name:       "Cached Webhook",
+		URL:        "https://example.com/webhook",
+		Secret:     "secret",
+		EventTypes: []string{"test.event"},
+		Enabled:    true,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/:id", handler.GetWebhook)
+	router.PUT("/webhooks/:id", handler.UpdateWebhook)
+	router.DELETE("/webhooks/:id", handler.DeleteWebhook)
+
+	getName := func(t *testing.T) string {
+		req, _ := http.NewRequest("GET", "/webhooks/"+webhook.ID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data models.WebhookEndpoint `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data.Name
+	}
+
+	assert.Equal(t, webhook.Name, getName(t))
+
+	// Changed behind the handler's back, so only a cache hit returns the old name
+	require.NoError(t, db.Model(&models.WebhookEndpoint{}).Where("id = ?", webhook.ID).Update("name", "Changed Directly").Error)
+	assert.Equal(t, webhook.Name, getName(t))
+
+	req, _ := http.NewRequest("PUT", "/webhooks/"+webhook.ID, bytes.NewBufferString(`{"name": "Updated Webhook"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "Updated Webhook", getName(t))
+
+	req, _ = http.NewRequest("DELETE", "/webhooks/"+webhook.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/webhooks/"+webhook.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Run("nil cache", func(t *testing.T) {
+		handler.cache = nil
+		require.NoError(t, db.Unscoped().Model(&models.WebhookEndpoint{}).Where("id = ?", webhook.ID).Update("deleted_at", nil).Error)
+
+		assert.Equal(t, "Updated Webhook", getName(t))
+	})
+}
+
+func TestPauseAndResumeWebhookEventTypes(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "webhook-1",
+		Name:           "Test Webhook",
+		URL:            "https://example.com/webhook",
+		Secret:         "secret123",
+		EventTypes:     []string{"order.created", "order.refunded", "user.created"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 30,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks/:id/pause", handler.PauseWebhookEventTypes)
+	router.POST("/webhooks/:id/resume", handler.ResumeWebhookEventTypes)
+
+	send := func(path string, eventTypes []string) int {
+		body, _ := json.Marshal(models.WebhookEventTypesRequest{EventTypes: eventTypes})
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	pausedTypes := func() []string {
+		var stored models.WebhookEndpoint
+		require.NoError(t, db.First(&stored, "id = ?", "webhook-1").Error)
+		return stored.PausedEventTypes
+	}
+
+	require.Equal(t, http.StatusOK, send("/webhooks/webhook-1/pause", []string{"order.refunded", "user.created"}))
+	assert.Equal(t, []string{"order.refunded", "user.created"}, pausedTypes())
+
+	// Pausing again doesn't duplicate entries
+	require.Equal(t, http.StatusOK, send("/webhooks/webhook-1/pause", []string{"user.created", "user.created"}))
+	assert.Equal(t, []string{"order.refunded", "user.created"}, pausedTypes())
+
+	require.Equal(t, http.StatusOK, send("/webhooks/webhook-1/resume", []string{"user.created"}))
+	assert.Equal(t, []string{"order.refunded"}, pausedTypes())
+
+	// Subscriptions are unchanged throughout
+	var stored models.WebhookEndpoint
+	require.NoError(t, db.First(&stored, "id = ?", "webhook-1").Error)
+	assert.Equal(t, webhook.EventTypes, stored.EventTypes)
+
+	assert.Equal(t, http.StatusBadRequest, send("/webhooks/webhook-1/pause", nil))
+	assert.Equal(t, http.StatusNotFound, send("/webhooks/missing/pause", []string{"order.created"}))
+}
+
+func TestGetWebhookPayloadSchema(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/payload-schema", handler.GetWebhookPayloadSchema)
+
+	req, _ := http.NewRequest("GET", "/webhooks/payload-schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var schema struct {
+		Schema     string                     `json:"$schema"`
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &schema)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema.Schema)
+	assert.Equal(t, "object", schema.Type)
+	for _, field := range []string{"event_id", "event_type", "stream_id", "source", "data", "timestamp", "sequence_number"} {
+		assert.Contains(t, schema.Properties, field)
+		assert.Contains(t, schema.Required, field)
+	}
+}
+
+func TestGetWebhooksETag(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	webhook := models.WebhookEndpoint{
+		ID:             "webhook-1",
+		Name:           "Webhook 1",
+		URL:            "https://example.com/webhook1",
+		Secret:         "secret1",
+		EventTypes:     []string{"user.created"},
+		Enabled:        true,
+		MaxRetries:     3,
+		TimeoutSeconds: 30,
+	}
+	require.NoError(t, db.Create(&webhook).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks", handler.GetWebhooks)
+	router.PUT("/webhooks/:id", handler.UpdateWebhook)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/webhooks", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	t.Run("unchanged list returns 304", func(t *testing.T) {
+		w := get(etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		w = get(`"other", W/` + etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("mutation changes the ETag", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/webhooks/webhook-1", bytes.NewBufferString(`{"name":"Renamed"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = get(etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), "Renamed")
+	})
+}
+
+func TestGetWebhookDeliveryAttempts(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	delivery := models.WebhookDelivery{
+		ID:        "delivery-1",
+		WebhookID: "webhook-1",
+		EventID:   "event-1",
+		Status:    "success",
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+
+	start := time.Now().Add(-time.Minute)
+	attempts := []models.WebhookDeliveryAttempt{
+		{ID: "att-2", DeliveryID: delivery.ID, Attempt: 2, Success: true, StatusCode: 200, CreatedAt: start.Add(time.Second)},
+		{ID: "att-1", DeliveryID: delivery.ID, Attempt: 1, StatusCode: 503, ErrorMessage: "webhook returned status 503", CreatedAt: start},
+	}
+	for _, attempt := range attempts {
+		require.NoError(t, db.Create(&attempt).Error)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/webhooks/deliveries/:delivery_id/attempts", handler.GetWebhookDeliveryAttempts)
+
+	req, _ := http.NewRequest("GET", "/webhooks/deliveries/delivery-1/attempts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []models.WebhookDeliveryAttempt `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, 1, response.Data[0].Attempt)
+	assert.Equal(t, 503, response.Data[0].StatusCode)
+	assert.Equal(t, 2, response.Data[1].Attempt)
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/webhooks/deliveries/missing/attempts", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"goapitemplate/internal/events"
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait bounds how long a single write to the client may take
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long the client may go without answering a ping
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be shorter than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+	// wsMaxMessageSize limits subscribe messages from the client
+	wsMaxMessageSize = 4096
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Origins are already enforced by the CORS middleware
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Summary Subscribe to Events over WebSocket
+// @Description Upgrade to a WebSocket and receive published events live. Send {"action": "subscribe", "event_types": [...]} to choose event types ("*" for all); matching events are pushed as {"type": "event", "event": {...}}.
+// @Tags events
+// @Success 101
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/events/ws [get]
+func (h *Handler) StreamEventsWebSocket(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already responded with an error status
+		h.logger.WithError(err).Warn("WebSocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	sub := h.eventManager.Watch()
+	defer sub.Close()
+
+	replies := make(chan models.StreamMessage, 1)
+	readerDone := make(chan struct{})
+	writerDone := make(chan struct{})
+	defer close(writerDone)
+
+	go h.readWebSocket(conn, sub, replies, readerDone, writerDone)
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := writeWebSocketJSON(conn, models.StreamMessage{Type: "event", Event: &event}); err != nil {
+				return
+			}
+		case reply := <-replies:
+			if err := writeWebSocketJSON(conn, reply); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-readerDone:
+			return
+		}
+	}
+}
+
+// readWebSocket handles subscribe messages from the client until the
+// connection fails or the writer stops. Replies go through the writer since a
+// connection supports only one concurrent writer.
+func (h *Handler) readWebSocket(conn *websocket.Conn, sub *events.Subscription, replies chan<- models.StreamMessage, readerDone chan<- struct{}, writerDone <-chan struct{}) {
+	defer close(readerDone)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg models.StreamSubscribeMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.WithError(err).Debug("WebSocket closed unexpectedly")
+			}
+			return
+		}
+
+		var reply models.StreamMessage
+		switch msg.Action {
+		case "subscribe":
+			if len(msg.EventTypes) == 0 {
+				reply = models.StreamMessage{Type: "error", Error: "event_types is required"}
+				break
+			}
+			sub.SetEventTypes(msg.EventTypes)
+			reply = models.StreamMessage{Type: "subscribed", EventTypes: msg.EventTypes}
+		case "unsubscribe":
+			sub.SetEventTypes(nil)
+			reply = models.StreamMessage{Type: "unsubscribed"}
+		default:
+			reply = models.StreamMessage{Type: "error", Error: "unknown action: " + msg.Action}
+		}
+
+		select {
+		case replies <- reply:
+		case <-writerDone:
+			return
+		}
+	}
+}
+
+func writeWebSocketJSON(conn *websocket.Conn, msg models.StreamMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(msg)
+}
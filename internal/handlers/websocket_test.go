@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events/ws", handler.StreamEventsWebSocket)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	readMessage := func(t *testing.T, timeout time.Duration) (models.StreamMessage, error) {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		var msg models.StreamMessage
+		err := conn.ReadJSON(&msg)
+		return msg, err
+	}
+
+	// Invalid messages are answered with an error
+	err = conn.WriteJSON(models.StreamSubscribeMessage{Action: "subscribe"})
+	require.NoError(t, err)
+	msg, err := readMessage(t, 2*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "error", msg.Type)
+
+	err = conn.WriteJSON(models.StreamSubscribeMessage{Action: "subscribe", EventTypes: []string{"user.created"}})
+	require.NoError(t, err)
+	msg, err = readMessage(t, 2*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "subscribed", msg.Type)
+	assert.Equal(t, []string{"user.created"}, msg.EventTypes)
+
+	ctx := context.Background()
+	err = handler.eventManager.Publish(ctx, "user-1", "user.updated", "test", map[string]interface{}{"n": 1})
+	require.NoError(t, err)
+	err = handler.eventManager.Publish(ctx, "user-1", "user.created", "test", map[string]interface{}{"n": 2})
+	require.NoError(t, err)
+
+	// Only the matching event is pushed
+	msg, err = readMessage(t, 2*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "event", msg.Type)
+	require.NotNil(t, msg.Event)
+	assert.Equal(t, "user.created", msg.Event.Type)
+	assert.Equal(t, "user-1", msg.Event.StreamID)
+
+	err = handler.eventManager.Publish(ctx, "user-1", "user.deleted", "test", nil)
+	require.NoError(t, err)
+
+	_, err = readMessage(t, 200*time.Millisecond)
+	assert.Error(t, err, "no further events should be received")
+
+	// Disconnecting removes the subscription
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		return handler.eventManager.SubscriptionCount() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,20 @@
+package logging
+
+import (
+	"goapitemplate/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Setup applies the configured level and format to the standard logrus logger
+// and returns it for injection into the handler, event manager and webhook
+// delivery service. The request logging middleware writes to the standard
+// logger as well, and config reloads (config.ApplyLogging) update it, so every
+// component logs with the same settings.
+func Setup(cfg config.LoggingConfig) *logrus.Logger {
+	logger := logrus.StandardLogger()
+	if err := config.ApplyLogging(cfg); err != nil {
+		logger.WithError(err).Warn("Invalid logging configuration, keeping current level")
+	}
+	return logger
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"goapitemplate/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	std := logrus.StandardLogger()
+	originalLevel, originalFormatter, originalOut := std.Level, std.Formatter, std.Out
+	defer func() {
+		std.SetLevel(originalLevel)
+		std.SetFormatter(originalFormatter)
+		std.SetOutput(originalOut)
+	}()
+
+	logger := Setup(config.LoggingConfig{Level: "warn", Format: "json"})
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	logger.Info("should be suppressed")
+	logger.Warn("should be emitted")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, "should be emitted", entry["msg"])
+
+	t.Run("text format", func(t *testing.T) {
+		logger := Setup(config.LoggingConfig{Level: "info", Format: "text"})
+		buf.Reset()
+
+		logger.Info("plain text")
+		assert.Contains(t, buf.String(), `msg="plain text"`)
+		assert.False(t, json.Valid(buf.Bytes()))
+	})
+}
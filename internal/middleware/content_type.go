@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJSON rejects POST, PUT and PATCH requests with a body that isn't
+// JSON with 415 Unsupported Media Type, instead of leaving clients to puzzle
+// over binding errors. application/json and structured-syntax JSON types such
+// as application/cloudevents+json are accepted, along with any extra media
+// types given. Requests without a body pass through.
+func RequireJSON(extra ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil && (isJSONMediaType(mediaType) || containsMediaType(extra, mediaType)) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, models.APIResponse{
+			Success: false,
+			Error:   "Content-Type must be application/json",
+		})
+	}
+}
+
+// isNDJSON reports whether the request body is newline-delimited JSON, a
+// stream of documents read as it arrives rather than a single one
+func isNDJSON(c *gin.Context) bool {
+	return c.ContentType() == "application/x-ndjson"
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+func containsMediaType(mediaTypes []string, mediaType string) bool {
+	for _, t := range mediaTypes {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestDuration, httpRequestsTotal)
+}
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths
+// can't blow up the number of series
+const unmatchedRoute = "unmatched"
+
+// Metrics records the duration and count of each request in Prometheus,
+// labeled by method, route template and status code. Serve them by
+// registering promhttp.Handler() on the router.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+	}
+}
@@ -1,20 +1,33 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"io"
 	"net/http"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"goapitemplate/internal/config"
+	"goapitemplate/pkg/id"
+	"goapitemplate/pkg/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		logrus.WithFields(logrus.Fields{
+			"request_id":   param.Keys["request_id"],
 			"status_code":  param.StatusCode,
 			"latency":      param.Latency,
 			"client_ip":    param.ClientIP,
@@ -28,48 +41,112 @@ func Logger() gin.HandlerFunc {
 	})
 }
 
+// LoggerWithConfig is like Logger, but with cfg.Details it also logs each
+// request's headers and JSON body, with sensitive values replaced by ***
+func LoggerWithConfig(cfg config.RequestLogConfig) gin.HandlerFunc {
+	if !cfg.Details {
+		return Logger()
+	}
+
+	redactor := newRedactor(cfg.RedactHeaders, cfg.RedactFields)
+	return func(c *gin.Context) {
+		start := time.Now()
+		headers := redactor.headers(c.Request.Header)
+		body := redactor.requestBody(c)
+
+		c.Next()
+
+		fields := logrus.Fields{
+			"request_id":  c.GetString("request_id"),
+			"status_code": c.Writer.Status(),
+			"latency":     time.Since(start),
+			"client_ip":   c.ClientIP(),
+			"method":      c.Request.Method,
+			"path":        c.Request.URL.Path,
+			"user_agent":  c.Request.UserAgent(),
+			"error":       c.Errors.ByType(gin.ErrorTypePrivate).String(),
+			"body_size":   c.Writer.Size(),
+			"headers":     headers,
+		}
+		if body != nil {
+			fields["body"] = body
+		}
+		logrus.WithFields(fields).Info("HTTP Request")
+	}
+}
+
+// Recovery turns a panic into a 500 APIResponse carrying the request ID, and
+// logs the panic with its stack trace
 func Recovery() gin.HandlerFunc {
-	return gin.RecoveryWithWriter(gin.DefaultErrorWriter, func(c *gin.Context, recovered interface{}) {
-		logrus.WithField("panic", recovered).Error("Panic recovered")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+	// Log through logrus only, instead of gin's own stack dump
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		requestID := c.GetString("request_id")
+		logrus.WithFields(logrus.Fields{
+			"panic":      recovered,
+			"request_id": requestID,
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"stack":      string(debug.Stack()),
+		}).Error("Panic recovered")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.APIResponse{
+			Success:   false,
+			Error:     "Internal server error",
+			Code:      "INTERNAL",
+			RequestID: requestID,
 		})
 	})
 }
 
 func CORS(corsConfig config.CORSConfig) gin.HandlerFunc {
+	return CORSForRoutes(corsConfig, nil)
+}
+
+// RouteLister lists the routes registered on a router, such as the Routes
+// method of a *gin.Engine
+type RouteLister func() gin.RoutesInfo
+
+// CORSForRoutes is like CORS, but answers preflight requests with only the
+// allowed methods that are registered for the requested path
+func CORSForRoutes(corsConfig config.CORSConfig, routes RouteLister) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		policy := corsPolicyFor(corsConfig, c.Request.URL.Path)
 		origin := c.Request.Header.Get("Origin")
-		
+
 		// Check if origin is allowed
-		if len(corsConfig.AllowedOrigins) > 0 && !contains(corsConfig.AllowedOrigins, "*") {
-			if !contains(corsConfig.AllowedOrigins, origin) {
+		if len(policy.AllowedOrigins) > 0 && !contains(policy.AllowedOrigins, "*") {
+			if !contains(policy.AllowedOrigins, origin) {
 				c.AbortWithStatus(http.StatusForbidden)
 				return
 			}
 		}
 
-		// Set CORS headers
-		if contains(corsConfig.AllowedOrigins, "*") {
+		// Set CORS headers. Browsers reject "*" on credentialed requests, so
+		// the request origin is echoed back instead.
+		if contains(policy.AllowedOrigins, "*") && !policy.AllowCredentials {
 			c.Header("Access-Control-Allow-Origin", "*")
 		} else if origin != "" {
 			c.Header("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
 		}
 
-		if corsConfig.AllowCredentials {
+		if policy.AllowCredentials {
 			c.Header("Access-Control-Allow-Credentials", "true")
 		}
 
-		if len(corsConfig.AllowedHeaders) > 0 {
-			c.Header("Access-Control-Allow-Headers", strings.Join(corsConfig.AllowedHeaders, ", "))
+		if len(policy.AllowedHeaders) > 0 {
+			c.Header("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
 		}
 
-		if len(corsConfig.AllowedMethods) > 0 {
-			c.Header("Access-Control-Allow-Methods", strings.Join(corsConfig.AllowedMethods, ", "))
+		methods := policy.AllowedMethods
+		if c.Request.Method == "OPTIONS" && routes != nil {
+			methods = registeredMethods(routes(), c.Request.URL.Path, methods)
+		}
+		if len(methods) > 0 {
+			c.Header("Access-Control-Allow-Methods", strings.Join(methods, ", "))
 		}
 
-		if corsConfig.MaxAge > 0 {
-			c.Header("Access-Control-Max-Age", fmt.Sprintf("%d", corsConfig.MaxAge))
+		if policy.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", fmt.Sprintf("%d", policy.MaxAge))
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -81,35 +158,156 @@ func CORS(corsConfig config.CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// corsPolicyFor returns the policy for path: the route override with the
+// longest matching prefix, with empty settings filled in from corsConfig
+func corsPolicyFor(corsConfig config.CORSConfig, path string) config.CORSConfig {
+	matched := ""
+	for prefix := range corsConfig.Routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+	if matched == "" {
+		return corsConfig
+	}
+
+	policy := corsConfig.Routes[matched]
+	if len(policy.AllowedOrigins) == 0 {
+		policy.AllowedOrigins = corsConfig.AllowedOrigins
+	}
+	if len(policy.AllowedMethods) == 0 {
+		policy.AllowedMethods = corsConfig.AllowedMethods
+	}
+	if len(policy.AllowedHeaders) == 0 {
+		policy.AllowedHeaders = corsConfig.AllowedHeaders
+	}
+	if policy.MaxAge == 0 {
+		policy.MaxAge = corsConfig.MaxAge
+	}
+	return policy
+}
+
+// registeredMethods narrows allowed to the methods routed for path. A path
+// that matches no route keeps the full list; the request itself will 404.
+func registeredMethods(routes gin.RoutesInfo, path string, allowed []string) []string {
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if routeMatches(route.Path, path) {
+			registered[route.Method] = true
+		}
+	}
+	if len(registered) == 0 {
+		return allowed
+	}
+
+	var methods []string
+	for _, method := range allowed {
+		if registered[strings.ToUpper(method)] {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// routeMatches reports whether path matches a gin route pattern with :param
+// and *wildcard segments
+func routeMatches(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
 func RateLimit(maxRequests int, window time.Duration) gin.HandlerFunc {
-	type client struct {
-		requests int
-		window   time.Time
+	limiter := newRateLimiter()
+
+	return func(c *gin.Context) {
+		limiter.handle(c, maxRequests, window)
 	}
+}
+
+// rateLimiter counts requests per client IP in fixed windows
+type rateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*rateLimitClient
+}
 
-	clients := make(map[string]*client)
+type rateLimitClient struct {
+	requests int
+	window   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{clients: make(map[string]*rateLimitClient)}
+}
 
+// allow records a request from ip and reports whether it is within the limit
+func (l *rateLimiter) allow(ip string, maxRequests int, window time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	clientData, exists := l.clients[ip]
+	if !exists || time.Since(clientData.window) > window {
+		l.clients[ip] = &rateLimitClient{
+			requests: 1,
+			window:   time.Now(),
+		}
+		return true
+	}
+
+	clientData.requests++
+	return clientData.requests <= maxRequests
+}
+
+func (l *rateLimiter) handle(c *gin.Context, maxRequests int, window time.Duration) {
+	if !l.allow(c.ClientIP(), maxRequests, window) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "Rate limit exceeded",
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// AdminAuth restricts a route to callers presenting the admin API key in the
+// X-Admin-Key header or as a bearer token. An empty key disables the route.
+func AdminAuth(apiKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		if clientData, exists := clients[ip]; exists {
-			if time.Since(clientData.window) > window {
-				clientData.requests = 1
-				clientData.window = time.Now()
-			} else {
-				clientData.requests++
-				if clientData.requests > maxRequests {
-					c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-						"error": "Rate limit exceeded",
-					})
-					return
-				}
-			}
-		} else {
-			clients[ip] = &client{
-				requests: 1,
-				window:   time.Now(),
-			}
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Admin API is disabled",
+			})
+			return
+		}
+
+		key := c.GetHeader("X-Admin-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized",
+			})
+			return
 		}
 
 		c.Next()
@@ -129,16 +327,7 @@ func RequestID() gin.HandlerFunc {
 }
 
 func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
-	for i := range result {
-		result[i] = charset[i%len(charset)]
-	}
-	return string(result)
+	return time.Now().Format("20060102150405") + "-" + id.String(8)
 }
 
 func contains(slice []string, item string) bool {
@@ -148,4 +337,80 @@ func contains(slice []string, item string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// Tracing starts a server span for each request, continuing any trace context
+// sent by the caller, and stores it in the request context
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx, span := otel.Tracer("goapitemplate/internal/middleware").Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// ReloadableConfig holds middleware settings that can be swapped while the
+// server is running. Each request reads the current values atomically.
+type ReloadableConfig struct {
+	cors      atomic.Pointer[config.CORSConfig]
+	rateLimit atomic.Pointer[config.RateLimitConfig]
+}
+
+// NewReloadableConfig creates reloadable middleware settings from cfg
+func NewReloadableConfig(cfg *config.Config) *ReloadableConfig {
+	r := &ReloadableConfig{}
+	r.Apply(cfg)
+	return r
+}
+
+// Apply swaps in the CORS and rate limit settings from cfg
+func (r *ReloadableConfig) Apply(cfg *config.Config) {
+	cors := cfg.CORS
+	rateLimit := cfg.RateLimit
+	r.cors.Store(&cors)
+	r.rateLimit.Store(&rateLimit)
+}
+
+// CORS is like the CORSForRoutes middleware but uses the current settings
+func (r *ReloadableConfig) CORS(routes RouteLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		CORSForRoutes(*r.cors.Load(), routes)(c)
+	}
+}
+
+// RateLimit is like the RateLimit middleware but uses the current settings,
+// including whether rate limiting is enabled at all
+func (r *ReloadableConfig) RateLimit() gin.HandlerFunc {
+	limiter := newRateLimiter()
+
+	return func(c *gin.Context) {
+		rateLimit := r.rateLimit.Load()
+		if !rateLimit.Enabled {
+			c.Next()
+			return
+		}
+
+		limiter.handle(c, rateLimit.MaxRequests, time.Duration(rateLimit.WindowMinutes)*time.Minute)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedValue replaces sensitive values in logs
+const redactedValue = "***"
+
+// maxLoggedBodyBytes is the largest request body that is logged; bigger
+// bodies are left out rather than logged in part
+const maxLoggedBodyBytes = 64 << 10
+
+// Headers and JSON fields that are always redacted
+var (
+	defaultRedactHeaders = []string{"Authorization", "X-API-Key", "X-Admin-Key", "Cookie", "Proxy-Authorization"}
+	defaultRedactFields  = []string{"secret", "secret_next", "client_secret", "password", "token", "access_token", "refresh_token", "key_pem"}
+)
+
+// redactor masks sensitive headers and JSON body fields, matching names
+// case-insensitively
+type redactor struct {
+	headerNames map[string]bool
+	fieldNames  map[string]bool
+}
+
+func newRedactor(headers, fields []string) *redactor {
+	r := &redactor{headerNames: map[string]bool{}, fieldNames: map[string]bool{}}
+	for _, name := range append(append([]string{}, defaultRedactHeaders...), headers...) {
+		r.headerNames[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range append(append([]string{}, defaultRedactFields...), fields...) {
+		r.fieldNames[strings.ToLower(name)] = true
+	}
+	return r
+}
+
+// headers returns a copy of h for logging with sensitive values redacted
+func (r *redactor) headers(h http.Header) map[string]string {
+	logged := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.headerNames[http.CanonicalHeaderKey(name)] {
+			logged[name] = redactedValue
+			continue
+		}
+		logged[name] = strings.Join(values, ", ")
+	}
+	return logged
+}
+
+// requestBody reads the JSON request body for logging, leaving it intact
+// for the handler. It returns nil for non-JSON, malformed or oversized bodies,
+// and for NDJSON streams, which the handler reads as they arrive.
+func (r *redactor) requestBody(c *gin.Context) interface{} {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") || isNDJSON(c) {
+		return nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBodyBytes+1))
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(raw), c.Request.Body), c.Request.Body}
+	if err != nil || len(raw) == 0 || len(raw) > maxLoggedBodyBytes {
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil
+	}
+	return r.value(body)
+}
+
+// value redacts sensitive fields in a decoded JSON value, at any depth
+func (r *redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.fieldNames[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = r.value(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+	}
+	return v
+}
+
+// readCloser reads from a replayed body while closing the original one
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"goapitemplate/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives each request a context deadline. The handler chain runs on
+// the request goroutine with its response buffered; if the deadline passed
+// before it returned, the buffered response is dropped and a 503 is sent
+// instead. Handlers should stop work once the context is done, since the 503
+// waits for them; their database queries run with it for that reason. A
+// state-changing request (anything but GET, HEAD and OPTIONS) that succeeded
+// regardless keeps its response, since its changes are committed and a 503
+// would invite a retry that repeats them. Streaming (text/event-stream),
+// WebSocket upgrade and NDJSON upload requests are exempt, as is everything
+// when timeout is not positive.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || isStreamingRequest(c) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		original := c.Writer
+		tw := newTimeoutWriter(original)
+		c.Writer = tw
+
+		// The gin.Context is only ever used from this goroutine; gin reuses
+		// it for another request once the middleware returns
+		func() {
+			// Restored even on panic so Recovery writes to the real response
+			defer func() { c.Writer = original }()
+			c.Next()
+		}()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !(changesState(c.Request.Method) && tw.succeeded()) {
+			tw.discard()
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.APIResponse{
+				Success: false,
+				Error:   "Request timed out",
+			})
+			return
+		}
+		tw.flush()
+	}
+}
+
+// isStreamingRequest reports whether the request opens a long-lived stream,
+// a streamed download (export routes) or a streamed upload (NDJSON), which
+// must not be buffered or cut off partway
+func isStreamingRequest(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream") ||
+		strings.EqualFold(c.GetHeader("Upgrade"), "websocket") ||
+		strings.HasSuffix(c.FullPath(), "/export") ||
+		isNDJSON(c)
+}
+
+// changesState reports whether a request with the given method may change
+// server state
+func changesState(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// timeoutWriter buffers a handler's response so it can be dropped if the
+// request times out before the handler finishes. Writes after the response
+// is dropped fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu        sync.Mutex
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	written   bool
+	discarded bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+		status:         http.StatusOK,
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.discarded {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// succeeded reports whether the handler wrote a non-error response
+func (w *timeoutWriter) succeeded() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written && w.status < http.StatusBadRequest
+}
+
+// flush copies the buffered response to the underlying writer
+func (w *timeoutWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dst := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		dst[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// discard drops the buffered response and any later writes
+func (w *timeoutWriter) discard() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.discarded = true
+	w.body.Reset()
+}
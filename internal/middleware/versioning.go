@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion tags responses from a versioned route group with an API-Version
+// header and stores the version in the gin context under "api_version"
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		c.Header("API-Version", version)
+		c.Next()
+	}
+}
+
+// Deprecation describes an endpoint that is being phased out
+type Deprecation struct {
+	// Since is when the endpoint was deprecated; zero means "now"
+	Since time.Time
+	// Sunset is when the endpoint will stop working; zero when not yet planned
+	Sunset time.Time
+	// Successor is the path or URL of the replacement endpoint, if any
+	Successor string
+}
+
+// Deprecated marks a route as deprecated. The handler keeps working, but
+// responses carry a Deprecation header (RFC 9745) and, when set, Sunset
+// (RFC 8594) and a successor-version Link so clients can migrate.
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since := d.Since
+		if since.IsZero() {
+			since = time.Now()
+		}
+		c.Header("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor))
+		}
+
+		c.Next()
+	}
+}
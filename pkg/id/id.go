@@ -0,0 +1,33 @@
+// Package id generates random identifiers
+package id
+
+import (
+	"crypto/rand"
+)
+
+// charset is the alphabet of String; 62 characters, so IDs are URL- and
+// header-safe
+const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// maxUnbiased is the largest multiple of len(charset) that fits in a byte;
+// bytes at or above it are discarded so every character is equally likely
+const maxUnbiased = 256 - 256%len(charset)
+
+// String returns length random alphanumeric characters from crypto/rand
+func String(length int) string {
+	result := make([]byte, 0, length)
+	random := make([]byte, length)
+	for len(result) < length {
+		rand.Read(random)
+		for _, b := range random {
+			if int(b) >= maxUnbiased {
+				continue
+			}
+			result = append(result, charset[int(b)%len(charset)])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result)
+}
@@ -0,0 +1,31 @@
+package id
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	seen := make(map[string]bool, 1000)
+	for i := 0; i < 1000; i++ {
+		s := String(8)
+		require.Len(t, s, 8)
+		require.False(t, seen[s], "duplicate string %s", s)
+		seen[s] = true
+
+		for _, r := range s {
+			assert.True(t, strings.ContainsRune(charset, r), "unexpected character %q", r)
+		}
+	}
+
+	assert.Empty(t, String(0))
+}
+
+func BenchmarkString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		String(16)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 
@@ -12,14 +14,25 @@ import (
 type Event struct {
 	ID            string    `gorm:"primaryKey" json:"id"`
 	Type          string    `gorm:"not null;index" json:"type"`
-	StreamID      string    `gorm:"not null;index" json:"stream_id"` // For grouping related events
-	Source        string    `gorm:"not null" json:"source"`
+	StreamID      string    `gorm:"not null;index;uniqueIndex:idx_events_stream_sequence,priority:1" json:"stream_id"` // For grouping related events
+	Source        string    `gorm:"not null;uniqueIndex:idx_events_cloudevent,priority:1" json:"source"`
 	Data          JSON      `gorm:"type:json" json:"data"`
+	Metadata      JSON      `gorm:"type:json" json:"metadata,omitempty"` // e.g. correlation_id, causation_id
+	SchemaVersion int       `gorm:"not null;default:1" json:"schema_version"` // Version of the Data shape for this event type
 	Timestamp     time.Time `gorm:"not null;index" json:"timestamp"`
 	CreatedAt     time.Time `json:"created_at"`
 	
-	// Event ordering within stream
-	SequenceNumber int64 `gorm:"not null;index:idx_stream_sequence" json:"sequence_number"`
+	// Event ordering within stream; unique per stream so two appends racing
+	// for the same number can't both be stored
+	SequenceNumber int64 `gorm:"not null;uniqueIndex:idx_events_stream_sequence,priority:2" json:"sequence_number"`
+
+	// CloudEventID is the id of the CloudEvent the event was created from,
+	// unique per source as CloudEvents requires; nil for other events
+	CloudEventID *string `gorm:"uniqueIndex:idx_events_cloudevent,priority:2" json:"cloudevent_id,omitempty"`
+
+	// ExpectedSequence, when set, is the sequence number the event must get;
+	// saving fails if another event was appended to the stream first
+	ExpectedSequence int64 `gorm:"-" json:"-"`
 }
 
 
@@ -33,29 +46,154 @@ type WebhookEndpoint struct {
 	Enabled        bool      `gorm:"not null;default:true" json:"enabled"`
 	MaxRetries     int       `gorm:"not null;default:3" json:"max_retries"`
 	TimeoutSeconds int       `gorm:"not null;default:30" json:"timeout_seconds"`
+	LogLevel       string    `json:"log_level,omitempty"` // Delivery log verbosity: debug, info, warn, error (empty uses global level)
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Soft delete keeps the webhook's delivery history associated until purged
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// OAuth2 client-credentials settings for receivers that require a bearer token
+	OAuthTokenURL     string `gorm:"column:oauth_token_url" json:"oauth_token_url,omitempty"`
+	OAuthClientID     string `gorm:"column:oauth_client_id" json:"oauth_client_id,omitempty"`
+	OAuthClientSecret string `gorm:"column:oauth_client_secret" json:"-"`
+	OAuthScopes       string `gorm:"column:oauth_scopes" json:"oauth_scopes,omitempty"` // Space-delimited, as sent to the token endpoint
+
+	// Adds a "webhook" block (id, name) to the delivered payload; off by default
+	// so receivers don't learn about our configuration unless asked
+	IncludeWebhookInfo bool `gorm:"not null;default:false" json:"include_webhook_info"`
+
+	// Go text/template rendering the request body in place of the default
+	// payload, for receivers that expect a different shape
+	PayloadTemplate string `gorm:"type:text" json:"payload_template,omitempty"`
+
+	// Delivers each stream's events one at a time in publish order, waiting
+	// for a delivery (including its retries) to finish before the next starts
+	OrderedDelivery bool `gorm:"not null;default:false" json:"ordered_delivery"`
+
+	// HMAC hash for the X-Webhook-Signature header: sha1, sha256 or sha512
+	SignatureAlgorithm string `gorm:"not null;default:sha256" json:"signature_algorithm"`
+
+	// Caps how many delivery attempts are sent per minute; deliveries over
+	// the limit stay pending until a slot frees up. Zero means no limit.
+	MaxDeliveriesPerMinute int `gorm:"not null;default:0" json:"max_deliveries_per_minute"`
+
+	// Caps how many retries are sent per day across all of the webhook's
+	// deliveries; once used up, retries wait until the day's window ends.
+	// Zero means no limit.
+	MaxRetriesPerDay int `gorm:"not null;default:0" json:"max_retries_per_day"`
+
+	// Upcoming secret during a rotation; while set, deliveries are also
+	// signed with it in X-Webhook-Signature-Next so receivers can accept either
+	SecretNext string `json:"secret_next,omitempty"`
+
+	// Content-Type of deliveries: application/json, or
+	// application/cloudevents+json for a CloudEvents structured-mode envelope
+	ContentType string `gorm:"not null;default:application/json" json:"content_type"`
+
+	// Subscribed event types whose delivery is on hold; such events get a
+	// "paused" delivery record instead of being sent. Patterns work as in EventTypes.
+	PausedEventTypes []string `gorm:"type:json;serializer:json" json:"paused_event_types,omitempty"`
+
+	// HTTP proxy for this webhook's deliveries, overriding WEBHOOK_HTTP_PROXY
+	ProxyURL string `json:"proxy_url,omitempty"`
+
+	// TLS settings for receivers with self-signed certificates or that
+	// require mutual TLS. Certificates are verified unless InsecureSkipVerify
+	// is set; the client certificate and key are PEM-encoded.
+	InsecureSkipVerify bool   `gorm:"not null;default:false" json:"insecure_skip_verify"`
+	ClientCertPEM      string `gorm:"type:text" json:"client_cert_pem,omitempty"`
+	ClientKeyPEM       string `gorm:"type:text" json:"-"`
+
+	// HTTP method deliveries are sent with: POST, PUT or PATCH
+	Method string `gorm:"not null;default:POST" json:"method"`
+
+	// Gzips request bodies and sets Content-Encoding: gzip. Signatures are
+	// computed over the compressed bytes, as sent.
+	CompressPayload bool `gorm:"not null;default:false" json:"compress_payload"`
 }
 
 // WebhookDelivery represents a webhook delivery attempt
 type WebhookDelivery struct {
-	ID           string     `gorm:"primaryKey" json:"id"`
-	WebhookID    string     `gorm:"not null;index" json:"webhook_id"`
-	EventID      string     `gorm:"not null;index" json:"event_id"`
-	Status       string     `gorm:"not null" json:"status"` // pending, success, failed
-	AttemptCount int        `gorm:"not null;default:0" json:"attempt_count"`
-	LastAttempt  *time.Time `json:"last_attempt,omitempty"`
-	NextRetry    *time.Time `json:"next_retry,omitempty"`
-	Response     string     `json:"response,omitempty"`
-	ErrorMessage string     `json:"error_message,omitempty"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID                string     `gorm:"primaryKey" json:"id"`
+	WebhookID         string     `gorm:"not null;index" json:"webhook_id"`
+	EventID           string     `gorm:"not null;index" json:"event_id"`
+	Status            string     `gorm:"not null" json:"status"` // pending, success, failed, paused
+	AttemptCount      int        `gorm:"not null;default:0" json:"attempt_count"`
+	LastAttempt       *time.Time `json:"last_attempt,omitempty"`
+	NextRetry         *time.Time `json:"next_retry,omitempty"`
+	Response          string     `json:"response,omitempty"`
+	ResponseTruncated bool       `gorm:"not null;default:false" json:"response_truncated"` // Response exceeded the configured maximum length
+	ErrorMessage      string     `json:"error_message,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Redelivery marks deliveries created by redelivering a stored event
+	Redelivery bool `gorm:"not null;default:false" json:"redelivery"`
 	
 	// Relationships
 	Webhook *WebhookEndpoint `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"webhook,omitempty"`
 	Event   *Event           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"event,omitempty"`
 }
 
+// WebhookDeliveryAttempt records one attempt of a webhook delivery; the
+// delivery itself only keeps the outcome of the latest attempt
+type WebhookDeliveryAttempt struct {
+	ID           string    `gorm:"primaryKey" json:"id"`
+	DeliveryID   string    `gorm:"not null;index" json:"delivery_id"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	Success      bool      `gorm:"not null;default:false" json:"success"`
+	StatusCode   int       `json:"status_code,omitempty"` // Zero when the receiver gave no response
+	Response     string    `json:"response,omitempty"`    // Truncated like WebhookDelivery.Response
+	ErrorMessage string    `json:"error_message,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"` // When the attempt was sent
+}
+
+// EventTypeAlias records that Alias is another name for EventType, e.g. after
+// a rename, so webhooks subscribed to either name receive events published
+// under the other
+type EventTypeAlias struct {
+	Alias     string    `gorm:"primaryKey" json:"alias"`
+	EventType string    `gorm:"not null;index" json:"event_type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OutboxEntry marks an event whose webhook deliveries haven't been recorded
+// yet. It is written in the same transaction as the event and removed once
+// the deliveries exist, so events saved just before a crash still get
+// delivered.
+type OutboxEntry struct {
+	EventID   string    `gorm:"primaryKey" json:"event_id"`
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+	// LockedUntil is when the lease of whoever is dispatching the event
+	// runs out; until then no one else dispatches it
+	LockedUntil time.Time `gorm:"index" json:"locked_until"`
+	// Relayed records that the event store has handed the event on, e.g. to
+	// Kafka, so the outbox dispatcher doesn't do it again
+	Relayed bool `gorm:"not null;default:false" json:"relayed"`
+}
+
+// StreamRetention records how far retention cleanup has pruned a stream, so
+// the integrity check doesn't report the deleted sequences as missing
+type StreamRetention struct {
+	StreamID string `gorm:"primaryKey" json:"stream_id"`
+	// PrunedThrough is the highest sequence number deleted from the stream
+	PrunedThrough int64 `gorm:"not null" json:"pruned_through"`
+}
+
+// AuditLog records an administrative action for compliance purposes
+type AuditLog struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	Action     string    `gorm:"not null;index" json:"action"`
+	Resource   string    `gorm:"not null;index" json:"resource"`
+	ResourceID string    `gorm:"not null;index" json:"resource_id"`
+	Actor      string    `json:"actor"`
+	Details    JSON      `gorm:"type:json" json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // JSON is a custom type for handling JSON data in GORM
 type JSON map[string]interface{}
 
@@ -99,6 +237,38 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+
+	// Details maps each invalid request field to what is wrong with it
+	Details map[string]string `json:"details,omitempty"`
+
+	// Code is a machine-readable error code, e.g. INTERNAL
+	Code string `json:"code,omitempty"`
+	// RequestID identifies the request in logs, for support requests
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// PaginatedResponse wraps a page of list results with pagination metadata
+type PaginatedResponse struct {
+	Items   interface{} `json:"items"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+	Total   int64       `json:"total"`
+	HasMore bool        `json:"has_more"`
+
+	// NextCursor fetches the next page on endpoints with cursor pagination;
+	// empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPaginatedResponse builds a PaginatedResponse for a page of count items
+func NewPaginatedResponse(items interface{}, count, limit, offset int, total int64) PaginatedResponse {
+	return PaginatedResponse{
+		Items:   items,
+		Limit:   limit,
+		Offset:  offset,
+		Total:   total,
+		HasMore: int64(offset+count) < total,
+	}
 }
 
 // Event System DTOs
@@ -107,6 +277,71 @@ type CreateEventRequest struct {
 	StreamID string                 `json:"stream_id" binding:"required"`
 	Source   string                 `json:"source" binding:"required"`
 	Data     map[string]interface{} `json:"data"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // e.g. correlation_id, causation_id
+	// SchemaVersion is the version of the Data shape; defaults to 1
+	SchemaVersion int `json:"schema_version,omitempty" binding:"omitempty,min=1"`
+	// Timestamp overrides the event time, e.g. when backfilling; defaults to now
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// ExpectedSequence makes the append conditional: it fails with 409 unless
+	// the event becomes this sequence number in its stream
+	ExpectedSequence int64 `json:"expected_sequence,omitempty" binding:"omitempty,min=1"`
+}
+
+// CreateEventTypeAliasRequest maps an old event type name onto its new one
+type CreateEventTypeAliasRequest struct {
+	Alias     string `json:"alias" binding:"required,nefield=EventType,excludes=*"`
+	EventType string `json:"event_type" binding:"required,excludes=*"`
+}
+
+// CloudEventRequest is a CloudEvents 1.0 structured-mode event, accepted in
+// place of CreateEventRequest when sent as application/cloudevents+json
+type CloudEventRequest struct {
+	SpecVersion     string                 `json:"specversion" binding:"required,eq=1.0"`
+	ID              string                 `json:"id" binding:"required"`
+	Type            string                 `json:"type" binding:"required"`
+	Source          string                 `json:"source" binding:"required"`
+	Subject         string                 `json:"subject" binding:"required"` // Used as the stream ID
+	Time            *time.Time             `json:"time,omitempty"`
+	DataContentType string                 `json:"datacontenttype,omitempty" binding:"omitempty,eq=application/json"`
+	Data            map[string]interface{} `json:"data"`
+
+	// Extension attributes mirrored on delivery
+	SchemaVersion int    `json:"schemaversion,omitempty" binding:"omitempty,min=1"`
+	CorrelationID string `json:"correlationid,omitempty"`
+}
+
+type CreateEventBatchRequest struct {
+	Events []CreateEventRequest `json:"events" binding:"required,min=1,max=1000"`
+	// Atomic creates all events or none; otherwise each event is created independently
+	Atomic bool `json:"atomic"`
+}
+
+// BatchResult reports the outcome of one item in a batch operation
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+// IngestResponse summarizes an NDJSON ingest. Errors lists failed lines by
+// line number (in Index), up to a limit; Failed counts all of them.
+type IngestResponse struct {
+	Received  int           `json:"received"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Errors    []BatchResult `json:"errors"`
+}
+
+// WebhookEventTypesRequest lists event types to pause or resume on a webhook
+type WebhookEventTypesRequest struct {
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
 }
 
 type CreateWebhookRequest struct {
@@ -114,8 +349,30 @@ type CreateWebhookRequest struct {
 	URL            string   `json:"url" binding:"required,url"`
 	Secret         string   `json:"secret" binding:"required"`
 	EventTypes     []string `json:"event_types" binding:"required"`
-	MaxRetries     int      `json:"max_retries"`
-	TimeoutSeconds int      `json:"timeout_seconds"`
+	MaxRetries     int      `json:"max_retries" binding:"min=0,max=10"`      // Defaults to 3
+	TimeoutSeconds int      `json:"timeout_seconds" binding:"min=0,max=120"` // Defaults to 30
+	LogLevel       string   `json:"log_level" binding:"omitempty,oneof=debug info warn error"`
+
+	OAuth              *OAuthClientCredentials `json:"oauth,omitempty"`
+	IncludeWebhookInfo bool                    `json:"include_webhook_info"`
+	PayloadTemplate    string                  `json:"payload_template,omitempty"`
+	OrderedDelivery    bool                    `json:"ordered_delivery"`
+	SignatureAlgorithm string                  `json:"signature_algorithm,omitempty" binding:"omitempty,oneof=sha1 sha256 sha512"` // Defaults to sha256
+
+	MaxDeliveriesPerMinute int `json:"max_deliveries_per_minute" binding:"min=0"` // Zero means no limit
+	MaxRetriesPerDay       int `json:"max_retries_per_day" binding:"min=0"`       // Zero means no limit
+
+	SecretNext  string `json:"secret_next,omitempty"`
+	ContentType string `json:"content_type,omitempty" binding:"omitempty,oneof=application/json application/cloudevents+json"` // Defaults to application/json
+
+	ProxyURL string `json:"proxy_url,omitempty" binding:"omitempty,url"` // Defaults to WEBHOOK_HTTP_PROXY
+
+	InsecureSkipVerify bool               `json:"insecure_skip_verify"` // Skips receiver certificate verification; for self-signed receivers only
+	ClientCertificate  *ClientCertificate `json:"client_certificate,omitempty"`
+
+	Method string `json:"method,omitempty" binding:"omitempty,oneof=POST PUT PATCH"` // Defaults to POST
+
+	CompressPayload bool `json:"compress_payload"`
 }
 
 type UpdateWebhookRequest struct {
@@ -124,8 +381,45 @@ type UpdateWebhookRequest struct {
 	Secret         string   `json:"secret,omitempty"`
 	EventTypes     []string `json:"event_types,omitempty"`
 	Enabled        *bool    `json:"enabled,omitempty"`
-	MaxRetries     int      `json:"max_retries,omitempty"`
-	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+	MaxRetries     int      `json:"max_retries,omitempty" binding:"omitempty,min=1,max=10"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" binding:"omitempty,min=1,max=120"`
+	LogLevel       string   `json:"log_level,omitempty" binding:"omitempty,oneof=debug info warn error"`
+
+	OAuth              *OAuthClientCredentials `json:"oauth,omitempty"`
+	IncludeWebhookInfo *bool                   `json:"include_webhook_info,omitempty"`
+	PayloadTemplate    *string                 `json:"payload_template,omitempty"` // An empty string restores the default payload
+	OrderedDelivery    *bool                   `json:"ordered_delivery,omitempty"`
+	SignatureAlgorithm string                  `json:"signature_algorithm,omitempty" binding:"omitempty,oneof=sha1 sha256 sha512"`
+
+	MaxDeliveriesPerMinute *int `json:"max_deliveries_per_minute,omitempty" binding:"omitempty,min=0"` // Zero removes the limit
+	MaxRetriesPerDay       *int `json:"max_retries_per_day,omitempty" binding:"omitempty,min=0"`       // Zero removes the limit
+
+	SecretNext  *string `json:"secret_next,omitempty"` // An empty string cancels a pending rotation
+	ContentType string  `json:"content_type,omitempty" binding:"omitempty,oneof=application/json application/cloudevents+json"`
+
+	ProxyURL *string `json:"proxy_url,omitempty" binding:"omitempty,url"` // An empty string restores WEBHOOK_HTTP_PROXY
+
+	InsecureSkipVerify *bool              `json:"insecure_skip_verify,omitempty"`
+	ClientCertificate  *ClientCertificate `json:"client_certificate,omitempty"`
+
+	Method string `json:"method,omitempty" binding:"omitempty,oneof=POST PUT PATCH"`
+
+	CompressPayload *bool `json:"compress_payload,omitempty"`
+}
+
+// ClientCertificate is the PEM-encoded certificate and private key a webhook
+// presents to receivers that require mutual TLS
+type ClientCertificate struct {
+	CertPEM string `json:"cert_pem" binding:"required"`
+	KeyPEM  string `json:"key_pem" binding:"required"`
+}
+
+// OAuthClientCredentials configures OAuth2 client-credentials auth for webhook delivery
+type OAuthClientCredentials struct {
+	TokenURL     string   `json:"token_url" binding:"required,url"`
+	ClientID     string   `json:"client_id" binding:"required"`
+	ClientSecret string   `json:"client_secret" binding:"required"`
+	Scopes       []string `json:"scopes,omitempty"`
 }
 
 
@@ -135,6 +429,98 @@ type EventStreamResponse struct {
 	Count    int64   `json:"count"`
 }
 
+// StreamSummary describes a stream's size and most recent activity
+type StreamSummary struct {
+	StreamID        string    `json:"stream_id"`
+	EventCount      int64     `json:"event_count"`
+	LatestTimestamp time.Time `json:"latest_timestamp"`
+}
+
+// WebhookStats summarizes one webhook's deliveries. SuccessRate is a
+// percentage; LastDeliveryAt is the most recent attempt, unset until the
+// first one.
+type WebhookStats struct {
+	WebhookID            string       `json:"webhook_id"`
+	TotalDeliveries      int64        `json:"total_deliveries"`
+	SuccessfulDeliveries int64        `json:"successful_deliveries"`
+	FailedDeliveries     int64        `json:"failed_deliveries"`
+	PendingDeliveries    int64        `json:"pending_deliveries"`
+	SuccessRate          float64      `json:"success_rate"`
+	AverageAttempts      float64      `json:"average_attempts"`
+	LastDeliveryAt       *time.Time   `json:"last_delivery_at,omitempty"`
+	RetryBudget          *RetryBudget `json:"retry_budget,omitempty"` // For webhooks with a max_retries_per_day
+}
+
+// SequenceGap is a run of sequence numbers missing from a stream, From to To
+// inclusive
+type SequenceGap struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// StreamIntegrity reports whether a stream's sequence numbers run from 1 to
+// LatestSequence without gaps
+type StreamIntegrity struct {
+	StreamID       string        `json:"stream_id"`
+	EventCount     int64         `json:"event_count"`
+	LatestSequence int64         `json:"latest_sequence"`
+	Intact         bool          `json:"intact"`
+	Gaps           []SequenceGap `json:"gaps"`
+}
+
+// DeliveryStatsBucket summarizes webhook deliveries created within one time
+// bucket; SuccessRate is a percentage
+type DeliveryStatsBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Total       int64     `json:"total"`
+	Success     int64     `json:"success"`
+	SuccessRate float64   `json:"success_rate"`
+}
+
+// EventCountBucket counts the events whose timestamp falls within one time
+// bucket
+type EventCountBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Count       int64     `json:"count"`
+}
+
+// RetryBudget reports how much of a webhook's MaxRetriesPerDay is left.
+// ResetsAt is set while a window is running; Exhausted flags webhooks whose
+// retries are on hold until then.
+type RetryBudget struct {
+	Limit     int        `json:"limit"`
+	Used      int        `json:"used"`
+	Remaining int        `json:"remaining"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+	Exhausted bool       `json:"exhausted"`
+}
+
+// StreamSubscribeMessage is sent by WebSocket clients to choose the event
+// types they receive. Action is "subscribe" or "unsubscribe".
+type StreamSubscribeMessage struct {
+	Action     string   `json:"action"`
+	EventTypes []string `json:"event_types"`
+}
+
+// StreamMessage is sent to WebSocket clients. Type is "subscribed",
+// "unsubscribed", "event" or "error".
+type StreamMessage struct {
+	Type       string   `json:"type"`
+	EventTypes []string `json:"event_types,omitempty"`
+	Event      *Event   `json:"event,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// WebhookTestResult reports the outcome of a test delivery to a webhook
+type WebhookTestResult struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Event      Event  `json:"event"` // The sample event that was sent
+}
+
 // TableName methods for GORM
 func (Event) TableName() string {
 	return "events"
@@ -148,3 +534,7 @@ func (WebhookEndpoint) TableName() string {
 func (WebhookDelivery) TableName() string {
 	return "webhook_deliveries"
 }
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
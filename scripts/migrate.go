@@ -13,7 +13,7 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	db, err := database.New(cfg.Database)
+	db, err := database.New(cfg.Database, cfg.Logging.Level)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}